@@ -0,0 +1,353 @@
+package govaluate
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+/*
+	An exact decimal number, such as an amount of money. Expressions parsed with `NUMBERS_DECIMAL` use these for every number.
+	A decimal is an integer scaled by a power of ten - "1.50" is 150 scaled by 10^2 - so any number written in decimal is held exactly,
+	and addition, subtraction and multiplication are always exact. Division rounds (see `ParseOptions.DecimalPlaces`).
+
+	The zero value is zero. Decimals are immutable, and can be shared.
+*/
+type Decimal struct {
+	unscaled *big.Int
+
+	// the number of digits after the decimal point. Never negative.
+	scale int
+}
+
+/*
+	The largest exponent (positive or negative) a string given to `ParseDecimal` may have, so that a short string can't make an enormous number.
+*/
+const maxDecimalExponent = 10000
+
+var bigTen = big.NewInt(10)
+
+/*
+	Parses a decimal from a string such as "12", "-0.035", or "1.5e3".
+	Digits after the decimal point are kept, even if they're zeros, so "1.50" has two decimal places.
+*/
+func ParseDecimal(value string) (Decimal, error) {
+
+	var digits, exponent string
+	var scale int
+
+	mantissa := value
+
+	index := strings.IndexAny(value, "eE")
+	if index >= 0 {
+
+		mantissa, exponent = value[:index], value[index+1:]
+		if exponent == "" {
+			return Decimal{}, fmt.Errorf("Unable to parse '%s' as a decimal, its exponent is missing", value)
+		}
+	}
+
+	digits = strings.TrimLeft(mantissa, "+-")
+	if len(mantissa)-len(digits) > 1 {
+		return Decimal{}, fmt.Errorf("Unable to parse '%s' as a decimal", value)
+	}
+
+	index = strings.IndexByte(digits, '.')
+	if index >= 0 {
+		scale = len(digits) - index - 1
+		digits = digits[:index] + digits[index+1:]
+	}
+
+	if digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("Unable to parse '%s' as a decimal", value)
+	}
+
+	if exponent != "" {
+
+		power, err := strconv.Atoi(exponent)
+		if err != nil || power > maxDecimalExponent || power < -maxDecimalExponent {
+			return Decimal{}, fmt.Errorf("Unable to parse '%s' as a decimal, its exponent is invalid or too large", value)
+		}
+		scale -= power
+	}
+
+	unscaled, _ := new(big.Int).SetString(digits, 10)
+	if strings.HasPrefix(mantissa, "-") {
+		unscaled.Neg(unscaled)
+	}
+
+	ret := Decimal{unscaled: unscaled, scale: scale}
+	if scale < 0 {
+		ret = Decimal{unscaled: unscaled.Mul(unscaled, powerOfTen(-scale)), scale: 0}
+	}
+	return ret, nil
+}
+
+/*
+	Returns this decimal as a string, with every digit it has after the decimal point, such as "-1.50".
+*/
+func (this Decimal) String() string {
+
+	digits := new(big.Int).Abs(this.integer()).String()
+	sign := ""
+
+	if this.integer().Sign() < 0 {
+		sign = "-"
+	}
+
+	if this.scale == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= this.scale {
+		digits = strings.Repeat("0", this.scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-this.scale] + "." + digits[len(digits)-this.scale:]
+}
+
+/*
+	Returns the float64 nearest to this decimal.
+*/
+func (this Decimal) Float64() float64 {
+
+	value, _ := this.Rat().Float64()
+	return value
+}
+
+/*
+	Returns this decimal as a fraction.
+*/
+func (this Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(this.integer(), powerOfTen(this.scale))
+}
+
+/*
+	Compares this decimal to [other], returning -1 if this is less, 0 if they're equal, and 1 if this is greater.
+	Decimals are equal if they have the same value, even if they have a different number of decimal places.
+*/
+func (this Decimal) Cmp(other Decimal) int {
+
+	left, right, _ := alignDecimals(this, other)
+	return left.Cmp(right)
+}
+
+/*
+	Rounds this decimal to [places] digits after the decimal point, using [rounding].
+	Decimals with fewer places are returned as they are. Negative places round to the left of the decimal point,
+	so rounding "1250" to -2 places (with ROUND_HALF_EVEN) gives "1200".
+*/
+func (this Decimal) Round(places int, rounding RoundingMode) Decimal {
+
+	if places >= this.scale {
+		return this
+	}
+	return roundRat(this.Rat(), places, rounding)
+}
+
+/*
+	Returns a function that rounds a number to a number of decimal places using [rounding], as in "round(x, n)".
+	Without a number of places, it rounds to an integer.
+	Expressions using `NUMBERS_DECIMAL` have this built in as "round", with `ParseOptions.Rounding`;
+	other expressions (or other rounding modes) can use it by giving it as a function (see `ParseOptions.Functions`).
+	Negative places round to the left of the decimal point, as with `Decimal.Round`.
+
+	Decimals are rounded to decimals, integers (see `NUMBERS_INTEGER`) to integers, and any other number to a float64.
+	Floats are rounded as the shortest decimal that parses to them, so 2.675 rounds (half up) to 2.68, even though the float64 is slightly below it.
+*/
+func RoundFunction(rounding RoundingMode) ExpressionFunction {
+
+	return func(arguments ...interface{}) (interface{}, error) {
+
+		var places int
+
+		if len(arguments) < 1 || len(arguments) > 2 {
+			return nil, fmt.Errorf("round expects a number and a number of decimal places, got %d arguments", len(arguments))
+		}
+
+		if len(arguments) == 2 {
+
+			count, ok := decimalOf(arguments[1])
+			if !ok || !count.isInteger() || count.Cmp(Decimal{unscaled: big.NewInt(maxDecimalExponent)}) > 0 || count.Cmp(Decimal{unscaled: big.NewInt(-maxDecimalExponent)}) < 0 {
+				return nil, fmt.Errorf("round expects a whole number of decimal places, got '%v'", arguments[1])
+			}
+			places = int(count.trim().integer().Int64())
+		}
+
+		value, ok := decimalOf(arguments[0])
+		if !ok {
+
+			// infinities and NaN are already as round as they can be.
+			if isNumber(arguments[0]) && !isString(arguments[0]) {
+				return arguments[0], nil
+			}
+			return nil, fmt.Errorf("round expects a number, got '%v' (%T)", arguments[0], arguments[0])
+		}
+
+		rounded := value.Round(places, rounding)
+
+		switch arguments[0].(type) {
+		case Decimal:
+			return rounded, nil
+		case float64, float32:
+			return rounded.Float64(), nil
+		}
+		return normalizeInteger(rounded.trim().integer()), nil
+	}
+}
+
+/*
+	Writes this decimal as a JSON number, with all of its digits.
+*/
+func (this Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(this.String()), nil
+}
+
+/*
+	Reads a decimal from a JSON number, or a string holding one.
+*/
+func (this *Decimal) UnmarshalJSON(data []byte) error {
+
+	parsed, err := ParseDecimal(strings.Trim(string(data), "\""))
+	if err != nil {
+		return err
+	}
+
+	*this = parsed
+	return nil
+}
+
+/*
+	Returns the unscaled integer of this decimal, which is never nil, even for the zero value.
+*/
+func (this Decimal) integer() *big.Int {
+
+	if this.unscaled == nil {
+		return new(big.Int)
+	}
+	return this.unscaled
+}
+
+/*
+	Returns true if this decimal has no fractional part.
+*/
+func (this Decimal) isInteger() bool {
+	return this.scale == 0 || this.Rat().IsInt()
+}
+
+/*
+	Returns this decimal with any trailing zeros after its decimal point removed.
+*/
+func (this Decimal) trim() Decimal {
+
+	unscaled := new(big.Int).Set(this.integer())
+	remainder := new(big.Int)
+	scale := this.scale
+
+	for scale > 0 {
+
+		quotient, _ := new(big.Int).QuoRem(unscaled, bigTen, remainder)
+		if remainder.Sign() != 0 {
+			break
+		}
+
+		unscaled = quotient
+		scale--
+	}
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+/*
+	Rounds [value] to a decimal with [places] digits after the decimal point (or to the left of it, if negative).
+*/
+func roundRat(value *big.Rat, places int, rounding RoundingMode) Decimal {
+
+	numerator := new(big.Int).Set(value.Num())
+	denominator := new(big.Int).Set(value.Denom())
+
+	if places >= 0 {
+		numerator.Mul(numerator, powerOfTen(places))
+		return Decimal{unscaled: rounding.divide(numerator, denominator), scale: places}
+	}
+
+	power := powerOfTen(-places)
+	denominator.Mul(denominator, power)
+
+	quotient := rounding.divide(numerator, denominator)
+	return Decimal{unscaled: quotient.Mul(quotient, power)}
+}
+
+/*
+	Returns the unscaled integers of [left] and [right] scaled to the same number of decimal places, and that number.
+*/
+func alignDecimals(left, right Decimal) (*big.Int, *big.Int, int) {
+
+	switch {
+	case left.scale < right.scale:
+		scaled := new(big.Int).Mul(left.integer(), powerOfTen(right.scale-left.scale))
+		return scaled, right.integer(), right.scale
+
+	case left.scale > right.scale:
+		scaled := new(big.Int).Mul(right.integer(), powerOfTen(left.scale-right.scale))
+		return left.integer(), scaled, left.scale
+	}
+	return left.integer(), right.integer(), left.scale
+}
+
+func powerOfTen(exponent int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(exponent)), nil)
+}
+
+/*
+	Returns [value] as a decimal, if it's a number. Floats are converted to the shortest decimal which
+	would be parsed as the same float (so 0.1 becomes exactly "0.1"), and infinities and NaN aren't numbers.
+*/
+func decimalOf(value interface{}) (Decimal, bool) {
+
+	switch typed := value.(type) {
+	case Decimal:
+		return typed, true
+	case float64:
+		return decimalOfFloat(typed, 64)
+	case float32:
+		return decimalOfFloat(float64(typed), 32)
+	case *big.Int:
+		if typed != nil {
+			return Decimal{unscaled: new(big.Int).Set(typed)}, true
+		}
+	case *big.Float:
+		if typed != nil && !typed.IsInf() {
+			ret, err := ParseDecimal(typed.Text('g', -1))
+			return ret, err == nil
+		}
+	}
+
+	_, kind := integerOf(value)
+	if kind == notInteger {
+		return Decimal{}, false
+	}
+	return Decimal{unscaled: bigIntegerOf(value)}, true
+}
+
+func decimalOfFloat(value float64, bitSize int) (Decimal, bool) {
+
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return Decimal{}, false
+	}
+
+	ret, err := ParseDecimal(strconv.FormatFloat(value, 'g', -1, bitSize))
+	return ret, err == nil
+}
+
+/*
+	Converts any number to a decimal. Anything else is returned as-is.
+*/
+func castToDecimal(value interface{}) interface{} {
+
+	ret, ok := decimalOf(value)
+	if !ok {
+		return value
+	}
+	return ret
+}
@@ -1,8 +1,7 @@
 package govaluate
 
 import (
-	"errors"
-	"fmt"
+	"context"
 )
 
 const isoDateFormat string = "2006-01-02T15:04:05.999999999Z0700"
@@ -32,9 +31,38 @@ type EvaluableExpression struct {
 	*/
 	ChecksTypes bool
 
+	/*
+		Limits on how much work each evaluation of this expression may do. By default, there are none.
+		See `EvaluationLimits`.
+	*/
+	Limits EvaluationLimits
+
+	/*
+		What arithmetic operators do when they divide by zero, or give NaN or an infinity. By default, nothing.
+		See `NonFiniteMode`.
+	*/
+	NonFinite NonFiniteMode
+
+	/*
+		What evaluations do when their parameters don't have a variable this expression uses. By default, they fail.
+		See `MissingMode`.
+	*/
+	Missing MissingMode
+
+	/*
+		The values of variables missing from the parameters of an evaluation, when `Missing` is MISSING_DEFAULT.
+		Missing accessors are looked up by their whole path, such as "order.discount".
+	*/
+	Defaults Parameters
+
 	tokens           []ExpressionToken
 	evaluationStages *evaluationStage
+	compiledStages   compiledStage
+	floatStages      floatStage
+	boolStages       boolStage
 	inputExpression  string
+	numbers          NumericMode
+	decimals         decimalContext
 }
 
 /*
@@ -64,7 +92,7 @@ func NewEvaluableExpressionFromTokens(tokens []ExpressionToken) (*EvaluableExpre
 		return nil, err
 	}
 
-	err = checkExpressionSyntax(tokens)
+	err = checkExpressionSyntax(withoutComments(tokens))
 	if err != nil {
 		return nil, err
 	}
@@ -74,11 +102,13 @@ func NewEvaluableExpressionFromTokens(tokens []ExpressionToken) (*EvaluableExpre
 		return nil, err
 	}
 
-	ret.evaluationStages, err = planStages(ret.tokens)
+	ret.evaluationStages, err = planStages(withoutComments(ret.tokens), NUMBERS_FLOAT, decimalContext{})
 	if err != nil {
 		return nil, err
 	}
 
+	ret.compile()
+
 	ret.ChecksTypes = true
 	return ret, nil
 }
@@ -88,43 +118,89 @@ func NewEvaluableExpressionFromTokens(tokens []ExpressionToken) (*EvaluableExpre
 	Functions passed into this will be available to the expression.
 */
 func NewEvaluableExpressionWithFunctions(expression string, functions map[string]ExpressionFunction) (*EvaluableExpression, error) {
+	return NewEvaluableExpressionWithOptions(expression, ParseOptions{Functions: functions})
+}
+
+/*
+	Similar to [NewEvaluableExpressionWithFunctions], except that the functions are given the context of the evaluation which calls them.
+	See `EvalContext`.
+*/
+func NewEvaluableExpressionWithContextFunctions(expression string, functions map[string]ContextExpressionFunction) (*EvaluableExpression, error) {
+	return NewEvaluableExpressionWithOptions(expression, ParseOptions{ContextFunctions: functions})
+}
+
+/*
+	Similar to [NewEvaluableExpression], except that parsing can be changed by the given [options].
+	See `ParseOptions`.
+*/
+func NewEvaluableExpressionWithOptions(expression string, options ParseOptions) (*EvaluableExpression, error) {
 
 	var ret *EvaluableExpression
 	var err error
 
+	if options.MaxLength > 0 && len(expression) > options.MaxLength {
+
+		budgetError := &BudgetExceededError{Budget: BUDGET_LENGTH, Limit: options.MaxLength}
+		return nil, newParseError(0, 0, "Expression is longer than %d bytes", options.MaxLength).causedBy(budgetError)
+	}
+
 	ret = new(EvaluableExpression)
 	ret.QueryDateFormat = isoDateFormat
 	ret.inputExpression = expression
+	ret.numbers = options.Numbers
+	ret.decimals = options.decimals()
 
-	ret.tokens, err = parseTokens(expression, functions)
+	ret.tokens, err = parseTokens(expression, options)
 	if err != nil {
-		return nil, err
+		return nil, locateParseError(err, expression)
+	}
+
+	// comments stay in the tokens, but nothing else needs to look at them.
+	err = checkParseLimits(withoutComments(ret.tokens), options)
+	if err != nil {
+		return nil, locateParseError(err, expression)
 	}
 
 	err = checkBalance(ret.tokens)
 	if err != nil {
-		return nil, err
+		return nil, locateParseError(err, expression)
 	}
 
-	err = checkExpressionSyntax(ret.tokens)
+	err = checkExpressionSyntax(withoutComments(ret.tokens))
 	if err != nil {
-		return nil, err
+		return nil, locateParseError(err, expression)
 	}
 
 	ret.tokens, err = optimizeTokens(ret.tokens)
 	if err != nil {
-		return nil, err
+		return nil, locateParseError(err, expression)
 	}
 
-	ret.evaluationStages, err = planStages(ret.tokens)
+	ret.evaluationStages, err = planStages(withoutComments(ret.tokens), options.Numbers, options.decimals())
 	if err != nil {
-		return nil, err
+		return nil, locateParseError(err, expression)
 	}
 
+	ret.compile()
+
 	ret.ChecksTypes = true
 	return ret, nil
 }
 
+/*
+	Compiles the planned stages of this expression into the faster ways it can be evaluated.
+	Only float64s can be evaluated without allocating, so integer expressions don't have typed stages.
+*/
+func (this *EvaluableExpression) compile() {
+
+	this.compiledStages = compileStages(this.evaluationStages)
+
+	if this.numbers == NUMBERS_FLOAT {
+		this.floatStages = compileFloatStage(this.evaluationStages)
+		this.boolStages = compileBoolStage(this.evaluationStages)
+	}
+}
+
 /*
 	Same as `Eval`, but automatically wraps a map of parameters into a `govalute.Parameters` structure.
 */
@@ -149,27 +225,63 @@ func (this EvaluableExpression) Evaluate(parameters map[string]interface{}) (int
 	e.g., if the expression is "foo + 1" and parameters contains "foo" = 2, this will return 3.0
 */
 func (this EvaluableExpression) Eval(parameters Parameters) (interface{}, error) {
+	return this.EvalContext(context.Background(), parameters)
+}
+
+/*
+	Same as `Eval`, except that evaluation stops with [ctx]'s error as soon as [ctx] is cancelled or passes its deadline.
+	Cancellation is checked before every operator is evaluated. A function or method call which is already running
+	will not be interrupted, but is given [ctx] if it accepts one; see `ContextExpressionFunction`.
+	Parameter methods whose first argument is a context.Context are called with [ctx] as that argument.
+	A nil [ctx] is the same as context.Background().
+*/
+func (this EvaluableExpression) EvalContext(ctx context.Context, parameters Parameters) (interface{}, error) {
 
 	if this.evaluationStages == nil {
 		return nil, nil
 	}
 
-	if parameters != nil {
-		parameters = &sanitizedParameters{parameters}
-	} else {
-		parameters = DUMMY_PARAMETERS
+	parameters = sanitizeParameters(parameters, this.numbers)
+	state := newEvaluationState(ctx, parameters, this.Limits, this.ChecksTypes)
+	state.nonFinite = this.NonFinite
+	state.setMissing(this.Missing, this.Defaults, this.numbers)
+
+	// compiled stages don't count against limits, check for cancellation, or check results, the planned stages are evaluated instead.
+	if this.compiledStages != nil && state.limits == nil && state.done == nil && state.nonFinite == NONFINITE_IEEE {
+		return this.compiledStages(state)
 	}
-	result, _, _, err := this.evaluateStage(this.evaluationStages, parameters)
+
+	result, _, _, err := this.evaluateStage(this.evaluationStages, state)
 	return result, err
 }
 
-func (this EvaluableExpression) evaluateStage(stage *evaluationStage, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+func (this EvaluableExpression) evaluateStage(stage *evaluationStage, state *evaluationState) (interface{}, interface{}, interface{}, error) {
+
+	if state.trace != nil {
+		return this.traceStage(stage, state)
+	}
+	return this.runStage(stage, state)
+}
+
+func (this EvaluableExpression) runStage(stage *evaluationStage, state *evaluationState) (interface{}, interface{}, interface{}, error) {
 
 	var left, right, leftStageValue, rightStageValue interface{}
 	var err error
 
+	err = state.checkCancelled()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if state.limits != nil {
+		err = state.startStage(stage, stage.isCall())
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
 	if stage.leftStage != nil {
-		left, leftStageValue, rightStageValue, err = this.evaluateStage(stage.leftStage, parameters)
+		left, leftStageValue, rightStageValue, err = this.evaluateStage(stage.leftStage, state)
 		if err != nil {
 			return nil, nil, nil, err
 		}
@@ -202,37 +314,62 @@ func (this EvaluableExpression) evaluateStage(stage *evaluationStage, parameters
 	}
 
 	if right != shortCircuitHolder && stage.rightStage != nil {
-		right, _, rightStageValue, err = this.evaluateStage(stage.rightStage, parameters)
+		right, _, rightStageValue, err = this.evaluateStage(stage.rightStage, state)
 		if err != nil {
 			return nil, nil, nil, err
 		}
 	}
 
-	if this.ChecksTypes {
-		if stage.typeCheck == nil {
+	if state.checksTypes {
+		err = checkOperandTypes(stage, left, right)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
 
-			err = typeCheck(stage.leftTypeCheck, left, stage.symbol, stage.typeErrorFormat)
-			if err != nil {
-				return nil, nil, nil, err
-			}
+	result, leftStageValue, rightStageValue, err := stage.operator(left, right, leftStageValue, rightStageValue, state)
+	if err != nil {
+		return result, leftStageValue, rightStageValue, locateEvaluationError(err, stage)
+	}
 
-			err = typeCheck(stage.rightTypeCheck, right, stage.symbol, stage.typeErrorFormat)
-			if err != nil {
-				return nil, nil, nil, err
-			}
-		} else {
-			// special case where the type check needs to know both sides to determine if the operator can handle it
-			if !stage.typeCheck(left, right) {
-				errorMsg := fmt.Sprintf(stage.typeErrorFormat, left, stage.symbol.String())
-				return nil, nil, nil, errors.New(errorMsg)
-			}
+	if state.nonFinite != NONFINITE_IEEE {
+		result, err = state.checkFinite(stage, left, right, result)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	if state.limits != nil {
+		err = state.checkResult(result, stage, stage.isCall())
+		if err != nil {
+			return nil, nil, nil, err
 		}
 	}
+	return result, leftStageValue, rightStageValue, nil
+}
+
+/*
+	Checks that [left] and [right] are types that the operator of [stage] can use.
+*/
+func checkOperandTypes(stage *evaluationStage, left interface{}, right interface{}) error {
+
+	if stage.typeCheck == nil {
 
-	return stage.operator(left, right, leftStageValue, rightStageValue, parameters)
+		err := typeCheck(stage.leftTypeCheck, left, stage)
+		if err != nil {
+			return err
+		}
+		return typeCheck(stage.rightTypeCheck, right, stage)
+	}
+
+	// special case where the type check needs to know both sides to determine if the operator can handle it
+	if !stage.typeCheck(left, right) {
+		return newTypeMismatchError(left, stage)
+	}
+	return nil
 }
 
-func typeCheck(check stageTypeCheck, value interface{}, symbol OperatorSymbol, format string) error {
+func typeCheck(check stageTypeCheck, value interface{}, stage *evaluationStage) error {
 
 	if check == nil {
 		return nil
@@ -242,12 +379,23 @@ func typeCheck(check stageTypeCheck, value interface{}, symbol OperatorSymbol, f
 		return nil
 	}
 
-	errorMsg := fmt.Sprintf(format, value, symbol.String())
-	return errors.New(errorMsg)
+	return newTypeMismatchError(value, stage)
+}
+
+func newTypeMismatchError(value interface{}, stage *evaluationStage) error {
+
+	return &TypeMismatchError{
+		Operator: stage.symbol,
+		Value:    value,
+		Start:    stage.start,
+		End:      stage.end,
+		format:   stage.typeErrorFormat,
+	}
 }
 
 /*
 	Returns an array representing the ExpressionTokens that make up this expression.
+	Comments are included as COMMENT tokens, with their text as the Value, and their positions in the expression.
 */
 func (this EvaluableExpression) Tokens() []ExpressionToken {
 
@@ -267,6 +415,22 @@ func (this EvaluableExpression) String() string {
 */
 func (this EvaluableExpression) Vars() []string {
 	var varlist []string
+
+	// expressions from `PartialEval` have no tokens. Their accessors were left because they still need their parameter, so it's listed too.
+	if this.tokens == nil && this.evaluationStages != nil {
+
+		Inspect(this.AST(), func(node ExpressionNode) bool {
+			switch typed := node.(type) {
+			case *VariableNode:
+				varlist = append(varlist, typed.Name)
+			case *AccessorNode:
+				varlist = append(varlist, typed.Path[0])
+			}
+			return true
+		})
+		return varlist
+	}
+
 	for _, val := range this.Tokens() {
 		if val.Kind == VARIABLE {
 			varlist = append(varlist, val.Value.(string))
@@ -0,0 +1,255 @@
+package govaluate
+
+import (
+	"reflect"
+	"strings"
+)
+
+/*
+	Checks, without evaluating, that every operator in this expression can be given the types of values it would be given,
+	using the same type checks as evaluation does (see `ChecksTypes`). The types of variables and accessors are taken from [schema].
+
+	Returns a *TypeCheckError for the first operator which could never be given types it can use, such as "name > 5" where name is a string,
+	or "flag + 1" where flag is a bool. Operators given a union of types (such as "number|nil") only fail if none of them could be used.
+	Variables missing from [schema] return a *MissingParameterError, and accessors of variables which are neither structs nor maps return an *AccessorError.
+	Accessors whose paths aren't in [schema] (but whose variable is) are assumed to be of any type, as are the results of functions.
+
+	Passing does not mean evaluation can't fail - strings which hold numbers can be used as numbers, and the schema may not match the parameters given.
+*/
+func (this EvaluableExpression) Check(schema TypeSchema) error {
+
+	if this.evaluationStages == nil {
+		return nil
+	}
+
+	_, err := checkStageTypes(this.evaluationStages, schema)
+	return err
+}
+
+/*
+	Returns the type of value this expression would evaluate to, given variables of the types in [schema], without evaluating it.
+	This may be a union of types, such as "number|nil" for "a ? 5" (which is nil when a is false).
+	Results of functions, and accessors missing from [schema], could be of any type.
+
+	Returns the same errors as `Check`, if the expression can't be given the types it needs.
+	An empty expression always evaluates to nil.
+*/
+func (this EvaluableExpression) InferType(schema TypeSchema) (ValueType, error) {
+
+	if this.evaluationStages == nil {
+		return TYPE_NIL, nil
+	}
+	return checkStageTypes(this.evaluationStages, schema)
+}
+
+/*
+	Checks the types given to [stage] and everything beneath it, returning the type of value it would evaluate to.
+*/
+func checkStageTypes(stage *evaluationStage, schema TypeSchema) (ValueType, error) {
+
+	var left, right ValueType
+	var err error
+
+	if stage == nil {
+		return TYPE_NIL, nil
+	}
+
+	switch stage.symbol {
+
+	case LITERAL:
+		value, _, _, _ := stage.operator(nil, nil, nil, nil, nil)
+		return valueTypeOf(reflect.TypeOf(value)), nil
+
+	case VALUE:
+		valueType, found := schema[stage.name]
+		if !found {
+			return 0, &MissingParameterError{Name: stage.name, Start: stage.start, End: stage.end}
+		}
+		return valueType, nil
+
+	case ACCESS:
+		return checkAccessorTypes(stage, schema)
+
+	// testing for a parameter is never an error, even if it might not be there.
+	case EXISTS:
+		return TYPE_BOOL, nil
+	}
+
+	left, err = checkStageTypes(stage.leftStage, schema)
+	if err != nil {
+		return 0, err
+	}
+
+	right, err = checkStageTypes(stage.rightStage, schema)
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkOperandValueTypes(stage, left, right)
+	if err != nil {
+		return 0, err
+	}
+	return findResultType(stage, left, right), nil
+}
+
+/*
+	Accessors are looked up in the schema by their whole path (such as "foo.Bar"), without any optional links ("foo?.Bar").
+	Failing that, the longest part of the path which is in the schema must be a struct or map, so that the rest can be accessed.
+	Paths with optional links may be nil as well.
+*/
+func checkAccessorTypes(stage *evaluationStage, schema TypeSchema) (ValueType, error) {
+
+	path, optional := splitAccessorPath(stage.path)
+
+	nilable := false
+	for _, link := range optional {
+		nilable = nilable || link
+	}
+
+	if stage.rightStage != nil {
+
+		_, err := checkStageTypes(stage.rightStage, schema)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for i := len(path); i > 0; i-- {
+
+		valueType, found := schema[strings.Join(path[:i], ".")]
+		if !found {
+			continue
+		}
+
+		if i == len(path) {
+			if nilable {
+				return valueType | TYPE_NIL, nil
+			}
+			return valueType, nil
+		}
+
+		// an optional link from something which can only be nil is always nil.
+		if optional[i] && valueType == TYPE_NIL {
+			return TYPE_NIL, nil
+		}
+
+		if valueType&(TYPE_STRUCT|TYPE_MAP) == 0 {
+			return 0, &AccessorError{
+				Path:    path,
+				Field:   path[i],
+				Message: "Unable to access '" + path[i] + "', '" + path[i-1] + "' is not a struct or map",
+				Start:   stage.start,
+				End:     stage.end,
+			}
+		}
+		return TYPE_ANY, nil
+	}
+
+	return 0, &MissingParameterError{Name: stage.path[0], Start: stage.start, End: stage.end}
+}
+
+/*
+	Runs the type checks of [stage] against an example value of every combination of the types in [left] and [right].
+	Returns a *TypeCheckError if none of them pass.
+*/
+func checkOperandValueTypes(stage *evaluationStage, left ValueType, right ValueType) error {
+
+	var offending ValueType
+
+	for _, leftMember := range left.members() {
+		for _, rightMember := range right.members() {
+
+			if checkOperandTypes(stage, leftMember.example(), rightMember.example()) == nil {
+				return nil
+			}
+		}
+	}
+
+	// when the sides are checked separately, blame whichever side can never pass.
+	offending = left
+	if stage.typeCheck == nil && passesTypeCheck(stage.leftTypeCheck, left) {
+		offending = right
+	}
+
+	return &TypeCheckError{
+		Operator: stage.symbol,
+		Type:     offending,
+		Start:    stage.start,
+		End:      stage.end,
+	}
+}
+
+func passesTypeCheck(check stageTypeCheck, valueType ValueType) bool {
+
+	if check == nil {
+		return true
+	}
+
+	for _, member := range valueType.members() {
+		if check(member.example()) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	Returns the type of value that [stage] evaluates to, given operands of the types [left] and [right].
+*/
+func findResultType(stage *evaluationStage, left ValueType, right ValueType) ValueType {
+
+	var ret ValueType
+
+	switch stage.symbol {
+
+	case NOOP:
+		return right
+
+	case SEPARATE:
+		return TYPE_ARRAY
+
+	case PLUS:
+		for _, leftMember := range left.members() {
+			for _, rightMember := range right.members() {
+
+				if !additionTypeCheck(leftMember.example(), rightMember.example()) {
+					continue
+				}
+
+				if leftMember == TYPE_STRING || rightMember == TYPE_STRING {
+					ret |= TYPE_STRING
+				} else {
+					ret |= TYPE_NUMBER
+				}
+			}
+		}
+		return ret
+
+	// characters of strings are strings, but elements of anything else could be anything.
+	case INDEX:
+		if left == TYPE_STRING {
+			return TYPE_STRING
+		}
+		return TYPE_ANY
+
+	case MINUS, MULTIPLY, DIVIDE, MODULUS, EXPONENT, NEGATE,
+		BITWISE_AND, BITWISE_OR, BITWISE_XOR, BITWISE_LSHIFT, BITWISE_RSHIFT, BITWISE_NOT:
+		return TYPE_NUMBER
+
+	case EQ, NEQ, GT, LT, GTE, LTE, REQ, NREQ, IN, AND, OR, INVERT:
+		return TYPE_BOOL
+
+	// "a ? b" is nil when a is false.
+	case TERNARY_TRUE:
+		return right | TYPE_NIL
+
+	// ":" and "??" are their left side, unless it is nil.
+	case TERNARY_FALSE, COALESCE:
+		if left&TYPE_NIL == 0 {
+			return left
+		}
+		return (left &^ TYPE_NIL) | right
+	}
+
+	return TYPE_ANY
+}
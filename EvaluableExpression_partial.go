@@ -0,0 +1,167 @@
+package govaluate
+
+import (
+	"context"
+)
+
+/*
+	Evaluates as much of this expression as possible using the given [parameters], and returns a new expression of what remains.
+	Any parameter which [parameters] returns an error for (such as a *MissingParameterError) is left in the residual expression,
+	every other parameter is substituted by its value.
+	e.g., partially evaluating "tier == 'gold' && amount > limit" with "tier" = "gold" and "limit" = 100
+	returns an expression equivalent to "amount > 100".
+
+	Every operator whose operands are all known is evaluated, and `&&`, `||`, `??` and ternaries are short-circuited
+	when their left side is known. Functions and parameter methods are never called, since they may not return the same value
+	when the residual expression is evaluated later; their arguments are still partially evaluated. A parameter whose method is called
+	stays in the residual (and in its `Vars()`), and must be given again when the residual is evaluated.
+	Operators which fail with their known operands are left as they are, to fail (or be short-circuited) when the residual is evaluated,
+	as are those whose result isn't finite (such as "1 / 0"), which has no literal that would parse back.
+
+	Missing parameters are always left in the residual, whatever this expression's `Missing` policy, which is applied when the residual is evaluated.
+	The residual expression keeps this expression's functions, `ChecksTypes`, `Limits`, `NonFinite`, `Missing` and `Defaults`. It has no tokens,
+	so it can't be turned into a query, and its `String()` is a rendering of its `AST()` rather than the original text.
+*/
+func (this EvaluableExpression) PartialEval(parameters Parameters) *EvaluableExpression {
+
+	ret := this
+
+	if this.evaluationStages == nil {
+		return &ret
+	}
+
+	parameters = sanitizeParameters(parameters, this.numbers)
+
+	state := newEvaluationState(context.Background(), parameters, EvaluationLimits{}, this.ChecksTypes)
+	state.nonFinite = this.NonFinite
+
+	ret.tokens = nil
+	ret.evaluationStages = this.partialStage(this.evaluationStages, state)
+	ret.compile()
+	ret.inputExpression = ret.AST().String()
+	return &ret
+}
+
+/*
+	Returns a stage equivalent to [stage] with every known sub-expression replaced by a literal.
+	Stages are never modified, unchanged sub-trees are shared with the original expression.
+*/
+func (this EvaluableExpression) partialStage(stage *evaluationStage, state *evaluationState) *evaluationStage {
+
+	var residual evaluationStage
+
+	if stage == nil || stage.symbol == LITERAL {
+		return stage
+	}
+
+	// a parameter which isn't there yet may be given to the residual, so only presence is known.
+	if stage.symbol == EXISTS {
+
+		present, _, _, _ := stage.operator(nil, nil, nil, nil, state)
+		if present == true {
+			return newLiteralStage(true, stage)
+		}
+		return stage
+	}
+
+	// nor is the value of an accessor whose parameter isn't there, even if its links are optional.
+	if stage.symbol == ACCESS {
+
+		_, err := state.Parameters.Get(stage.path[0])
+		if err != nil {
+			return stage
+		}
+	}
+
+	residual = *stage
+	residual.leftStage = this.partialStage(stage.leftStage, state)
+
+	if residual.leftStage != nil && residual.leftStage.symbol == LITERAL {
+
+		left, _, _, _ := residual.leftStage.operator(nil, nil, nil, nil, nil)
+
+		switch stage.symbol {
+		case AND:
+			if left == false {
+				return newLiteralStage(false, stage)
+			}
+		case OR:
+			if left == true {
+				return newLiteralStage(true, stage)
+			}
+		case TERNARY_TRUE:
+			if left == false {
+				return newLiteralStage(nil, stage)
+			}
+		case TERNARY_FALSE, COALESCE:
+			if left != nil {
+				return residual.leftStage
+			}
+			return this.partialStage(stage.rightStage, state)
+		}
+	}
+
+	residual.rightStage = this.partialStage(stage.rightStage, state)
+
+	// parenthesis around a single value aren't needed once it's known.
+	if stage.symbol == NOOP && residual.rightStage != nil && residual.rightStage.symbol == LITERAL {
+		return residual.rightStage
+	}
+
+	if !isFoldable(&residual) {
+		return &residual
+	}
+
+	// non-finite results have no literal which parses back, so they're left to be calculated again, as `elideStage` leaves them.
+	result, _, _, err := this.evaluateStage(&residual, state)
+	if err != nil || isNonFinite(result) {
+		return &residual
+	}
+	return newLiteralStage(result, stage)
+}
+
+/*
+	Returns true if [stage] can be replaced by the literal it evaluates to.
+	Separators and parenthesis are never replaced themselves, since the stages above them need to know how their arrays were built,
+	but are foldable as part of a larger stage.
+*/
+func isFoldable(stage *evaluationStage) bool {
+
+	switch stage.symbol {
+	case SEPARATE, NOOP, FUNCTIONAL:
+		return false
+	case ACCESS:
+		if stage.rightStage != nil {
+			return false
+		}
+	}
+	return isConstant(stage.leftStage) && isConstant(stage.rightStage)
+}
+
+/*
+	Returns true if [stage] is made only of literals, separators and parenthesis.
+*/
+func isConstant(stage *evaluationStage) bool {
+
+	if stage == nil {
+		return true
+	}
+
+	switch stage.symbol {
+	case LITERAL:
+		return true
+	case SEPARATE, NOOP:
+		return isConstant(stage.leftStage) && isConstant(stage.rightStage)
+	}
+	return false
+}
+
+func newLiteralStage(value interface{}, original *evaluationStage) *evaluationStage {
+
+	return &evaluationStage{
+		symbol:   LITERAL,
+		operator: makeLiteralStage(value),
+		start:    original.start,
+		end:      original.end,
+	}
+}
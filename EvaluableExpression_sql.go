@@ -25,7 +25,7 @@ func (this EvaluableExpression) ToSQLQuery() (string, error) {
 	var transaction string
 	var err error
 
-	stream = newTokenStream(this.tokens)
+	stream = newTokenStream(withoutComments(this.tokens))
 	transactions = new(expressionOutputStream)
 
 	for stream.hasNext() {
@@ -73,19 +73,36 @@ func (this EvaluableExpression) findNextSQLString(stream *tokenStream, transacti
 			ret = "0"
 		}
 
+	case NULL:
+		ret = "NULL"
+
 	case VARIABLE:
 		ret = fmt.Sprintf("[%s]", token.Value.(string))
 
 	case NUMERIC:
-		ret = fmt.Sprintf("%g", token.Value.(float64))
+		switch value := token.Value.(type) {
+		case float64:
+			ret = fmt.Sprintf("%g", value)
+		case Decimal:
+			ret = value.String()
+		default:
+			ret = fmt.Sprintf("%d", value)
+		}
 
 	case COMPARATOR:
 		switch comparatorSymbols[token.Value.(string)] {
 
+		// nothing is equal to NULL in SQL, it has to be tested with IS.
 		case EQ:
 			ret = "="
+			if isNullComparison(stream) {
+				ret = "IS"
+			}
 		case NEQ:
 			ret = "<>"
+			if isNullComparison(stream) {
+				ret = "IS NOT"
+			}
 		case REQ:
 			ret = "RLIKE"
 		case NREQ:
@@ -157,6 +174,8 @@ func (this EvaluableExpression) findNextSQLString(stream *tokenStream, transacti
 		ret = ")"
 	case SEPARATOR:
 		ret = ","
+	case BRACKET, BRACKET_CLOSE:
+		return "", errors.New("Indexes cannot be converted to SQL")
 
 	default:
 		errorMsg := fmt.Sprintf("Unrecognized query token '%s' of kind '%s'", token.Value, token.Kind)
@@ -165,3 +184,14 @@ func (this EvaluableExpression) findNextSQLString(stream *tokenStream, transacti
 
 	return ret, nil
 }
+
+/*
+	Returns true if either side of the comparator just read from [stream] is a null literal.
+*/
+func isNullComparison(stream *tokenStream) bool {
+
+	if stream.index >= 2 && stream.tokens[stream.index-2].Kind == NULL {
+		return true
+	}
+	return stream.hasNext() && stream.tokens[stream.index].Kind == NULL
+}
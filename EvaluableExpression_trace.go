@@ -0,0 +1,196 @@
+package govaluate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+/*
+	A record of how one part of an expression was evaluated, returned by `EvalWithTrace`.
+	Traces form a tree matching the expression's `AST()`; Left and Right are the traces of the operator's operands,
+	whose Results are the values the operator was given.
+*/
+type EvaluationTrace struct {
+
+	// the operator which was evaluated. Variables are VALUE, constants are LITERAL, and function calls are FUNCTIONAL.
+	Operator OperatorSymbol
+
+	// the part of the expression which was evaluated, as rendered by `ExpressionNode.String()`.
+	Expression string
+
+	/*
+		The traces of the left and right sides of the operator.
+		Either is nil if the operator has no such side, or if it was never evaluated
+		(because the evaluation failed first, or the operator was short-circuited).
+	*/
+	Left, Right *EvaluationTrace
+
+	Result interface{}
+
+	// true if the right side was skipped because the left side determined the result, such as "false && x".
+	ShortCircuited bool
+
+	// the error this part of the expression failed with, if any.
+	Err error
+
+	// the traces of stages evaluated beneath this one, in order, until they are sorted into Left and Right.
+	children []*EvaluationTrace
+}
+
+/*
+	Same as `Eval`, but also returns a trace of how every part of the expression was evaluated, such as to explain why a rule returned false.
+	The trace is returned even if evaluation fails, in which case it shows which part failed.
+	Tracing makes evaluation considerably slower, and should not be used when the trace isn't needed.
+*/
+func (this EvaluableExpression) EvalWithTrace(parameters Parameters) (interface{}, *EvaluationTrace, error) {
+
+	if this.evaluationStages == nil {
+		return nil, nil, nil
+	}
+
+	parameters = sanitizeParameters(parameters, this.numbers)
+
+	state := newEvaluationState(context.Background(), parameters, this.Limits, this.ChecksTypes)
+	state.nonFinite = this.NonFinite
+	state.setMissing(this.Missing, this.Defaults, this.numbers)
+	state.trace = new(EvaluationTrace)
+
+	result, _, _, err := this.evaluateStage(this.evaluationStages, state)
+	return result, state.trace.children[0], err
+}
+
+/*
+	Evaluates [stage] as normal, recording its trace as a child of the trace of the stage above it.
+*/
+func (this EvaluableExpression) traceStage(stage *evaluationStage, state *evaluationState) (interface{}, interface{}, interface{}, error) {
+
+	parent := state.trace
+	trace := &EvaluationTrace{
+		Operator: stage.symbol,
+	}
+
+	// empty parenthesis, such as those of "foo.Func()", have nothing in them to render.
+	node := stageToNode(stage)
+	if node != nil {
+		trace.Expression = node.String()
+	}
+
+	state.trace = trace
+	result, leftStageValue, rightStageValue, err := this.runStage(stage, state)
+	state.trace = parent
+
+	// each side that was evaluated left exactly one trace, left first.
+	if stage.leftStage != nil && len(trace.children) > 0 {
+		trace.Left = trace.children[0]
+		trace.children = trace.children[1:]
+	}
+	if stage.rightStage != nil && len(trace.children) > 0 {
+		trace.Right = trace.children[0]
+	}
+	trace.children = nil
+
+	trace.Result = result
+	trace.Err = err
+	trace.ShortCircuited = err == nil && stage.rightStage != nil && trace.Right == nil
+
+	// parenthesis aren't interesting on their own, their contents are traced instead.
+	if stage.symbol == NOOP && trace.Right != nil {
+		trace = trace.Right
+	}
+
+	parent.children = append(parent.children, trace)
+	return result, leftStageValue, rightStageValue, err
+}
+
+/*
+	Renders this trace as indented text, one line per operator, variable, and function call.
+	Constants are left out, since their values are already shown in the expression of the line above them.
+*/
+func (this *EvaluationTrace) String() string {
+
+	var buffer bytes.Buffer
+
+	this.writeTo(&buffer, 0)
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+func (this *EvaluationTrace) writeTo(buffer *bytes.Buffer, depth int) {
+
+	buffer.WriteString(strings.Repeat("  ", depth))
+	buffer.WriteString(this.Expression)
+
+	if this.Err != nil {
+		buffer.WriteString(" -> error: ")
+		buffer.WriteString(this.Err.Error())
+	} else {
+		buffer.WriteString(" -> ")
+		buffer.WriteString((&LiteralNode{Value: this.Result}).String())
+	}
+
+	if this.ShortCircuited {
+		buffer.WriteString(" (short-circuited)")
+	}
+	buffer.WriteString("\n")
+
+	for _, side := range []*EvaluationTrace{this.Left, this.Right} {
+		if side != nil && side.Operator != LITERAL {
+			side.writeTo(buffer, depth+1)
+		}
+	}
+}
+
+/*
+	Encodes this trace as a JSON object. The operator is written as it appears in expressions (or as a name, for stages like LITERAL),
+	and errors as their message.
+*/
+func (this *EvaluationTrace) MarshalJSON() ([]byte, error) {
+
+	var errorMessage string
+
+	if this.Err != nil {
+		errorMessage = this.Err.Error()
+	}
+
+	return json.Marshal(struct {
+		Operator       string           `json:"operator"`
+		Expression     string           `json:"expression"`
+		Result         interface{}      `json:"result"`
+		ShortCircuited bool             `json:"shortCircuited,omitempty"`
+		Error          string           `json:"error,omitempty"`
+		Left           *EvaluationTrace `json:"left,omitempty"`
+		Right          *EvaluationTrace `json:"right,omitempty"`
+	}{
+		Operator:       traceOperatorName(this.Operator),
+		Expression:     this.Expression,
+		Result:         this.Result,
+		ShortCircuited: this.ShortCircuited,
+		Error:          errorMessage,
+		Left:           this.Left,
+		Right:          this.Right,
+	})
+}
+
+func traceOperatorName(symbol OperatorSymbol) string {
+
+	switch symbol {
+	case EQ:
+		return "=="
+	case LITERAL:
+		return "LITERAL"
+	case ACCESS:
+		return "ACCESS"
+	case FUNCTIONAL:
+		return "FUNCTIONAL"
+	case SEPARATE:
+		return ","
+	}
+
+	name := symbol.String()
+	if name == "" {
+		return fmt.Sprintf("OperatorSymbol(%d)", int(symbol))
+	}
+	return name
+}
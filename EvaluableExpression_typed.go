@@ -0,0 +1,81 @@
+package govaluate
+
+import (
+	"fmt"
+	"math/big"
+)
+
+/*
+	Same as `Eval`, but for expressions which evaluate to a number, returning it as a float64.
+	Returns an error if the expression evaluates to anything else. Integers and decimals (see `NumericMode`) are converted to a float64.
+
+	Expressions made only of numbers, numeric parameters, and arithmetic, bitwise, ternary and "??" operators (and the bool expressions
+	`EvalBool` can evaluate, as ternary conditions) are evaluated without allocating anything, given `TypedParameters` or `MapParameters`
+	whose values are already numbers, or nil on the left of "??".
+	Anything else, such as accessors and functions, (or any expression with `Limits` or a `NonFinite` policy) is evaluated by `Eval`,
+	as are evaluations which fail or find a parameter that isn't a number, so the parameters of those may be read twice.
+*/
+func (this EvaluableExpression) EvalFloat64(parameters Parameters) (float64, error) {
+
+	if parameters == nil {
+		parameters = DUMMY_PARAMETERS
+	}
+
+	if this.floatStages != nil && this.Limits == (EvaluationLimits{}) && this.NonFinite == NONFINITE_IEEE {
+
+		value, ok := this.floatStages(parameters)
+		if ok {
+			return value, nil
+		}
+	}
+
+	result, err := this.Eval(parameters)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := result.(type) {
+	case float64:
+		return value, nil
+	case int64:
+		return float64(value), nil
+	case uint64:
+		return float64(value), nil
+	case Decimal:
+		return value.Float64(), nil
+	case *big.Int, *big.Float:
+		return convert2Float64(value)
+	}
+	return 0, fmt.Errorf("Expression evaluated to '%v' (%T), not a float64", result, result)
+}
+
+/*
+	Same as `EvalFloat64`, but for expressions which evaluate to a bool.
+	Expressions made only of bools, bool parameters, logical, ternary and "??" operators, comparisons between numbers,
+	and "==" or "!=" between bools are evaluated without allocating.
+*/
+func (this EvaluableExpression) EvalBool(parameters Parameters) (bool, error) {
+
+	if parameters == nil {
+		parameters = DUMMY_PARAMETERS
+	}
+
+	if this.boolStages != nil && this.Limits == (EvaluationLimits{}) && this.NonFinite == NONFINITE_IEEE {
+
+		value, ok := this.boolStages(parameters)
+		if ok {
+			return value, nil
+		}
+	}
+
+	result, err := this.Eval(parameters)
+	if err != nil {
+		return false, err
+	}
+
+	value, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("Expression evaluated to '%v' (%T), not a bool", result, result)
+	}
+	return value, nil
+}
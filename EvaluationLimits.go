@@ -0,0 +1,179 @@
+package govaluate
+
+import (
+	"fmt"
+)
+
+/*
+	Limits on the work a single evaluation may do, set through `EvaluableExpression.Limits`.
+	These are meant for expressions from untrusted sources, which could otherwise (for instance)
+	build ever-larger strings until they run out of memory.
+	Zero means no limit.
+
+	Exceeding any of them stops the evaluation with a *BudgetExceededError.
+*/
+type EvaluationLimits struct {
+
+	/*
+		The maximum number of stages (operators, values, and function calls) evaluated.
+		Stages skipped by short-circuiting don't count.
+	*/
+	MaxStages int
+
+	/*
+		The maximum length, in bytes, of any string produced by an operator or function.
+		Strings given as parameters are not checked unless they are operated on.
+	*/
+	MaxStringLength int
+
+	/*
+		The maximum length of any array produced by an operator or function, such as the separator in "(1, 2, 3)".
+	*/
+	MaxArrayLength int
+
+	/*
+		The maximum number of calls to functions and parameter methods.
+	*/
+	MaxFunctionCalls int
+}
+
+/*
+	Identifies which limit a *BudgetExceededError exceeded.
+*/
+type BudgetKind int
+
+const (
+	BUDGET_LENGTH BudgetKind = iota
+	BUDGET_TOKENS
+	BUDGET_DEPTH
+	BUDGET_STAGES
+	BUDGET_STRING_LENGTH
+	BUDGET_ARRAY_LENGTH
+	BUDGET_FUNCTION_CALLS
+)
+
+/*
+	Returns a string representation of this budget, naming the option which sets it.
+*/
+func (kind BudgetKind) String() string {
+
+	switch kind {
+	case BUDGET_LENGTH:
+		return "MaxLength"
+	case BUDGET_TOKENS:
+		return "MaxTokens"
+	case BUDGET_DEPTH:
+		return "MaxDepth"
+	case BUDGET_STAGES:
+		return "MaxStages"
+	case BUDGET_STRING_LENGTH:
+		return "MaxStringLength"
+	case BUDGET_ARRAY_LENGTH:
+		return "MaxArrayLength"
+	case BUDGET_FUNCTION_CALLS:
+		return "MaxFunctionCalls"
+	}
+	return fmt.Sprintf("BudgetKind(%d)", int(kind))
+}
+
+/*
+	Checks the limits in [options] which apply to the expression string and its tokens.
+*/
+func checkParseLimits(tokens []ExpressionToken, options ParseOptions) error {
+
+	var depth int
+
+	if options.MaxTokens > 0 && len(tokens) > options.MaxTokens {
+		return newBudgetParseError(tokens[options.MaxTokens], BUDGET_TOKENS, options.MaxTokens,
+			"Expression has more than %d tokens", options.MaxTokens)
+	}
+
+	if options.MaxDepth <= 0 {
+		return nil
+	}
+
+	for _, token := range tokens {
+
+		switch token.Kind {
+		case CLAUSE:
+			depth++
+			if depth > options.MaxDepth {
+				return newBudgetParseError(token, BUDGET_DEPTH, options.MaxDepth,
+					"Expression is nested more than %d levels deep", options.MaxDepth)
+			}
+		case CLAUSE_CLOSE:
+			depth--
+		}
+	}
+	return nil
+}
+
+func newBudgetParseError(token ExpressionToken, kind BudgetKind, limit int, format string, arguments ...interface{}) error {
+
+	budgetError := &BudgetExceededError{
+		Budget: kind,
+		Limit:  limit,
+		Start:  token.Start,
+		End:    token.End,
+	}
+	return newTokenError(token, format, arguments...).causedBy(budgetError)
+}
+
+/*
+	Counts a stage against the evaluation's limits, before it is run.
+	[isCall] is true if the stage calls a function or method.
+*/
+func (this *evaluationState) startStage(stage *evaluationStage, isCall bool) error {
+
+	this.stages++
+	if this.limits.MaxStages > 0 && this.stages > this.limits.MaxStages {
+		return newBudgetEvaluationError(BUDGET_STAGES, this.limits.MaxStages, stage)
+	}
+
+	if isCall {
+
+		this.functionCalls++
+		if this.limits.MaxFunctionCalls > 0 && this.functionCalls > this.limits.MaxFunctionCalls {
+			return newBudgetEvaluationError(BUDGET_FUNCTION_CALLS, this.limits.MaxFunctionCalls, stage)
+		}
+	}
+	return nil
+}
+
+/*
+	Checks the size of a value produced by a stage against the evaluation's limits.
+	Values which came straight from parameters or literals (or are elements of them) aren't produced by the expression, and aren't checked.
+*/
+func (this *evaluationState) checkResult(result interface{}, stage *evaluationStage, isCall bool) error {
+
+	switch stage.symbol {
+	case VALUE, LITERAL, NOOP, INDEX:
+		return nil
+	case ACCESS:
+		if !isCall {
+			return nil
+		}
+	}
+
+	switch value := result.(type) {
+	case string:
+		if this.limits.MaxStringLength > 0 && len(value) > this.limits.MaxStringLength {
+			return newBudgetEvaluationError(BUDGET_STRING_LENGTH, this.limits.MaxStringLength, stage)
+		}
+	case []interface{}:
+		if this.limits.MaxArrayLength > 0 && len(value) > this.limits.MaxArrayLength {
+			return newBudgetEvaluationError(BUDGET_ARRAY_LENGTH, this.limits.MaxArrayLength, stage)
+		}
+	}
+	return nil
+}
+
+func newBudgetEvaluationError(kind BudgetKind, limit int, stage *evaluationStage) error {
+
+	return &BudgetExceededError{
+		Budget: kind,
+		Limit:  limit,
+		Start:  stage.start,
+		End:    stage.end,
+	}
+}
@@ -0,0 +1,353 @@
+package govaluate
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+/*
+	Returned when an expression cannot be parsed, such as for malformed tokens, unbalanced parenthesis, or invalid syntax.
+
+	Start and End are the character (not byte) offsets of the part of the expression which caused the error,
+	matching `ExpressionToken.Start` and `ExpressionToken.End`.
+	Line and Column are one-based, and point at Start.
+	All four are zero if the location is unknown, such as for expressions given to `NewEvaluableExpressionFromTokens`.
+
+	If the error was caused by another error (such as an *UndefinedFunctionError, or a regexp compilation error), Err holds it.
+*/
+type ParseError struct {
+	Message      string
+	Start, End   int
+	Line, Column int
+
+	// the line of the expression containing the error, followed by another line with carets underneath the error.
+	Excerpt string
+
+	Err error
+}
+
+/*
+	Returned (wrapped by a *ParseError) when an expression calls a function which was not given to it.
+*/
+type UndefinedFunctionError struct {
+	Name string
+}
+
+/*
+	Returned when an operator is given a value it cannot operate on, such as "'foo' * 2".
+	Value is the offending value. For operators which check both sides together (such as comparators), Value is the left side.
+
+	Start and End are the character offsets of the operator within the expression, or zero if unknown.
+*/
+type TypeMismatchError struct {
+	Operator   OperatorSymbol
+	Value      interface{}
+	Start, End int
+
+	format string
+}
+
+/*
+	Returned by `EvaluableExpression.Check` when an operator could never be given a type it can operate on, such as "name > 5" where name is a string.
+	Type is the offending type. For operators which check both sides together (such as comparators), Type is the left side.
+
+	Start and End are the character offsets of the operator within the expression, or zero if unknown.
+*/
+type TypeCheckError struct {
+	Operator   OperatorSymbol
+	Type       ValueType
+	Start, End int
+}
+
+/*
+	Returned by MapParameters when an expression refers to a parameter that was not given.
+	Custom `Parameters` implementations are encouraged to return this too, so callers can tell missing parameters apart from other failures.
+
+	Start and End are the character offsets of the parameter within the expression, or zero if unknown.
+*/
+type MissingParameterError struct {
+	Name       string
+	Start, End int
+}
+
+/*
+	Returned when a field or method cannot be accessed on a parameter, such as "foo.Bar" where foo has no field "Bar".
+	Path is the whole accessor (e.g., ["foo", "Bar"]), Field is the element of it which could not be accessed.
+	If a method was called and returned an error, or accessing the field panicked, Err holds that error.
+
+	Start and End are the character offsets of the accessor within the expression, or zero if unknown.
+*/
+type AccessorError struct {
+	Path       []string
+	Field      string
+	Message    string
+	Start, End int
+
+	Err error
+}
+
+/*
+	Returned when a value cannot be indexed with a key, such as "items[5]" where items has fewer elements,
+	or "prices['EUR']" where prices has no such key. Value is what was indexed, and Key what it was indexed with.
+
+	Start and End are the character offsets of the index within the expression, or zero if unknown.
+*/
+type IndexError struct {
+	Value      interface{}
+	Key        interface{}
+	Message    string
+	Start, End int
+}
+
+/*
+	Returned when an `ExpressionFunction` returns an error, which is held in Err.
+
+	Start and End are the character offsets of the function name within the expression, or zero if unknown.
+*/
+type FunctionCallError struct {
+	Name       string
+	Start, End int
+
+	Err error
+}
+
+/*
+	Returned when an expression goes over one of the limits in `ParseOptions` or `EvaluationLimits`.
+	Budget is the limit which was exceeded, and Limit is what it was set to.
+	When parsing, this is wrapped by a *ParseError.
+
+	Start and End are the character offsets of the part of the expression which went over the limit, or zero if unknown.
+*/
+type BudgetExceededError struct {
+	Budget     BudgetKind
+	Limit      int
+	Start, End int
+}
+
+/*
+	Returned when an arithmetic operator divides by zero, or gives NaN or an infinity, and `EvaluableExpression.NonFinite` is `NONFINITE_ERROR`.
+	Left and Right are the operands (Left is nil for prefixes, such as negation), and Result is what the operator gave.
+
+	Start and End are the character offsets of the operator within the expression, or zero if unknown.
+*/
+type ArithmeticError struct {
+	Operator    OperatorSymbol
+	Left, Right interface{}
+	Result      interface{}
+	Start, End  int
+}
+
+func newParseError(start int, end int, format string, arguments ...interface{}) *ParseError {
+
+	return &ParseError{
+		Message: fmt.Sprintf(format, arguments...),
+		Start:   start,
+		End:     end,
+	}
+}
+
+/*
+	Creates an error spanning the given [token].
+	Tokens which weren't parsed from an expression string have no span, and their errors will have no location.
+*/
+func newTokenError(token ExpressionToken, format string, arguments ...interface{}) *ParseError {
+	return newParseError(token.Start, token.End, format, arguments...)
+}
+
+func (this *ParseError) causedBy(err error) *ParseError {
+
+	this.Err = err
+	return this
+}
+
+func (this *ParseError) Error() string {
+
+	if this.Line == 0 {
+		return this.Message
+	}
+	return fmt.Sprintf("%s (line %d, column %d)\n%s", this.Message, this.Line, this.Column, this.Excerpt)
+}
+
+func (this *ParseError) Unwrap() error {
+	return this.Err
+}
+
+func (this *UndefinedFunctionError) Error() string {
+	return "Undefined function " + this.Name
+}
+
+func (this *BudgetExceededError) Error() string {
+	return fmt.Sprintf("Expression exceeded %s budget of %d", this.Budget.String(), this.Limit)
+}
+
+func (this *TypeMismatchError) Error() string {
+	return fmt.Sprintf(this.format, this.Value, this.Operator.String())
+}
+
+func (this *TypeCheckError) Error() string {
+	return fmt.Sprintf("Type '%v' cannot be used with the operator '%v'", this.Type.String(), this.Operator.String())
+}
+
+func (this *ArithmeticError) Error() string {
+
+	if this.isDivisionByZero() {
+		return fmt.Sprintf("Operator '%s' cannot divide %v by zero", this.Operator.String(), this.Left)
+	}
+
+	if this.Operator == NEGATE {
+		return fmt.Sprintf("Operator '%s' gave %v for %v", this.Operator.String(), this.Result, this.Right)
+	}
+	return fmt.Sprintf("Operator '%s' gave %v for %v and %v", this.Operator.String(), this.Result, this.Left, this.Right)
+}
+
+/*
+	Returns true if this error was caused by dividing (or taking the modulus) by zero, rather than by an operand that was already NaN or infinite.
+*/
+func (this *ArithmeticError) isDivisionByZero() bool {
+
+	if this.Operator != DIVIDE && this.Operator != MODULUS {
+		return false
+	}
+
+	right, err := convert2Float64(this.Right)
+	return err == nil && right == 0
+}
+
+func (this *MissingParameterError) Error() string {
+	return "No parameter '" + this.Name + "' found."
+}
+
+func (this *AccessorError) Error() string {
+
+	if this.Err == nil {
+		return this.Message
+	}
+	return this.Message + ": " + this.Err.Error()
+}
+
+func (this *AccessorError) Unwrap() error {
+	return this.Err
+}
+
+func (this *IndexError) Error() string {
+	return this.Message
+}
+
+func (this *FunctionCallError) Error() string {
+	return fmt.Sprintf("Function '%s' failed: %v", this.Name, this.Err)
+}
+
+func (this *FunctionCallError) Unwrap() error {
+	return this.Err
+}
+
+/*
+	When parsing fails with a *ParseError, these don't know the expression they came from.
+	This adds the line, column, and an excerpt of [expression] to such errors.
+	Other errors are returned as-is.
+*/
+func locateParseError(err error, expression string) error {
+
+	located, ok := err.(*ParseError)
+	if !ok || located.End <= 0 || expression == "" {
+		return err
+	}
+
+	located.Line, located.Column, located.Excerpt = describeSpan([]rune(expression), located.Start, located.End)
+	return located
+}
+
+/*
+	Errors from parameters, accessors, functions, and operators which check their own operands are created without knowing where in the expression they happened.
+	This returns a copy of such errors with the location of the [stage] which returned them.
+	Other errors are returned as-is.
+*/
+func locateEvaluationError(err error, stage *evaluationStage) error {
+
+	start, end := stage.start, stage.end
+
+	switch typed := err.(type) {
+	case *TypeMismatchError:
+		if typed.End == 0 {
+			located := *typed
+			located.Start, located.End = start, end
+			return &located
+		}
+	case *MissingParameterError:
+		if typed.End == 0 {
+			located := *typed
+			located.Start, located.End = start, end
+			return &located
+		}
+	case *AccessorError:
+		if typed.End == 0 {
+			located := *typed
+			located.Start, located.End = start, end
+			return &located
+		}
+	case *FunctionCallError:
+		if typed.End == 0 {
+			located := *typed
+			located.Start, located.End = start, end
+			return &located
+		}
+	case *IndexError:
+		if typed.End == 0 {
+			located := *typed
+			located.Start, located.End = start, end
+			return &located
+		}
+	}
+	return err
+}
+
+/*
+	Finds the one-based line and column of [start] in [source],
+	and returns the line it is on, followed by another line with carets underneath the characters between [start] and [end].
+*/
+func describeSpan(source []rune, start int, end int) (int, int, string) {
+
+	var buffer bytes.Buffer
+	var line, lineStart, lineEnd int
+
+	if start > len(source) {
+		start = len(source)
+	}
+
+	line = 1
+	for i := 0; i < start; i++ {
+		if source[i] == '\n' {
+			line++
+			lineStart = i + 1
+		}
+	}
+
+	lineEnd = lineStart
+	for lineEnd < len(source) && source[lineEnd] != '\n' {
+		lineEnd++
+	}
+
+	if end > lineEnd {
+		end = lineEnd
+	}
+
+	buffer.WriteString(string(source[lineStart:lineEnd]))
+	buffer.WriteString("\n")
+
+	// keep tabs, so that the carets line up with the excerpt however wide a tab is shown.
+	for _, character := range source[lineStart:start] {
+		if character == '\t' {
+			buffer.WriteRune('\t')
+		} else {
+			buffer.WriteRune(' ')
+		}
+	}
+
+	if end <= start {
+		end = start + 1
+	}
+	buffer.WriteString(strings.Repeat("^", end-start))
+
+	return line, start - lineStart + 1, buffer.String()
+}
@@ -0,0 +1,489 @@
+package govaluate
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+	ExpressionNode is a single node of the abstract syntax tree of a parsed expression.
+	The tree is obtained through `EvaluableExpression.AST()`, and can be traversed with `Walk` or `Inspect`.
+
+	Every node is one of *LiteralNode, *VariableNode, *AccessorNode, *FunctionNode,
+	*IndexNode, *UnaryNode, *BinaryNode, *TernaryNode or *ArrayNode.
+*/
+type ExpressionNode interface {
+
+	/*
+		Returns the direct children of this node, in evaluation order.
+		Leaf nodes return nil.
+	*/
+	Children() []ExpressionNode
+
+	/*
+		Returns an expression string equivalent to this node, which can be parsed again.
+		Parenthesis are added around every nested operator, regardless of precedence.
+	*/
+	String() string
+
+	expressionNode()
+}
+
+/*
+	A constant value. Literals which were folded together during planning (such as "1 + 2") are represented by a single literal.
+	The null literal has a nil Value.
+
+	String() writes times as quoted RFC 3339 dates, which parse back as date literals.
+	NaN and infinities have no literal form, and are written as "NaN", "+Inf" and "-Inf", which don't parse back to them.
+*/
+type LiteralNode struct {
+	Value interface{}
+}
+
+/*
+	A reference to a parameter, such as "foo" or "[escaped name]".
+*/
+type VariableNode struct {
+	Name string
+}
+
+/*
+	A field or method access on a parameter, such as "foo.Bar" or "foo.Bar(1, 2)".
+	Path always begins with the parameter name. Names accessed optionally (as in "user?.profile") begin with '?'.
+	IsMethodCall is true when the last element of the path is called like a function, in which case Arguments holds its arguments.
+*/
+type AccessorNode struct {
+	Path         []string
+	IsMethodCall bool
+	Arguments    []ExpressionNode
+}
+
+/*
+	A call to one of the functions given to `NewEvaluableExpressionWithFunctions`,
+	or to "exists" or "has", which test whether the parameter or accessor they're given is present.
+*/
+type FunctionNode struct {
+	Name      string
+	Arguments []ExpressionNode
+}
+
+/*
+	An index of a value, such as "items[0]" or "prices[currency]".
+*/
+type IndexNode struct {
+	Value, Key ExpressionNode
+}
+
+/*
+	A prefix operator (NEGATE, INVERT or BITWISE_NOT) applied to a single operand.
+*/
+type UnaryNode struct {
+	Operator OperatorSymbol
+	Operand  ExpressionNode
+}
+
+/*
+	Any operator which takes a left and right side, such as PLUS, AND, IN or COALESCE.
+*/
+type BinaryNode struct {
+	Operator    OperatorSymbol
+	Left, Right ExpressionNode
+}
+
+/*
+	A ternary "condition ? then : else" expression.
+	Else is nil if the expression has no ":" branch, in which case the expression evaluates to nil when Condition is false.
+*/
+type TernaryNode struct {
+	Condition, Then, Else ExpressionNode
+}
+
+/*
+	A list of values created by the separator operator, such as "(1, 2, 3)".
+*/
+type ArrayNode struct {
+	Elements []ExpressionNode
+}
+
+func (this *LiteralNode) expressionNode()  {}
+func (this *VariableNode) expressionNode() {}
+func (this *AccessorNode) expressionNode() {}
+func (this *FunctionNode) expressionNode() {}
+func (this *IndexNode) expressionNode()    {}
+func (this *UnaryNode) expressionNode()    {}
+func (this *BinaryNode) expressionNode()   {}
+func (this *TernaryNode) expressionNode()  {}
+func (this *ArrayNode) expressionNode()    {}
+
+func (this *LiteralNode) Children() []ExpressionNode {
+	return nil
+}
+
+func (this *VariableNode) Children() []ExpressionNode {
+	return nil
+}
+
+func (this *AccessorNode) Children() []ExpressionNode {
+	return this.Arguments
+}
+
+func (this *FunctionNode) Children() []ExpressionNode {
+	return this.Arguments
+}
+
+func (this *IndexNode) Children() []ExpressionNode {
+	return []ExpressionNode{this.Value, this.Key}
+}
+
+func (this *UnaryNode) Children() []ExpressionNode {
+	return []ExpressionNode{this.Operand}
+}
+
+func (this *BinaryNode) Children() []ExpressionNode {
+	return []ExpressionNode{this.Left, this.Right}
+}
+
+func (this *TernaryNode) Children() []ExpressionNode {
+
+	if this.Else == nil {
+		return []ExpressionNode{this.Condition, this.Then}
+	}
+	return []ExpressionNode{this.Condition, this.Then, this.Else}
+}
+
+func (this *ArrayNode) Children() []ExpressionNode {
+	return this.Elements
+}
+
+func (this *LiteralNode) String() string {
+
+	switch value := this.Value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return quoteString(value)
+	case *regexp.Regexp:
+		return quoteString(value.String())
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case uint64:
+		return strconv.FormatUint(value, 10)
+	case Decimal:
+		return value.String()
+	case *big.Int:
+		return value.String()
+	case *big.Float:
+		return value.Text('f', -1)
+	case time.Time:
+		return quoteString(value.Format(time.RFC3339Nano))
+	case []interface{}:
+
+		elements := make([]ExpressionNode, len(value))
+		for i, element := range value {
+			elements[i] = &LiteralNode{Value: element}
+		}
+		return (&ArrayNode{Elements: elements}).String()
+	}
+	return convert2Str(this.Value)
+}
+
+func (this *VariableNode) String() string {
+
+	// names which would otherwise be read as literals or operators.
+	switch this.Name {
+	case "true", "false", "null", "NULL", "in", "IN":
+		return "[" + this.Name + "]"
+	}
+
+	for _, character := range this.Name {
+		if !isVariableName(character) || character == '.' {
+			return "[" + this.Name + "]"
+		}
+	}
+
+	if this.Name == "" || !unicode.IsLetter(getFirstRune(this.Name)) {
+		return "[" + this.Name + "]"
+	}
+	return this.Name
+}
+
+func (this *AccessorNode) String() string {
+
+	ret := joinAccessorPath(this.Path)
+	if this.IsMethodCall {
+		ret += "(" + joinNodes(this.Arguments) + ")"
+	}
+	return ret
+}
+
+func (this *FunctionNode) String() string {
+	return this.Name + "(" + joinNodes(this.Arguments) + ")"
+}
+
+func (this *IndexNode) String() string {
+
+	// indexes bind more tightly than prefixes, so "-a" must be in parenthesis to be indexed.
+	value := nestedNodeString(this.Value)
+	if _, isUnary := this.Value.(*UnaryNode); isUnary {
+		value = "(" + value + ")"
+	}
+	return value + "[" + this.Key.String() + "]"
+}
+
+func (this *UnaryNode) String() string {
+	return this.Operator.String() + nestedNodeString(this.Operand)
+}
+
+func (this *BinaryNode) String() string {
+
+	var operator string
+
+	// EQ stringifies as "=" for error messages, which isn't valid syntax.
+	switch this.Operator {
+	case EQ:
+		operator = "=="
+	default:
+		operator = this.Operator.String()
+	}
+
+	return nestedNodeString(this.Left) + " " + operator + " " + nestedNodeString(this.Right)
+}
+
+func (this *TernaryNode) String() string {
+
+	ret := nestedNodeString(this.Condition) + " ? " + nestedNodeString(this.Then)
+	if this.Else != nil {
+		ret += " : " + nestedNodeString(this.Else)
+	}
+	return ret
+}
+
+func (this *ArrayNode) String() string {
+	return "(" + joinNodes(this.Elements) + ")"
+}
+
+/*
+	Returns the abstract syntax tree of this expression, or nil if the expression is empty.
+	The tree is built from the planned form of the expression, so operator precedence is already resolved,
+	parenthesis do not appear as nodes, and sub-expressions consisting only of literals are already folded into a single LiteralNode.
+*/
+func (this EvaluableExpression) AST() ExpressionNode {
+
+	if this.evaluationStages == nil {
+		return nil
+	}
+	return stageToNode(this.evaluationStages)
+}
+
+/*
+	Converts a planned stage (and everything beneath it) to its node representation.
+*/
+func stageToNode(stage *evaluationStage) ExpressionNode {
+
+	if stage == nil {
+		return nil
+	}
+
+	switch stage.symbol {
+
+	case NOOP:
+		return stageToNode(stage.rightStage)
+
+	case LITERAL:
+		value, _, _, _ := stage.operator(nil, nil, nil, nil, nil)
+		return &LiteralNode{Value: value}
+
+	case VALUE:
+		return &VariableNode{Name: stage.name}
+
+	case ACCESS:
+		return &AccessorNode{
+			Path:         stage.path,
+			IsMethodCall: stage.rightStage != nil,
+			Arguments:    stageToArguments(stage.rightStage),
+		}
+
+	case FUNCTIONAL:
+		arguments := stageToArguments(stage.rightStage)
+
+		// an array in its own parenthesis, as in "foo((1, 2))", is a single argument.
+		if len(arguments) > 1 && !stage.spreads {
+			arguments = []ExpressionNode{&ArrayNode{Elements: arguments}}
+		}
+		return &FunctionNode{
+			Name:      stage.name,
+			Arguments: arguments,
+		}
+
+	case EXISTS:
+		var argument ExpressionNode = &VariableNode{Name: stage.path[0]}
+		if len(stage.path) > 1 {
+			argument = &AccessorNode{Path: stage.path}
+		}
+		return &FunctionNode{
+			Name:      stage.name,
+			Arguments: []ExpressionNode{argument},
+		}
+
+	case NEGATE:
+		fallthrough
+	case INVERT:
+		fallthrough
+	case BITWISE_NOT:
+		return &UnaryNode{
+			Operator: stage.symbol,
+			Operand:  stageToNode(stage.rightStage),
+		}
+
+	case SEPARATE:
+		return &ArrayNode{Elements: stageToElements(stage)}
+
+	case INDEX:
+		return &IndexNode{
+			Value: stageToNode(stage.leftStage),
+			Key:   stageToNode(stage.rightStage),
+		}
+
+	case TERNARY_TRUE:
+		return &TernaryNode{
+			Condition: stageToNode(stage.leftStage),
+			Then:      stageToNode(stage.rightStage),
+		}
+
+	case TERNARY_FALSE:
+
+		// "a ? b : c" is planned as ":" whose left side is "a ? b".
+		if stage.leftStage != nil && stage.leftStage.symbol == TERNARY_TRUE {
+			return &TernaryNode{
+				Condition: stageToNode(stage.leftStage.leftStage),
+				Then:      stageToNode(stage.leftStage.rightStage),
+				Else:      stageToNode(stage.rightStage),
+			}
+		}
+	}
+
+	return &BinaryNode{
+		Operator: stage.symbol,
+		Left:     stageToNode(stage.leftStage),
+		Right:    stageToNode(stage.rightStage),
+	}
+}
+
+/*
+	Separators are planned as a left-leaning chain, "(1, 2, 3)" being "((1, 2), 3)".
+	This flattens that chain into a single list of elements.
+	Parenthesized arrays nested inside of the chain are kept as their own elements.
+*/
+func stageToElements(stage *evaluationStage) []ExpressionNode {
+
+	var ret []ExpressionNode
+
+	if stage.leftStage != nil && stage.leftStage.symbol == SEPARATE {
+		ret = stageToElements(stage.leftStage)
+	} else {
+		ret = append(ret, stageToNode(stage.leftStage))
+	}
+
+	return append(ret, stageToNode(stage.rightStage))
+}
+
+/*
+	Arguments to functions and methods are planned as a single clause, which may contain a separator.
+*/
+func stageToArguments(stage *evaluationStage) []ExpressionNode {
+
+	if stage == nil {
+		return nil
+	}
+
+	if stage.symbol == NOOP {
+		stage = stage.rightStage
+	}
+
+	if stage == nil {
+		return []ExpressionNode{}
+	}
+
+	if stage.symbol == SEPARATE {
+		return stageToElements(stage)
+	}
+	return []ExpressionNode{stageToNode(stage)}
+}
+
+func nestedNodeString(node ExpressionNode) string {
+
+	switch node.(type) {
+	case nil:
+		return ""
+	case *BinaryNode, *TernaryNode:
+		return "(" + node.String() + ")"
+	}
+	return node.String()
+}
+
+func joinNodes(nodes []ExpressionNode) string {
+
+	var buffer bytes.Buffer
+
+	for i, node := range nodes {
+
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+		buffer.WriteString(node.String())
+	}
+	return buffer.String()
+}
+
+/*
+	Quotes [value] as a string literal which parses back to the same string.
+	Quotes and backslashes are escaped, as are control characters and invalid UTF-8, which would otherwise be hard to see or lost.
+*/
+func quoteString(value string) string {
+
+	var buffer bytes.Buffer
+
+	buffer.WriteRune('\'')
+	for index, character := range value {
+
+		// bytes which aren't valid UTF-8 (such as from "\xff") are kept as they were written.
+		if character == utf8.RuneError {
+
+			_, size := utf8.DecodeRuneInString(value[index:])
+			if size == 1 {
+				buffer.WriteString(fmt.Sprintf("\\x%02x", value[index]))
+				continue
+			}
+		}
+
+		switch character {
+		case '\'', '"', '\\':
+			buffer.WriteRune('\\')
+			buffer.WriteRune(character)
+		case '\n':
+			buffer.WriteString("\\n")
+		case '\r':
+			buffer.WriteString("\\r")
+		case '\t':
+			buffer.WriteString("\\t")
+		default:
+			if unicode.IsControl(character) {
+				buffer.WriteString(strings.Trim(strconv.QuoteRuneToASCII(character), "'"))
+				continue
+			}
+			buffer.WriteRune(character)
+		}
+	}
+	buffer.WriteRune('\'')
+
+	return buffer.String()
+}
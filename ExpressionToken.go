@@ -6,4 +6,12 @@ package govaluate
 type ExpressionToken struct {
 	Kind  TokenKind
 	Value interface{}
+
+	// The character offsets of this token in the expression it was parsed from;
+	// Start is the offset of its first character, End is the offset just past its last.
+	// Both are zero for tokens which were not parsed from an expression string.
+	Start, End int
+
+	// the name a FUNCTION token was looked up by, since its Value is the function itself.
+	name string
 }
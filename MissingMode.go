@@ -0,0 +1,95 @@
+package govaluate
+
+import (
+	"fmt"
+)
+
+/*
+	What an evaluation does when the parameters it's given don't have a variable the expression uses,
+	set through `EvaluableExpression.Missing`. A variable is missing if `Parameters.Get` returns an error for it.
+	Whatever the mode, `exists` and `has` (such as "exists(discount)") test whether a variable was given, without failing.
+*/
+type MissingMode int
+
+const (
+
+	/*
+		The evaluation stops with the error `Parameters.Get` returned, such as a *MissingParameterError. This is the default.
+	*/
+	MISSING_ERROR MissingMode = iota
+
+	/*
+		Missing variables are nil, so that they can be replaced with `??` (as in "discount ?? 0").
+	*/
+	MISSING_NIL
+
+	/*
+		Missing variables are taken from `EvaluableExpression.Defaults` instead.
+		Variables which are missing from those as well are errors.
+	*/
+	MISSING_DEFAULT
+)
+
+/*
+	Returns a string representation of this mode.
+*/
+func (this MissingMode) String() string {
+
+	switch this {
+	case MISSING_ERROR:
+		return "error"
+	case MISSING_NIL:
+		return "nil"
+	case MISSING_DEFAULT:
+		return "default"
+	}
+	return fmt.Sprintf("MissingMode(%d)", int(this))
+}
+
+/*
+	Sets the policy this evaluation uses for missing variables. [defaults] are only used by MISSING_DEFAULT,
+	and have their numbers converted in the same way as the evaluation's parameters.
+*/
+func (this *evaluationState) setMissing(mode MissingMode, defaults Parameters, numbers NumericMode) {
+
+	this.missing = mode
+	if mode == MISSING_DEFAULT && defaults != nil {
+		this.defaults = sanitizeParameters(defaults, numbers)
+	}
+}
+
+/*
+	Returns the parameter of the given [name], applying the evaluation's policy if it's missing.
+	Every parameter an evaluation reads goes through here, other than those tested by `exists`, and those at the start of accessors.
+*/
+func (this *evaluationState) Get(name string) (interface{}, error) {
+
+	value, err := this.Parameters.Get(name)
+	if err == nil {
+		return value, nil
+	}
+	return this.missingValue(name, err)
+}
+
+/*
+	Returns what the parameter (or accessor path) of the given [name] is when it's missing, according to the evaluation's policy.
+	[err] is the error it's missing with, returned unless the policy gives it a value.
+*/
+func (this *evaluationState) missingValue(name string, err error) (interface{}, error) {
+
+	switch this.missing {
+	case MISSING_NIL:
+		return nil, nil
+
+	case MISSING_DEFAULT:
+		if this.defaults == nil {
+			break
+		}
+
+		value, defaultErr := this.defaults.Get(name)
+		if defaultErr == nil {
+			return value, nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,95 @@
+package govaluate
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+/*
+	What an evaluation does when an arithmetic operator divides by zero, or gives NaN or an infinity,
+	set through `EvaluableExpression.NonFinite`.
+	Without a policy, such results carry on into the rest of the expression, where (for instance) every comparison with NaN is false.
+*/
+type NonFiniteMode int
+
+const (
+
+	/*
+		Results are left as IEEE 754 gives them: "1 / 0" is +Inf, and "0 / 0" is NaN. This is the default.
+	*/
+	NONFINITE_IEEE NonFiniteMode = iota
+
+	/*
+		The evaluation stops with an *ArithmeticError, which names the operator and its operands.
+	*/
+	NONFINITE_ERROR
+
+	/*
+		The result is nil instead, so that it can be replaced with `??` (as in "(total / count) ?? 0").
+	*/
+	NONFINITE_NIL
+)
+
+/*
+	Returns a string representation of this mode.
+*/
+func (this NonFiniteMode) String() string {
+
+	switch this {
+	case NONFINITE_IEEE:
+		return "ieee"
+	case NONFINITE_ERROR:
+		return "error"
+	case NONFINITE_NIL:
+		return "nil"
+	}
+	return fmt.Sprintf("NonFiniteMode(%d)", int(this))
+}
+
+/*
+	Applies the evaluation's policy to the [result] an operator gave for [left] and [right].
+	Only arithmetic operators are checked, since nothing else does arithmetic of its own.
+*/
+func (this *evaluationState) checkFinite(stage *evaluationStage, left, right, result interface{}) (interface{}, error) {
+
+	switch stage.symbol {
+	case PLUS, MINUS, MULTIPLY, DIVIDE, MODULUS, EXPONENT, NEGATE:
+	default:
+		return result, nil
+	}
+
+	if !isNonFinite(result) {
+		return result, nil
+	}
+
+	if this.nonFinite == NONFINITE_NIL {
+		return nil, nil
+	}
+
+	return nil, &ArithmeticError{
+		Operator: stage.symbol,
+		Left:     left,
+		Right:    right,
+		Result:   result,
+		Start:    stage.start,
+		End:      stage.end,
+	}
+}
+
+/*
+	Returns true if [value] is NaN or an infinity.
+	Results in every numeric mode are covered, since integers, decimals, and big numbers that can't be finite are float64s.
+*/
+func isNonFinite(value interface{}) bool {
+
+	switch typed := value.(type) {
+	case float64:
+		return math.IsInf(typed, 0) || math.IsNaN(typed)
+	case float32:
+		return math.IsInf(float64(typed), 0) || math.IsNaN(float64(typed))
+	case *big.Float:
+		return typed != nil && typed.IsInf()
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package govaluate
+
+import (
+	"fmt"
+)
+
+/*
+	How an expression represents numbers, set by `ParseOptions.Numbers`.
+*/
+type NumericMode int
+
+const (
+
+	/*
+		Every number (literal, parameter, or field) is converted to a float64, and all arithmetic is done on float64s.
+		Integers beyond 2^53 can't be held exactly. This is the default.
+	*/
+	NUMBERS_FLOAT NumericMode = iota
+
+	/*
+		Integer literals, and parameters and fields of any integer type, are kept as int64
+		(or uint64, for values too large for an int64). Operators given two integers give an exact integer result,
+		and only give a float64 when mixed with a float64, or when the result isn't an integer (such as "7 / 2")
+		or doesn't fit in 64 bits. See `MANUAL.md` for the details of each operator.
+	*/
+	NUMBERS_INTEGER
+
+	/*
+		Every number (literal, parameter, or field) is converted to an exact `Decimal`, and all arithmetic is done on decimals.
+		Addition, subtraction, multiplication and modulus are exact. Division and negative exponents are exact if they can be,
+		otherwise they're rounded to `ParseOptions.DecimalPlaces` using `ParseOptions.Rounding`.
+		Only NaN and infinities (such as from dividing by zero) remain float64s.
+	*/
+	NUMBERS_DECIMAL
+)
+
+/*
+	Returns a string representation of this mode.
+*/
+func (this NumericMode) String() string {
+
+	switch this {
+	case NUMBERS_FLOAT:
+		return "float"
+	case NUMBERS_INTEGER:
+		return "integer"
+	case NUMBERS_DECIMAL:
+		return "decimal"
+	}
+	return fmt.Sprintf("NumericMode(%d)", int(this))
+}
+
+/*
+	Converts a number given to an expression (as a parameter, or by an accessor) into the type this mode uses for it.
+	Anything else is returned as-is.
+*/
+func (this NumericMode) sanitize(value interface{}) interface{} {
+
+	switch this {
+	case NUMBERS_INTEGER:
+		return castToInteger(value)
+	case NUMBERS_DECIMAL:
+		return castToDecimal(value)
+	}
+	return castToFloat64(value)
+}
+
+/*
+	Returns the operators this mode uses instead of the default ones, dividing decimals with [decimals].
+	The default mode has none.
+*/
+func (this NumericMode) operators(decimals decimalContext) map[OperatorSymbol]evaluationOperator {
+
+	switch this {
+	case NUMBERS_INTEGER:
+		return integerStageSymbolMap
+	case NUMBERS_DECIMAL:
+		return makeDecimalStageSymbolMap(decimals)
+	}
+	return nil
+}
+
+/*
+	Returns the operator for the given [symbol] from [operators], or the default one if it isn't there.
+*/
+func findNumericOperator(operators map[OperatorSymbol]evaluationOperator, symbol OperatorSymbol) evaluationOperator {
+
+	operator, found := operators[symbol]
+	if found {
+		return operator
+	}
+	return stageSymbolMap[symbol]
+}
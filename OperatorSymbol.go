@@ -46,6 +46,8 @@ const (
 	FUNCTIONAL
 	ACCESS
 	SEPARATE
+	EXISTS
+	INDEX
 )
 
 type operatorPrecedence int
@@ -132,6 +134,10 @@ func findOperatorPrecedenceForSymbol(symbol OperatorSymbol) operatorPrecedence {
 		return ternaryPrecedence
 	case ACCESS:
 		fallthrough
+	case EXISTS:
+		fallthrough
+	case INDEX:
+		fallthrough
 	case FUNCTIONAL:
 		return functionalPrecedence
 	case SEPARATE:
@@ -304,6 +310,10 @@ func (this OperatorSymbol) String() string {
 		return ":"
 	case COALESCE:
 		return "??"
+	case EXISTS:
+		return "exists"
+	case INDEX:
+		return "[]"
 	}
 	return ""
 }
@@ -0,0 +1,75 @@
+package govaluate
+
+/*
+	Everything which can be given to `NewEvaluableExpressionWithOptions`, to change how an expression is parsed.
+	The zero value parses the same way as `NewEvaluableExpression`.
+
+	The Max* fields limit how large an expression may be, so that expressions from untrusted sources can't use
+	unbounded time or memory while being parsed, planned, or evaluated. Zero means no limit.
+	Exceeding any of them returns a *ParseError wrapping a *BudgetExceededError.
+*/
+type ParseOptions struct {
+
+	/*
+		Functions which the expression may call. See `NewEvaluableExpressionWithFunctions`.
+	*/
+	Functions map[string]ExpressionFunction
+
+	/*
+		Functions which the expression may call, which are given the evaluation's context.
+		See `NewEvaluableExpressionWithContextFunctions`.
+	*/
+	ContextFunctions map[string]ContextExpressionFunction
+
+	/*
+		The maximum length of the expression string, in bytes.
+	*/
+	MaxLength int
+
+	/*
+		The maximum number of tokens in the expression, counting each operator, value, and parenthesis.
+	*/
+	MaxTokens int
+
+	/*
+		The maximum nesting depth of parenthesis, including those around function and method arguments.
+		"(a + (b))" has a depth of 2.
+	*/
+	MaxDepth int
+
+	/*
+		How numbers are represented. By default, every number is a float64.
+		See `NumericMode`.
+	*/
+	Numbers NumericMode
+
+	/*
+		With `NUMBERS_DECIMAL`, the number of digits after the decimal point that results are rounded to,
+		if they can't be held exactly (such as "1 / 3"). Zero means `DEFAULT_DECIMAL_PLACES`.
+	*/
+	DecimalPlaces int
+
+	/*
+		With `NUMBERS_DECIMAL`, how results are rounded to `DecimalPlaces`, and how the built-in "round" function rounds.
+		By default, `ROUND_HALF_EVEN`.
+	*/
+	Rounding RoundingMode
+
+	/*
+		Strings quoted with ' or " treat a backslash as earlier versions did, keeping whatever follows it as-is,
+		rather than as a Go escape sequence. "'\d+'" is "d+", and "'\n'" is "n". Raw strings aren't affected.
+	*/
+	LegacyEscapes bool
+}
+
+/*
+	Returns how decimal results are rounded, using the default places if none are set.
+*/
+func (this ParseOptions) decimals() decimalContext {
+
+	ret := decimalContext{places: this.DecimalPlaces, rounding: this.Rounding}
+	if ret.places == 0 {
+		ret.places = DEFAULT_DECIMAL_PLACES
+	}
+	return ret
+}
@@ -0,0 +1,512 @@
+package govaluate
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+	An expression compiled into a flat list of instructions, which are run by a stack machine.
+	This is an alternative to evaluating an `EvaluableExpression` directly, created by `EvaluableExpression.Program()`.
+
+	Programs evaluate to the same results (and errors) as the expression they were compiled from.
+	Unlike expressions, they count against `Limits` and check for cancellation in the same way they always evaluate,
+	rather than falling back to a slower one. They can also be saved with `MarshalJSON` and loaded again with `LoadProgram`, without parsing the expression again.
+
+	A Program is safe to evaluate from multiple goroutines at once.
+*/
+type Program struct {
+
+	/*
+		Whether or not to check types when evaluating. See `EvaluableExpression.ChecksTypes`.
+	*/
+	ChecksTypes bool
+
+	/*
+		Limits on how much work each evaluation may do. See `EvaluationLimits`.
+		Programs count each instruction they run as a stage, which is about one per operator, value, or function call in the expression.
+	*/
+	Limits EvaluationLimits
+
+	/*
+		What arithmetic operators do when they divide by zero, or give NaN or an infinity. See `NonFiniteMode`.
+	*/
+	NonFinite NonFiniteMode
+
+	/*
+		What evaluations do when their parameters don't have a variable the program uses. See `MissingMode`.
+	*/
+	Missing MissingMode
+
+	/*
+		The values of missing variables, when `Missing` is MISSING_DEFAULT. See `EvaluableExpression.Defaults`.
+	*/
+	Defaults Parameters
+
+	instructions []instruction
+	constants    []interface{}
+	numbers      NumericMode
+	decimals     decimalContext
+
+	// the most values that will ever be on the stack at once.
+	stackSize int
+}
+
+type opcode int
+
+const (
+	opConstant opcode = iota
+	opParameter
+	opAccess
+	opCall
+	opUnary
+	opBinary
+	opArray
+	opShortCircuit
+	opExists
+)
+
+type instruction struct {
+	opcode opcode
+
+	/*
+		For opConstant, the index of the constant to push.
+		For opAccess, the number of values popped as arguments to a method (zero or one).
+		For opCall, one if the popped value is a list of arguments to spread, zero if it's a single argument.
+		For opArray, the number of values popped into the array.
+		For opShortCircuit, the index of the instruction to jump to.
+	*/
+	argument int
+
+	// the operator, type checks, and location of the stage this instruction was compiled from. Has no children.
+	stage *evaluationStage
+}
+
+/*
+	Stacks are reused between evaluations, so that evaluating a program doesn't need to allocate one.
+*/
+var programStacks = sync.Pool{
+	New: func() interface{} {
+		return new([]interface{})
+	},
+}
+
+/*
+	Compiles this expression into a `Program`, which has the same `ChecksTypes`, `Limits` and `NonFinite` as this expression.
+	Returns nil if the expression is empty.
+*/
+func (this EvaluableExpression) Program() *Program {
+
+	if this.evaluationStages == nil {
+		return nil
+	}
+
+	ret := &Program{
+		ChecksTypes: this.ChecksTypes,
+		Limits:      this.Limits,
+		NonFinite:   this.NonFinite,
+		Missing:     this.Missing,
+		Defaults:    this.Defaults,
+		numbers:     this.numbers,
+		decimals:    this.decimals,
+	}
+
+	ret.compileStage(this.evaluationStages)
+
+	// compiled programs are always well-formed.
+	ret.stackSize, _ = measureStack(ret.instructions, len(ret.constants))
+	return ret
+}
+
+/*
+	Same as `EvaluableExpression.Evaluate`.
+*/
+func (this *Program) Evaluate(parameters map[string]interface{}) (interface{}, error) {
+
+	if parameters == nil {
+		return this.Eval(nil)
+	}
+	return this.Eval(MapParameters(parameters))
+}
+
+/*
+	Same as `EvaluableExpression.Eval`.
+*/
+func (this *Program) Eval(parameters Parameters) (interface{}, error) {
+	return this.EvalContext(context.Background(), parameters)
+}
+
+/*
+	Same as `EvaluableExpression.EvalContext`. Cancellation is checked before every instruction.
+*/
+func (this *Program) EvalContext(ctx context.Context, parameters Parameters) (interface{}, error) {
+
+	if this == nil || len(this.instructions) == 0 {
+		return nil, nil
+	}
+
+	parameters = sanitizeParameters(parameters, this.numbers)
+
+	state := newEvaluationState(ctx, parameters, this.Limits, this.ChecksTypes)
+	state.nonFinite = this.NonFinite
+	state.setMissing(this.Missing, this.Defaults, this.numbers)
+
+	return this.run(state)
+}
+
+func (this *Program) run(state *evaluationState) (interface{}, error) {
+
+	var current *instruction
+	var left, right, result interface{}
+	var err error
+
+	pooled := programStacks.Get().(*[]interface{})
+	stack := *pooled
+	if cap(stack) < this.stackSize {
+		stack = make([]interface{}, 0, this.stackSize)
+	}
+
+	defer func() {
+
+		// don't keep evaluated values alive while the stack is pooled.
+		stack = stack[:cap(stack)]
+		for i := range stack {
+			stack[i] = nil
+		}
+		*pooled = stack[:0]
+		programStacks.Put(pooled)
+	}()
+
+	for pc := 0; pc < len(this.instructions); pc++ {
+
+		current = &this.instructions[pc]
+
+		err = state.checkCancelled()
+		if err != nil {
+			return nil, err
+		}
+
+		if state.limits != nil && current.opcode != opShortCircuit {
+			err = state.startStage(current.stage, current.isCall())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		switch current.opcode {
+
+		case opConstant:
+			stack = append(stack, this.constants[current.argument])
+			continue
+
+		case opParameter:
+			result, err = state.Get(current.stage.name)
+			if err != nil {
+				return nil, locateEvaluationError(err, current.stage)
+			}
+			stack = append(stack, result)
+			continue
+
+		case opExists:
+			result, _, _, err = current.stage.operator(nil, nil, nil, nil, state)
+			if err != nil {
+				return nil, locateEvaluationError(err, current.stage)
+			}
+
+		case opShortCircuit:
+			if current.shortCircuits(stack[len(stack)-1]) {
+
+				// "a ? b" is nil when a is false.
+				if current.stage.symbol == TERNARY_TRUE {
+					stack[len(stack)-1] = nil
+				}
+				pc = current.argument - 1
+			}
+			continue
+
+		case opArray:
+			array := make([]interface{}, current.argument)
+			copy(array, stack[len(stack)-current.argument:])
+			stack = stack[:len(stack)-current.argument]
+			result = array
+
+		case opAccess, opCall, opUnary:
+			right = nil
+			if current.opcode != opAccess || current.argument > 0 {
+				right = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+
+			if current.opcode == opUnary && state.checksTypes {
+				err = checkOperandTypes(current.stage, nil, right)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			result, _, _, err = current.stage.operator(nil, right, nil, nil, state)
+			if err != nil {
+				return nil, locateEvaluationError(err, current.stage)
+			}
+
+			if current.opcode == opUnary && state.nonFinite != NONFINITE_IEEE {
+				result, err = state.checkFinite(current.stage, nil, right, result)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+		case opBinary:
+			left = stack[len(stack)-2]
+			right = stack[len(stack)-1]
+			stack = stack[:len(stack)-2]
+
+			if state.checksTypes {
+				err = checkOperandTypes(current.stage, left, right)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			result, _, _, err = current.stage.operator(left, right, nil, nil, state)
+			if err != nil {
+				return nil, locateEvaluationError(err, current.stage)
+			}
+
+			if state.nonFinite != NONFINITE_IEEE {
+				result, err = state.checkFinite(current.stage, left, right, result)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if state.limits != nil {
+			err = state.checkResult(result, current.stage, current.isCall())
+			if err != nil {
+				return nil, err
+			}
+		}
+		stack = append(stack, result)
+	}
+
+	return stack[0], nil
+}
+
+/*
+	Returns true if this instruction calls a function, or a method of a parameter.
+*/
+func (this *instruction) isCall() bool {
+	return this.opcode == opCall || (this.opcode == opAccess && this.argument > 0)
+}
+
+/*
+	Returns true if the short-circuiting operator of this instruction doesn't need to evaluate its right side,
+	given the value of its left side.
+*/
+func (this *instruction) shortCircuits(left interface{}) bool {
+
+	switch this.stage.symbol {
+	case AND, TERNARY_TRUE:
+		return left == false
+	case OR:
+		return left == true
+	case TERNARY_FALSE, COALESCE:
+		return left != nil
+	}
+	return false
+}
+
+/*
+	Appends the instructions which evaluate [stage] (and everything beneath it) to this program.
+*/
+func (this *Program) compileStage(stage *evaluationStage) {
+
+	if stage == nil {
+		this.emitConstant(nil, nil)
+		return
+	}
+
+	switch stage.symbol {
+
+	case LITERAL:
+		value, _, _, _ := stage.operator(nil, nil, nil, nil, nil)
+		this.emitConstant(value, stage)
+
+	case VALUE:
+		this.emit(opParameter, 0, stage)
+
+	case EXISTS:
+		this.emit(opExists, 0, stage)
+
+	case NOOP:
+		this.compileStage(stage.rightStage)
+
+	case SEPARATE:
+		var elements []*evaluationStage
+
+		// the whole chain of separators builds one array, see `compileSeparator`.
+		element := stage
+		for ; element != nil && element.symbol == SEPARATE; element = element.leftStage {
+			elements = append(elements, element.rightStage)
+		}
+		elements = append(elements, element)
+
+		for i := len(elements) - 1; i >= 0; i-- {
+			this.compileStage(elements[i])
+		}
+		this.emit(opArray, len(elements), stage)
+
+	case AND, OR, TERNARY_TRUE, TERNARY_FALSE, COALESCE:
+		this.compileStage(stage.leftStage)
+		jump := this.emit(opShortCircuit, 0, stage)
+		this.compileStage(stage.rightStage)
+		this.emit(opBinary, 0, stage)
+		this.instructions[jump].argument = len(this.instructions)
+
+	case IN:
+		this.compileStage(stage.leftStage)
+
+		// constant arrays are built once, see `compileMembership`.
+		if isConstant(stage.rightStage) {
+
+			value, err := compileOperand(stage.rightStage)(nil)
+			if err == nil {
+				this.emitConstant(value, stage.rightStage)
+				this.emit(opBinary, 0, stage)
+				return
+			}
+		}
+		this.compileStage(stage.rightStage)
+		this.emit(opBinary, 0, stage)
+
+	case FUNCTIONAL:
+		this.compileStage(stage.rightStage)
+
+		if stage.spreads {
+			this.emit(opCall, 1, stage)
+			return
+		}
+		this.emit(opCall, 0, stage)
+
+	case ACCESS:
+		if stage.rightStage == nil {
+			this.emit(opAccess, 0, stage)
+			return
+		}
+		this.compileStage(stage.rightStage)
+		this.emit(opAccess, 1, stage)
+
+	default:
+		if stage.leftStage == nil {
+			this.compileStage(stage.rightStage)
+			this.emit(opUnary, 0, stage)
+			return
+		}
+		this.compileStage(stage.leftStage)
+		this.compileStage(stage.rightStage)
+		this.emit(opBinary, 0, stage)
+	}
+}
+
+/*
+	Appends an instruction, returning its index.
+*/
+func (this *Program) emit(code opcode, argument int, stage *evaluationStage) int {
+
+	instructionStage := new(evaluationStage)
+	instructionStage.setToNonStage(*stage)
+
+	this.instructions = append(this.instructions, instruction{
+		opcode:   code,
+		argument: argument,
+		stage:    instructionStage,
+	})
+	return len(this.instructions) - 1
+}
+
+/*
+	Appends an instruction to push [value], which came from [stage].
+	Missing sides of operators have no stage, and are pushed as nil.
+*/
+func (this *Program) emitConstant(value interface{}, stage *evaluationStage) {
+
+	if stage == nil {
+		stage = &evaluationStage{symbol: LITERAL}
+	}
+
+	this.constants = append(this.constants, value)
+	this.emit(opConstant, len(this.constants)-1, stage)
+}
+
+/*
+	Runs through [instructions] to find how deep the stack gets.
+	Returns false if the instructions would ever pop more values than they pushed, jump anywhere but forward,
+	jump to somewhere with a different stack depth, refer to a constant that isn't there, or finish with anything but one value.
+*/
+func measureStack(instructions []instruction, constants int) (int, bool) {
+
+	var depth, deepest int
+
+	depths := make([]int, len(instructions)+1)
+
+	for pc, current := range instructions {
+
+		depths[pc] = depth
+
+		switch current.opcode {
+		case opConstant:
+			if current.argument < 0 || current.argument >= constants {
+				return 0, false
+			}
+			depth++
+		case opParameter, opExists:
+			depth++
+		case opAccess:
+			if current.argument != 0 && current.argument != 1 {
+				return 0, false
+			}
+			if current.argument == 1 && depth < 1 {
+				return 0, false
+			}
+			depth += 1 - current.argument
+		case opCall, opUnary:
+			if depth < 1 || current.argument < 0 || current.argument > 1 {
+				return 0, false
+			}
+		case opBinary:
+			if depth < 2 {
+				return 0, false
+			}
+			depth--
+		case opArray:
+			if current.argument < 0 || depth < current.argument {
+				return 0, false
+			}
+			depth += 1 - current.argument
+		case opShortCircuit:
+			if depth < 1 || current.argument <= pc || current.argument > len(instructions) {
+				return 0, false
+			}
+		default:
+			return 0, false
+		}
+
+		if depth < 0 {
+			return 0, false
+		}
+		if depth > deepest {
+			deepest = depth
+		}
+	}
+	depths[len(instructions)] = depth
+
+	// jumps skip the right side of an operator and the operator itself, which together leave the stack as it was.
+	for pc, current := range instructions {
+		if current.opcode == opShortCircuit && depths[current.argument] != depths[pc] {
+			return 0, false
+		}
+	}
+
+	return deepest, depth == 1
+}
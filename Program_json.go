@@ -0,0 +1,332 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+)
+
+/*
+	The version of the format written by `Program.MarshalJSON`. Programs of any other version can't be loaded.
+*/
+const programFormatVersion int = 1
+
+type programJSON struct {
+	Version      int               `json:"version"`
+	Instructions []instructionJSON `json:"instructions"`
+	Constants    []constantJSON    `json:"constants"`
+	Numbers      NumericMode       `json:"numbers,omitempty"`
+	Places       int               `json:"places,omitempty"`
+	Rounding     RoundingMode      `json:"rounding,omitempty"`
+}
+
+type instructionJSON struct {
+	Opcode   opcode         `json:"op"`
+	Argument int            `json:"arg,omitempty"`
+	Symbol   OperatorSymbol `json:"symbol"`
+	Name     string         `json:"name,omitempty"`
+	Path     []string       `json:"path,omitempty"`
+	Format   string         `json:"format,omitempty"`
+	Start    int            `json:"start,omitempty"`
+	End      int            `json:"end,omitempty"`
+}
+
+/*
+	A constant is encoded with exactly one of its fields set, or none for nil.
+	Numbers are kept as strings, since JSON has no way to write infinities or NaN (nor integers beyond 2^53 exactly).
+*/
+type constantJSON struct {
+	Number   *string         `json:"number,omitempty"`
+	Integer  *string         `json:"integer,omitempty"`
+	Unsigned *string         `json:"unsigned,omitempty"`
+	Decimal  *string         `json:"decimal,omitempty"`
+	BigInt   *string         `json:"bigInt,omitempty"`
+	BigFloat *string         `json:"bigFloat,omitempty"`
+	String   *string         `json:"string,omitempty"`
+	Bool     *bool           `json:"bool,omitempty"`
+	Regexp   *string         `json:"regexp,omitempty"`
+	Array    *[]constantJSON `json:"array,omitempty"`
+
+	// the precision of a BigFloat, in bits.
+	Precision uint `json:"precision,omitempty"`
+}
+
+/*
+	Encodes this program as JSON, which can be loaded again with `LoadProgram`.
+	Functions are written by name, and must be given again when loading.
+	`ChecksTypes`, `Limits`, `NonFinite`, `Missing` and `Defaults` are not written.
+
+	Returns an error if the program has a constant that can't be encoded, which only happens
+	if it was compiled from an expression created by `NewEvaluableExpressionFromTokens` with unusual literal values.
+*/
+func (this *Program) MarshalJSON() ([]byte, error) {
+
+	var err error
+
+	encoded := programJSON{
+		Version:      programFormatVersion,
+		Instructions: make([]instructionJSON, len(this.instructions)),
+		Constants:    make([]constantJSON, len(this.constants)),
+		Numbers:      this.numbers,
+		Places:       this.decimals.places,
+		Rounding:     this.decimals.rounding,
+	}
+
+	for i, current := range this.instructions {
+
+		encoded.Instructions[i] = instructionJSON{
+			Opcode:   current.opcode,
+			Argument: current.argument,
+			Symbol:   current.stage.symbol,
+			Name:     current.stage.name,
+			Path:     current.stage.path,
+			Format:   current.stage.typeErrorFormat,
+			Start:    current.stage.start,
+			End:      current.stage.end,
+		}
+	}
+
+	for i, constant := range this.constants {
+
+		encoded.Constants[i], err = encodeConstant(constant)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(encoded)
+}
+
+/*
+	Loads a program encoded by `Program.MarshalJSON`.
+	[options] must have every function the program calls; its limits are ignored.
+	The returned program checks types, has no `Limits`, leaves non-finite results as they are, and fails on missing parameters.
+*/
+func LoadProgram(data []byte, options ParseOptions) (*Program, error) {
+
+	var encoded programJSON
+	var ret *Program
+	var operators map[OperatorSymbol]evaluationOperator
+	var valid bool
+	var err error
+
+	err = json.Unmarshal(data, &encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	if encoded.Version != programFormatVersion {
+		return nil, fmt.Errorf("Unable to load program of version %d, expected version %d", encoded.Version, programFormatVersion)
+	}
+
+	ret = &Program{
+		ChecksTypes:  true,
+		instructions: make([]instruction, len(encoded.Instructions)),
+		constants:    make([]interface{}, len(encoded.Constants)),
+		numbers:      encoded.Numbers,
+		decimals:     decimalContext{places: encoded.Places, rounding: encoded.Rounding},
+	}
+	operators = ret.numbers.operators(ret.decimals)
+
+	for i, constant := range encoded.Constants {
+
+		ret.constants[i], err = decodeConstant(constant)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, current := range encoded.Instructions {
+
+		ret.instructions[i], err = decodeInstruction(current, ret.numbers, ret.decimals, operators, options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ret.stackSize, valid = measureStack(ret.instructions, len(ret.constants))
+	if !valid {
+		return nil, fmt.Errorf("Unable to load program, its instructions are malformed")
+	}
+	return ret, nil
+}
+
+/*
+	Recreates an instruction, and the stage it was compiled from.
+*/
+func decodeInstruction(encoded instructionJSON, numbers NumericMode, decimals decimalContext, operators map[OperatorSymbol]evaluationOperator, options ParseOptions) (instruction, error) {
+
+	stage := &evaluationStage{
+		symbol:          encoded.Symbol,
+		typeErrorFormat: encoded.Format,
+		name:            encoded.Name,
+		path:            encoded.Path,
+		start:           encoded.Start,
+		end:             encoded.End,
+	}
+
+	checks := findTypeChecks(encoded.Symbol)
+	stage.leftTypeCheck = checks.left
+	stage.rightTypeCheck = checks.right
+	stage.typeCheck = checks.combined
+
+	switch encoded.Opcode {
+
+	case opAccess:
+		if len(encoded.Path) == 0 {
+			return instruction{}, fmt.Errorf("Unable to load program, accessor has no path")
+		}
+		stage.operator = makeAccessorStage(encoded.Path, encoded.Argument > 0, numbers)
+
+	case opExists:
+		if len(encoded.Path) == 0 {
+			return instruction{}, fmt.Errorf("Unable to load program, presence test has no path")
+		}
+		stage.operator = makePresenceStage(encoded.Path)
+
+	case opCall:
+		function, found := options.Functions[encoded.Name]
+		if found {
+			stage.operator = makeFunctionStage(encoded.Name, function, encoded.Argument > 0)
+			break
+		}
+
+		contextFunction, found := options.ContextFunctions[encoded.Name]
+		if found {
+			stage.operator = makeContextFunctionStage(encoded.Name, contextFunction, encoded.Argument > 0)
+			break
+		}
+
+		function, found = findBuiltinFunction(encoded.Name, numbers, decimals.rounding)
+		if found {
+			stage.operator = makeFunctionStage(encoded.Name, function, encoded.Argument > 0)
+			break
+		}
+		return instruction{}, &UndefinedFunctionError{Name: encoded.Name}
+
+	case opUnary, opBinary:
+		stage.operator = findNumericOperator(operators, encoded.Symbol)
+		if stage.operator == nil {
+			return instruction{}, fmt.Errorf("Unable to load program, unknown operator %d", int(encoded.Symbol))
+		}
+	}
+
+	return instruction{
+		opcode:   encoded.Opcode,
+		argument: encoded.Argument,
+		stage:    stage,
+	}, nil
+}
+
+func encodeConstant(value interface{}) (constantJSON, error) {
+
+	var ret constantJSON
+
+	switch typed := value.(type) {
+
+	case nil:
+
+	case float64:
+		number := strconv.FormatFloat(typed, 'g', -1, 64)
+		ret.Number = &number
+
+	case int64:
+		number := strconv.FormatInt(typed, 10)
+		ret.Integer = &number
+
+	case uint64:
+		number := strconv.FormatUint(typed, 10)
+		ret.Unsigned = &number
+
+	case Decimal:
+		number := typed.String()
+		ret.Decimal = &number
+
+	case *big.Int:
+		number := typed.String()
+		ret.BigInt = &number
+
+	case *big.Float:
+		number := typed.Text('g', -1)
+		ret.BigFloat = &number
+		ret.Precision = typed.Prec()
+
+	case string:
+		ret.String = &typed
+
+	case bool:
+		ret.Bool = &typed
+
+	case *regexp.Regexp:
+		pattern := typed.String()
+		ret.Regexp = &pattern
+
+	case []interface{}:
+		elements := make([]constantJSON, len(typed))
+		for i, element := range typed {
+
+			encoded, err := encodeConstant(element)
+			if err != nil {
+				return ret, err
+			}
+			elements[i] = encoded
+		}
+		ret.Array = &elements
+
+	default:
+		return ret, fmt.Errorf("Unable to encode constant '%v' of type %T", value, value)
+	}
+	return ret, nil
+}
+
+func decodeConstant(encoded constantJSON) (interface{}, error) {
+
+	switch {
+	case encoded.Number != nil:
+		return strconv.ParseFloat(*encoded.Number, 64)
+
+	case encoded.Integer != nil:
+		return strconv.ParseInt(*encoded.Integer, 10, 64)
+
+	case encoded.Unsigned != nil:
+		return strconv.ParseUint(*encoded.Unsigned, 10, 64)
+
+	case encoded.Decimal != nil:
+		return ParseDecimal(*encoded.Decimal)
+
+	case encoded.BigInt != nil:
+		number, ok := new(big.Int).SetString(*encoded.BigInt, 10)
+		if !ok {
+			return nil, fmt.Errorf("Unable to load program, '%s' is not an integer", *encoded.BigInt)
+		}
+		return number, nil
+
+	case encoded.BigFloat != nil:
+		number, _, err := big.ParseFloat(*encoded.BigFloat, 10, encoded.Precision, big.ToNearestEven)
+		return number, err
+
+	case encoded.String != nil:
+		return *encoded.String, nil
+
+	case encoded.Bool != nil:
+		return *encoded.Bool, nil
+
+	case encoded.Regexp != nil:
+		return regexp.Compile(*encoded.Regexp)
+
+	case encoded.Array != nil:
+		ret := make([]interface{}, len(*encoded.Array))
+		for i, element := range *encoded.Array {
+
+			decoded, err := decodeConstant(element)
+			if err != nil {
+				return nil, err
+			}
+			ret[i] = decoded
+		}
+		return ret, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,120 @@
+package govaluate
+
+import (
+	"fmt"
+	"math/big"
+)
+
+/*
+	How a decimal is rounded when it has more digits than can be kept, set by `ParseOptions.Rounding`, or given to `Decimal.Round`.
+*/
+type RoundingMode int
+
+const (
+
+	/*
+		Rounds to the nearest digit, or to the nearest even digit if exactly halfway ("2.5" to "2", "3.5" to "4").
+		This is the default, and avoids the upward bias of always rounding halves up.
+	*/
+	ROUND_HALF_EVEN RoundingMode = iota
+
+	/*
+		Rounds to the nearest digit, or away from zero if exactly halfway ("2.5" to "3", "-2.5" to "-3").
+	*/
+	ROUND_HALF_UP
+
+	/*
+		Rounds to the nearest digit, or towards zero if exactly halfway ("2.5" to "2", "-2.5" to "-2").
+	*/
+	ROUND_HALF_DOWN
+
+	/*
+		Rounds away from zero ("2.1" to "3", "-2.1" to "-3").
+	*/
+	ROUND_UP
+
+	/*
+		Rounds towards zero, truncating ("2.9" to "2", "-2.9" to "-2").
+	*/
+	ROUND_DOWN
+
+	/*
+		Rounds towards positive infinity ("2.1" to "3", "-2.9" to "-2").
+	*/
+	ROUND_CEILING
+
+	/*
+		Rounds towards negative infinity ("2.9" to "2", "-2.1" to "-3").
+	*/
+	ROUND_FLOOR
+)
+
+/*
+	Returns a string representation of this rounding mode.
+*/
+func (this RoundingMode) String() string {
+
+	switch this {
+	case ROUND_HALF_EVEN:
+		return "half-even"
+	case ROUND_HALF_UP:
+		return "half-up"
+	case ROUND_HALF_DOWN:
+		return "half-down"
+	case ROUND_UP:
+		return "up"
+	case ROUND_DOWN:
+		return "down"
+	case ROUND_CEILING:
+		return "ceiling"
+	case ROUND_FLOOR:
+		return "floor"
+	}
+	return fmt.Sprintf("RoundingMode(%d)", int(this))
+}
+
+/*
+	Divides [numerator] by [denominator] (which must be positive), rounding the quotient to an integer.
+*/
+func (this RoundingMode) divide(numerator, denominator *big.Int) *big.Int {
+
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+
+	// the remainder has the sign of the numerator, and so of the exact quotient.
+	negative := remainder.Sign() < 0
+
+	// how the remainder compares to half of the denominator.
+	doubled := new(big.Int).Abs(remainder)
+	half := doubled.Lsh(doubled, 1).Cmp(denominator)
+
+	var away bool
+
+	switch this {
+	case ROUND_HALF_UP:
+		away = half >= 0
+	case ROUND_HALF_DOWN:
+		away = half > 0
+	case ROUND_UP:
+		away = true
+	case ROUND_DOWN:
+		away = false
+	case ROUND_CEILING:
+		away = !negative
+	case ROUND_FLOOR:
+		away = negative
+	default:
+		away = half > 0 || (half == 0 && quotient.Bit(0) == 1)
+	}
+
+	if !away {
+		return quotient
+	}
+
+	if negative {
+		return quotient.Sub(quotient, big.NewInt(1))
+	}
+	return quotient.Add(quotient, big.NewInt(1))
+}
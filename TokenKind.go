@@ -27,6 +27,12 @@ const (
 	CLAUSE_CLOSE
 
 	TERNARY
+
+	COMMENT
+	NULL
+
+	BRACKET
+	BRACKET_CLOSE
 )
 
 /*
@@ -69,6 +75,14 @@ func (kind TokenKind) String() string {
 		return "TERNARY"
 	case ACCESSOR:
 		return "ACCESSOR"
+	case COMMENT:
+		return "COMMENT"
+	case NULL:
+		return "NULL"
+	case BRACKET:
+		return "BRACKET"
+	case BRACKET_CLOSE:
+		return "BRACKET_CLOSE"
 	}
 
 	return "UNKNOWN"
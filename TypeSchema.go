@@ -0,0 +1,140 @@
+package govaluate
+
+import (
+	"reflect"
+)
+
+/*
+	The types of the variables (and accessor paths, such as "foo.Bar") that an expression may use.
+	Used to check an expression before it is evaluated, see `EvaluableExpression.Check`.
+*/
+type TypeSchema map[string]ValueType
+
+/*
+	The signatures of the parameter methods an expression may call, by accessor path (such as "foo.Describe").
+*/
+type MethodSchema map[string]MethodSignature
+
+/*
+	Describes a method that can be called on a parameter, such as "foo.Describe('x')".
+*/
+type MethodSignature struct {
+
+	/*
+		The types of the method's arguments.
+		A context.Context first argument isn't included, since it's given the evaluation's context rather than an argument from the expression.
+	*/
+	Arguments []ValueType
+
+	// the type of the method's result.
+	Result ValueType
+
+	// whether the method also returns an error, which fails the evaluation if it isn't nil. Any other second result is ignored.
+	ReturnsError bool
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+/*
+	Creates a schema for parameters of the given Go types, keyed by the name of each parameter.
+	Each parameter is walked the same way accessors would walk it when evaluating: exported fields of structs
+	(including those of nested structs, and of pointers to structs) are given by their path, such as "foo.Nested.Field",
+	as are exported methods which return one or two values (the second being an error, or ignored).
+
+	Methods are added to both the returned TypeSchema (by the type they return) and MethodSchema.
+	Since only the last link of an accessor can be a method, methods are included for the parameter and each of its nested fields,
+	such as "foo.Nested.Describe", but nothing is included for what a method returns.
+	Maps have no fields which can be known ahead of time, so only the map itself is included.
+*/
+func NewTypeSchema(parameters map[string]reflect.Type) (TypeSchema, MethodSchema) {
+
+	types := make(TypeSchema)
+	methods := make(MethodSchema)
+
+	for name, reflected := range parameters {
+		describeType(name, reflected, types, methods, nil)
+	}
+	return types, methods
+}
+
+/*
+	Adds the type at [path] to [types], and then every field and method which can be accessed from it.
+	[visiting] holds the types described further up the path, so that recursive types stop rather than recurse forever.
+*/
+func describeType(path string, reflected reflect.Type, types TypeSchema, methods MethodSchema, visiting []reflect.Type) {
+
+	types[path] = valueTypeOf(reflected)
+
+	if reflected == nil {
+		return
+	}
+
+	for _, visited := range visiting {
+		if visited == reflected {
+			return
+		}
+	}
+	visiting = append(visiting, reflected)
+
+	// accessors resolve one pointer, and look up methods on both it and the struct it points to.
+	structType := reflected
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	// structs which are values in their own right (such as times and decimals) have nothing to access.
+	if structType.Kind() != reflect.Struct || valueTypeOf(reflected)&TYPE_STRUCT == 0 {
+		return
+	}
+
+	for _, field := range reflect.VisibleFields(structType) {
+
+		if !field.IsExported() {
+			continue
+		}
+		describeType(path+"."+field.Name, field.Type, types, methods, visiting)
+	}
+
+	for i := 0; i < reflected.NumMethod(); i++ {
+
+		method := reflected.Method(i)
+
+		signature, ok := describeMethod(method.Type)
+		if !ok {
+			continue
+		}
+
+		methodPath := path + "." + method.Name
+		methods[methodPath] = signature
+		types[methodPath] = signature.Result
+	}
+}
+
+/*
+	Describes the signature of a method (whose first argument is its receiver),
+	or returns false if it can't be called by an accessor because it doesn't return one or two values.
+*/
+func describeMethod(reflected reflect.Type) (MethodSignature, bool) {
+
+	var ret MethodSignature
+
+	switch reflected.NumOut() {
+	case 1:
+	case 2:
+		ret.ReturnsError = reflected.Out(1).Implements(errorType)
+	default:
+		return ret, false
+	}
+
+	ret.Result = valueTypeOf(reflected.Out(0))
+
+	for i := 1; i < reflected.NumIn(); i++ {
+
+		argument := reflected.In(i)
+		if i == 1 && argument == contextType {
+			continue
+		}
+		ret.Arguments = append(ret.Arguments, valueTypeOf(argument))
+	}
+	return ret, true
+}
@@ -0,0 +1,151 @@
+package govaluate
+
+/*
+	Parameters whose values can be set and read back without boxing them into an interface{}.
+	Meant to be kept and reused between evaluations by `EvaluableExpression.EvalFloat64` and `EvaluableExpression.EvalBool`,
+	which evaluate without allocating when given these; setting a parameter that was set before doesn't allocate either.
+
+	The zero value has no parameters, and is ready to use.
+	TypedParameters are not safe to set from multiple goroutines at once, or to set while being evaluated.
+*/
+type TypedParameters struct {
+	indexes map[string]int
+	values  []typedParameter
+}
+
+type typedParameterKind int
+
+const (
+	typedNumber typedParameterKind = iota
+	typedBool
+	typedValue
+)
+
+type typedParameter struct {
+	kind    typedParameterKind
+	number  float64
+	boolean bool
+	value   interface{}
+}
+
+/*
+	Sets the parameter of the given [name] to a number.
+*/
+func (this *TypedParameters) SetFloat64(name string, value float64) {
+
+	parameter := this.find(name)
+	*parameter = typedParameter{kind: typedNumber, number: value}
+}
+
+/*
+	Sets the parameter of the given [name] to a bool.
+*/
+func (this *TypedParameters) SetBool(name string, value bool) {
+
+	parameter := this.find(name)
+	*parameter = typedParameter{kind: typedBool, boolean: value}
+}
+
+/*
+	Sets the parameter of the given [name] to any other value, such as a string.
+*/
+func (this *TypedParameters) Set(name string, value interface{}) {
+
+	parameter := this.find(name)
+	*parameter = typedParameter{kind: typedValue, value: value}
+}
+
+/*
+	Returns the value of the parameter of the given [name], or a *MissingParameterError if it was never set.
+*/
+func (this *TypedParameters) Get(name string) (interface{}, error) {
+
+	index, found := this.indexes[name]
+	if !found {
+		return nil, &MissingParameterError{Name: name}
+	}
+
+	parameter := &this.values[index]
+	switch parameter.kind {
+	case typedNumber:
+		return parameter.number, nil
+	case typedBool:
+		return boolIface(parameter.boolean), nil
+	}
+	return parameter.value, nil
+}
+
+/*
+	Returns the parameter of the given [name], adding it if it was never set.
+*/
+func (this *TypedParameters) find(name string) *typedParameter {
+
+	index, found := this.indexes[name]
+	if found {
+		return &this.values[index]
+	}
+
+	if this.indexes == nil {
+		this.indexes = make(map[string]int)
+	}
+
+	this.indexes[name] = len(this.values)
+	this.values = append(this.values, typedParameter{})
+	return &this.values[len(this.values)-1]
+}
+
+/*
+	Returns the number held by the parameter of the given [name],
+	or false if it was never set or isn't a number that `sanitizedParameters` would convert to a float64.
+*/
+func (this *TypedParameters) getFloat64(name string) (float64, bool) {
+
+	index, found := this.indexes[name]
+	if !found {
+		return 0, false
+	}
+
+	parameter := &this.values[index]
+	switch parameter.kind {
+	case typedNumber:
+		return parameter.number, true
+	case typedValue:
+		return typedFloat64(parameter.value)
+	}
+	return 0, false
+}
+
+/*
+	Returns the bool held by the parameter of the given [name], or false if it was never set or isn't a bool.
+*/
+func (this *TypedParameters) getBool(name string) (bool, bool) {
+
+	index, found := this.indexes[name]
+	if !found {
+		return false, false
+	}
+
+	parameter := &this.values[index]
+	switch parameter.kind {
+	case typedBool:
+		return parameter.boolean, true
+	case typedValue:
+		value, ok := parameter.value.(bool)
+		return value, ok
+	}
+	return false, false
+}
+
+/*
+	Returns true if the parameter of the given [name] was set to nil.
+*/
+func (this *TypedParameters) isNil(name string) bool {
+
+	index, found := this.indexes[name]
+	if !found {
+		return false
+	}
+
+	parameter := &this.values[index]
+	return parameter.kind == typedValue && parameter.value == nil
+}
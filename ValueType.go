@@ -0,0 +1,155 @@
+package govaluate
+
+import (
+	"math/big"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+/*
+	The types of values that an expression can work with, as seen by its operators.
+	Types can be combined (with "|") into a union of types, such as for a value which could be either a number or nil.
+*/
+type ValueType int
+
+const (
+	TYPE_NUMBER ValueType = 1 << iota
+	TYPE_STRING
+	TYPE_BOOL
+	TYPE_TIME
+	TYPE_ARRAY
+	TYPE_MAP
+	TYPE_STRUCT
+	TYPE_NIL
+
+	// a value of unknown type, which could be any of the above.
+	TYPE_ANY = TYPE_NUMBER | TYPE_STRING | TYPE_BOOL | TYPE_TIME | TYPE_ARRAY | TYPE_MAP | TYPE_STRUCT | TYPE_NIL
+)
+
+var valueTypeNames = []struct {
+	valueType ValueType
+	name      string
+}{
+	{TYPE_NUMBER, "number"},
+	{TYPE_STRING, "string"},
+	{TYPE_BOOL, "bool"},
+	{TYPE_TIME, "time"},
+	{TYPE_ARRAY, "array"},
+	{TYPE_MAP, "map"},
+	{TYPE_STRUCT, "struct"},
+	{TYPE_NIL, "nil"},
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+var regexpType = reflect.TypeOf((*regexp.Regexp)(nil))
+var decimalType = reflect.TypeOf(Decimal{})
+var bigIntType = reflect.TypeOf((*big.Int)(nil))
+var bigFloatType = reflect.TypeOf((*big.Float)(nil))
+
+/*
+	Returns a string representation of this type, such as "number", or "number|nil" for unions.
+*/
+func (this ValueType) String() string {
+
+	var names []string
+
+	if this == TYPE_ANY {
+		return "any"
+	}
+
+	for _, typeName := range valueTypeNames {
+		if this&typeName.valueType != 0 {
+			names = append(names, typeName.name)
+		}
+	}
+
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+/*
+	Returns the single types which make up this (possibly union) type.
+*/
+func (this ValueType) members() []ValueType {
+
+	var ret []ValueType
+
+	for _, typeName := range valueTypeNames {
+		if this&typeName.valueType != 0 {
+			ret = append(ret, typeName.valueType)
+		}
+	}
+	return ret
+}
+
+/*
+	Returns a value of this single type, which the operators' type checks will treat the same as any other value of this type.
+	Strings are never numeric (though "5" would be).
+*/
+func (this ValueType) example() interface{} {
+
+	switch this {
+	case TYPE_NUMBER:
+		return 0.0
+	case TYPE_STRING:
+		return ""
+	case TYPE_BOOL:
+		return false
+	case TYPE_TIME:
+		return time.Time{}
+	case TYPE_ARRAY:
+		return []interface{}{}
+	case TYPE_MAP:
+		return map[string]interface{}{}
+	case TYPE_STRUCT:
+		return struct{}{}
+	}
+	return nil
+}
+
+/*
+	Returns the type that values of the given Go type have, once given to an expression.
+*/
+func valueTypeOf(reflected reflect.Type) ValueType {
+
+	if reflected == nil {
+		return TYPE_NIL
+	}
+
+	if reflected == timeType {
+		return TYPE_TIME
+	}
+
+	if reflected == decimalType || reflected == bigIntType || reflected == bigFloatType {
+		return TYPE_NUMBER
+	}
+
+	// constant regex patterns are compiled during parsing, but are still strings as far as their operators are concerned.
+	if reflected == regexpType {
+		return TYPE_STRING
+	}
+
+	switch reflected.Kind() {
+	case reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return TYPE_NUMBER
+	case reflect.String:
+		return TYPE_STRING
+	case reflect.Bool:
+		return TYPE_BOOL
+	case reflect.Slice, reflect.Array:
+		return TYPE_ARRAY
+	case reflect.Map:
+		return TYPE_MAP
+	case reflect.Struct:
+		return TYPE_STRUCT
+	case reflect.Ptr:
+		return valueTypeOf(reflected.Elem()) | TYPE_NIL
+	}
+	return TYPE_ANY
+}
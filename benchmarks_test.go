@@ -130,12 +130,149 @@ func BenchmarkEvaluationParametersModifiers(bench *testing.B) {
 	}
 }
 
+/*
+  Same as BenchmarkEvaluationParametersModifiers, but evaluates the planned stages instead of the compiled ones.
+  Compare the two to see what compilation gains.
+*/
+func BenchmarkPlannedParametersModifiers(bench *testing.B) {
+
+	expression, _ := NewEvaluableExpression("(requests_made * requests_succeeded / 100) >= 90")
+	expression.compiledStages = nil
+
+	parameters := map[string]interface{}{
+		"requests_made":      99.0,
+		"requests_succeeded": 90.0,
+	}
+
+	bench.ResetTimer()
+	for i := 0; i < bench.N; i++ {
+		expression.Evaluate(parameters)
+	}
+}
+
+/*
+  Benchmarks a typical rule, several comparisons joined by logical operators.
+*/
+func BenchmarkEvaluationRule(bench *testing.B) {
+
+	expression, _ := NewEvaluableExpression("age > 18 && country in ('CN', 'US', 'FR') && score >= 700 || vip")
+	parameters := map[string]interface{}{
+		"age":     30.0,
+		"country": "FR",
+		"score":   710.0,
+		"vip":     false,
+	}
+
+	bench.ResetTimer()
+	for i := 0; i < bench.N; i++ {
+		expression.Evaluate(parameters)
+	}
+}
+
+/*
+  Same as BenchmarkEvaluationRule, but evaluates the planned stages instead of the compiled ones.
+*/
+func BenchmarkPlannedRule(bench *testing.B) {
+
+	expression, _ := NewEvaluableExpression("age > 18 && country in ('CN', 'US', 'FR') && score >= 700 || vip")
+	expression.compiledStages = nil
+
+	parameters := map[string]interface{}{
+		"age":     30.0,
+		"country": "FR",
+		"score":   710.0,
+		"vip":     false,
+	}
+
+	bench.ResetTimer()
+	for i := 0; i < bench.N; i++ {
+		expression.Evaluate(parameters)
+	}
+}
+
+/*
+  Same as BenchmarkEvaluationRule, but evaluates a compiled `Program`.
+*/
+func BenchmarkProgramRule(bench *testing.B) {
+
+	expression, _ := NewEvaluableExpression("age > 18 && country in ('CN', 'US', 'FR') && score >= 700 || vip")
+	program := expression.Program()
+
+	parameters := map[string]interface{}{
+		"age":     30.0,
+		"country": "FR",
+		"score":   710.0,
+		"vip":     false,
+	}
+
+	bench.ResetTimer()
+	for i := 0; i < bench.N; i++ {
+		program.Evaluate(parameters)
+	}
+}
+
+/*
+  Same as BenchmarkEvaluationRule, but with limits set, which programs count without slowing down.
+*/
+func BenchmarkProgramRuleWithLimits(bench *testing.B) {
+
+	expression, _ := NewEvaluableExpression("age > 18 && country in ('CN', 'US', 'FR') && score >= 700 || vip")
+	program := expression.Program()
+	program.Limits = EvaluationLimits{MaxStages: 100}
+
+	parameters := map[string]interface{}{
+		"age":     30.0,
+		"country": "FR",
+		"score":   710.0,
+		"vip":     false,
+	}
+
+	bench.ResetTimer()
+	for i := 0; i < bench.N; i++ {
+		program.Evaluate(parameters)
+	}
+}
+
 /*
   Benchmarks the ludicrously-unlikely worst-case expression,
   one which uses all features.
   This is largely a canary benchmark to make sure that any syntax additions don't
   unnecessarily bloat the evaluation time.
 */
+func BenchmarkEvalFloat64(bench *testing.B) {
+
+	var parameters TypedParameters
+
+	expression, _ := NewEvaluableExpression("vip ? price * quantity * (1 - discount) : price * quantity")
+	parameters.SetFloat64("price", 12.5)
+	parameters.SetFloat64("quantity", 3)
+	parameters.SetFloat64("discount", 0.2)
+	parameters.SetBool("vip", true)
+
+	bench.ReportAllocs()
+	bench.ResetTimer()
+	for i := 0; i < bench.N; i++ {
+		parameters.SetFloat64("price", float64(i))
+		expression.EvalFloat64(&parameters)
+	}
+}
+
+func BenchmarkEvalBool(bench *testing.B) {
+
+	expression, _ := NewEvaluableExpression("age > 18 && score >= 700 || vip")
+	parameters := MapParameters{
+		"age":   30.0,
+		"score": 710.0,
+		"vip":   false,
+	}
+
+	bench.ReportAllocs()
+	bench.ResetTimer()
+	for i := 0; i < bench.N; i++ {
+		expression.EvalBool(parameters)
+	}
+}
+
 func BenchmarkComplexExpression(bench *testing.B) {
 
 	var expressionString string
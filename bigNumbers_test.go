@@ -0,0 +1,222 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+/*
+	Represents a test of an expression given *big.Int or *big.Float parameters.
+	Expected is the result formatted with its type, such as "5 (*big.Int)".
+*/
+type BigNumberTest struct {
+	Name     string
+	Input    string
+	Numbers  NumericMode
+	Expected string
+}
+
+func TestBigNumberEvaluation(test *testing.T) {
+
+	large, _ := new(big.Int).SetString("1267650600228229401496703205376", 10)
+	third := new(big.Float).SetPrec(200).Quo(big.NewFloat(1), big.NewFloat(3))
+
+	parameters := map[string]interface{}{
+		"large": large,
+		"same":  new(big.Int).Set(large),
+		"small": big.NewInt(12),
+		"third": third,
+	}
+
+	bigNumberTests := []BigNumberTest{
+		BigNumberTest{
+			Name:     "Addition",
+			Input:    "large + 1",
+			Expected: "1267650600228229401496703205377 (*big.Int)",
+		},
+		BigNumberTest{
+			Name:     "Subtraction",
+			Input:    "1 - large",
+			Expected: "-1267650600228229401496703205375 (*big.Int)",
+		},
+		BigNumberTest{
+			Name:     "Multiplication",
+			Input:    "large * large",
+			Expected: "1606938044258990275541962092341162602522202993782792835301376 (*big.Int)",
+		},
+		BigNumberTest{
+			Name:     "Fractional multiplication",
+			Input:    "small * 1.5",
+			Expected: "18 (*big.Float)",
+		},
+		BigNumberTest{
+			Name:     "Exact division",
+			Input:    "large / 1024",
+			Expected: "1237940039285380274899124224 (*big.Int)",
+		},
+		BigNumberTest{
+			Name:     "Inexact division",
+			Input:    "small / 8",
+			Expected: "1.5 (*big.Float)",
+		},
+		BigNumberTest{
+			Name:     "Division by zero",
+			Input:    "small / 0",
+			Expected: "+Inf (float64)",
+		},
+		BigNumberTest{
+			Name:     "Modulus",
+			Input:    "(large + 6) % 7",
+			Expected: "1 (*big.Int)",
+		},
+		BigNumberTest{
+			Name:     "Float modulus",
+			Input:    "-small % 2.5",
+			Expected: "-2 (*big.Float)",
+		},
+		BigNumberTest{
+			Name:     "Exponent",
+			Input:    "small ** 30",
+			Expected: "237376313799769806328950291431424 (*big.Int)",
+		},
+		BigNumberTest{
+			Name:     "Fractional exponent",
+			Input:    "small ** 0.5 > 3.46",
+			Expected: "true (bool)",
+		},
+		BigNumberTest{
+			Name:     "Bitwise",
+			Input:    "((large | 0xFF) & ~1) ^ 0x0F",
+			Expected: "1267650600228229401496703205617 (*big.Int)",
+		},
+		BigNumberTest{
+			Name:     "Shifts",
+			Input:    "(large >> 95) + (small << 100)",
+			Expected: "15211807202738752817960438464544 (*big.Int)",
+		},
+		BigNumberTest{
+			Name:     "Negation",
+			Input:    "-large",
+			Expected: "-1267650600228229401496703205376 (*big.Int)",
+		},
+		BigNumberTest{
+			Name:     "Comparison beyond float64",
+			Input:    "large + 1 > large && large < large + 1 && small >= 12 && small <= 12.0",
+			Expected: "true (bool)",
+		},
+		BigNumberTest{
+			Name:     "Equality",
+			Input:    "large == same && large != same + 1 && small == 12",
+			Expected: "true (bool)",
+		},
+		BigNumberTest{
+			Name:     "Membership",
+			Input:    "same in (1, large) && 12 in (0, small) && !(small in (1, large))",
+			Expected: "true (bool)",
+		},
+		BigNumberTest{
+			Name:     "Big floats keep their precision",
+			Input:    "third * 3 == 1 && third + 0.5 > 0.83",
+			Expected: "true (bool)",
+		},
+		BigNumberTest{
+			Name:     "Concatenation",
+			Input:    "'id-' + large",
+			Expected: "id-1267650600228229401496703205376 (string)",
+		},
+		BigNumberTest{
+			Name:     "Integer mode",
+			Input:    "large + 9007199254740993",
+			Numbers:  NUMBERS_INTEGER,
+			Expected: "1267650600228238408695957946369 (*big.Int)",
+		},
+		BigNumberTest{
+			Name:     "Decimal mode",
+			Input:    "small * 0.25",
+			Numbers:  NUMBERS_DECIMAL,
+			Expected: "3.00 (govaluate.Decimal)",
+		},
+	}
+
+	for _, bigNumberTest := range bigNumberTests {
+
+		expression, err := NewEvaluableExpressionWithOptions(bigNumberTest.Input, ParseOptions{Numbers: bigNumberTest.Numbers})
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %s", bigNumberTest.Name, err)
+			test.Fail()
+			continue
+		}
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil {
+			test.Logf("Test '%s' failed: %s", bigNumberTest.Name, err)
+			test.Fail()
+			continue
+		}
+
+		actual := fmt.Sprintf("%v (%T)", result, result)
+		if actual != bigNumberTest.Expected {
+			test.Logf("Test '%s' failed", bigNumberTest.Name)
+			test.Logf("Expected '%s', got '%s'", bigNumberTest.Expected, actual)
+			test.Fail()
+		}
+	}
+
+	// parameters must never be modified by the operators that use them.
+	if large.String() != "1267650600228229401496703205376" || parameters["small"].(*big.Int).Int64() != 12 {
+		test.Logf("Expected parameters to be unchanged, got %v", parameters)
+		test.Fail()
+	}
+}
+
+/*
+	Big numbers folded into literals by partial evaluation must be kept by programs, and described by schemas as numbers.
+*/
+func TestBigNumberLiterals(test *testing.T) {
+
+	large, _ := new(big.Int).SetString("1267650600228229401496703205376", 10)
+	precise := new(big.Float).SetPrec(200).SetInt64(3)
+
+	expression, _ := NewEvaluableExpression("x > large * 2 && x < precise")
+	residual := expression.PartialEval(MapParameters{"large": large, "precise": precise})
+
+	if residual.String() != "(x > 2535301200456458802993406410752) && (x < 3)" {
+		test.Logf("Unexpected residual '%s'", residual.String())
+		test.Fail()
+	}
+
+	encoded, err := json.Marshal(residual.Program())
+	if err != nil {
+		test.Logf("Failed to encode: %s", err)
+		test.Fail()
+		return
+	}
+
+	program, err := LoadProgram(encoded, ParseOptions{})
+	if err != nil {
+		test.Logf("Failed to load: %s", err)
+		test.Fail()
+		return
+	}
+
+	if !reflect.DeepEqual(program.constants, residual.Program().constants) {
+		test.Logf("Expected constants %v, got %v", residual.Program().constants, program.constants)
+		test.Fail()
+	}
+
+	schema, _ := NewTypeSchema(map[string]reflect.Type{"large": reflect.TypeOf(large), "precise": reflect.TypeOf(precise)})
+	if schema["large"] != TYPE_NUMBER || schema["precise"] != TYPE_NUMBER || len(schema) != 2 {
+		test.Logf("Expected big numbers to be numbers, got %v", schema)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpression("large / 2")
+	result, err := expression.EvalFloat64(MapParameters{"large": large})
+	if err != nil || result != 633825300114114700748351602688 {
+		test.Logf("Expected EvalFloat64 to convert a big number, got %v (%v)", result, err)
+		test.Fail()
+	}
+}
@@ -0,0 +1,241 @@
+package govaluate
+
+import (
+	"math"
+	"math/big"
+)
+
+/*
+	The most bits a *big.Int result of a shift or exponent may have, so that a short expression can't make an enormous number.
+	Results which would be larger are calculated as float64s instead.
+*/
+const maxBigBits = 1 << 16
+
+/*
+	Returns true if either operand is a *big.Int or *big.Float. Operators given one calculate with math/big, rather than float64s,
+	so that neither side loses precision:
+
+	If neither side is a *big.Float, and both are whole numbers, the result is an exact *big.Int.
+	Otherwise, if both are finite numbers, the result is a *big.Float with the precision of the more precise side.
+	Anything else (such as NaN, division by zero, or fractional exponents) is calculated with float64s, as if there were no big numbers.
+*/
+func isBigOperand(left, right interface{}) bool {
+
+	switch left.(type) {
+	case *big.Int, *big.Float:
+		return true
+	}
+
+	switch right.(type) {
+	case *big.Int, *big.Float:
+		return true
+	}
+	return false
+}
+
+/*
+	Calculates [left] and [right] with [integer] if they're both whole numbers, or [float] if they aren't (or [integer] returns nil).
+	Either may be nil, if the operator has no way to calculate those.
+	Returns false if neither could, in which case the operator should use float64s.
+*/
+func bigArithmetic(left, right interface{}, integer func(left, right *big.Int) *big.Int, float func(left, right *big.Float) *big.Float) (interface{}, bool) {
+
+	if integer != nil {
+
+		leftInteger, leftOk := bigIntOf(left)
+		rightInteger, rightOk := bigIntOf(right)
+
+		if leftOk && rightOk {
+
+			result := integer(leftInteger, rightInteger)
+			if result != nil {
+				return result, true
+			}
+		}
+	}
+
+	if float != nil {
+
+		leftFloat, leftOk := bigFloatOf(left)
+		rightFloat, rightOk := bigFloatOf(right)
+
+		if leftOk && rightOk {
+
+			result := float(leftFloat, rightFloat)
+			if result != nil {
+				return result, true
+			}
+		}
+	}
+	return nil, false
+}
+
+/*
+	Compares two numbers exactly, returning false if either isn't a finite number.
+*/
+func compareBig(left, right interface{}) (int, bool) {
+
+	leftFloat, leftOk := bigFloatOf(left)
+	rightFloat, rightOk := bigFloatOf(right)
+
+	if !leftOk || !rightOk {
+		return 0, false
+	}
+	return leftFloat.Cmp(rightFloat), true
+}
+
+/*
+	Returns a copy of [value] as a *big.Int, if it's a whole number other than a *big.Float.
+	Big floats are always calculated as floats, even if they're whole, so that their precision isn't lost.
+*/
+func bigIntOf(value interface{}) (*big.Int, bool) {
+
+	switch typed := value.(type) {
+	case *big.Int:
+		if typed == nil {
+			return nil, false
+		}
+		return new(big.Int).Set(typed), true
+
+	case *big.Float:
+		return nil, false
+
+	case float64:
+		return bigIntOfFloat(typed)
+
+	case float32:
+		return bigIntOfFloat(float64(typed))
+	}
+
+	_, kind := integerOf(value)
+	if kind == notInteger {
+		return nil, false
+	}
+	return bigIntegerOf(value), true
+}
+
+func bigIntOfFloat(value float64) (*big.Int, bool) {
+
+	if math.IsInf(value, 0) || math.IsNaN(value) || value != math.Trunc(value) {
+		return nil, false
+	}
+
+	ret, _ := big.NewFloat(value).Int(nil)
+	return ret, true
+}
+
+/*
+	Returns a copy of [value] as a *big.Float, if it's a finite number.
+	Floats keep their precision, and integers are given enough to be held exactly.
+*/
+func bigFloatOf(value interface{}) (*big.Float, bool) {
+
+	switch typed := value.(type) {
+	case *big.Float:
+		if typed == nil || typed.IsInf() {
+			return nil, false
+		}
+		return new(big.Float).Copy(typed), true
+
+	case *big.Int:
+		if typed == nil {
+			return nil, false
+		}
+		return new(big.Float).SetInt(typed), true
+
+	case float64:
+		if math.IsInf(typed, 0) || math.IsNaN(typed) {
+			return nil, false
+		}
+		return big.NewFloat(typed), true
+
+	case float32:
+		return bigFloatOf(float64(typed))
+	}
+
+	_, kind := integerOf(value)
+	if kind == notInteger {
+		return nil, false
+	}
+	return new(big.Float).SetInt(bigIntegerOf(value)), true
+}
+
+/*
+	Returns true if [left] and [right] are numbers of the same value, whatever their types.
+	Only used when either is a big number, and never fails - anything which isn't a number is unequal.
+*/
+func equalBig(left, right interface{}) bool {
+
+	comparison, ok := compareBig(left, right)
+	return ok && comparison == 0
+}
+
+func addBigFloat(left, right *big.Float) *big.Float {
+	return new(big.Float).Add(left, right)
+}
+
+func subtractBigFloat(left, right *big.Float) *big.Float {
+	return new(big.Float).Sub(left, right)
+}
+
+func multiplyBigFloat(left, right *big.Float) *big.Float {
+	return new(big.Float).Mul(left, right)
+}
+
+/*
+	Division by zero is left to float64s, which give infinities or NaN.
+*/
+func divideBigFloat(left, right *big.Float) *big.Float {
+
+	if right.Sign() == 0 {
+		return nil
+	}
+	return new(big.Float).Quo(left, right)
+}
+
+/*
+	Same as the float64 modulus, the result has the sign of the left side.
+*/
+func modulusBigFloat(left, right *big.Float) *big.Float {
+
+	if right.Sign() == 0 {
+		return nil
+	}
+
+	quotient := new(big.Float).Quo(left, right)
+	whole, _ := quotient.Int(nil)
+
+	ret := new(big.Float).Mul(right, new(big.Float).SetInt(whole))
+	return ret.Sub(left, ret)
+}
+
+/*
+	Unlike `exponentBigInt`, any exponent is calculated exactly, as long as the result isn't too large.
+*/
+func exponentBigIntUnbounded(left, right *big.Int) *big.Int {
+
+	if right.Sign() < 0 || !right.IsInt64() || int64(left.BitLen())*right.Int64() > maxBigBits {
+		return nil
+	}
+	return left.Exp(left, right, nil)
+}
+
+func leftShiftBigIntUnbounded(left, right *big.Int) *big.Int {
+
+	if right.Sign() < 0 || !right.IsInt64() || int64(left.BitLen())+right.Int64() > maxBigBits {
+		return nil
+	}
+	return left.Lsh(left, uint(right.Int64()))
+}
+
+func rightShiftBigIntUnbounded(left, right *big.Int) *big.Int {
+
+	if right.Sign() < 0 {
+		return nil
+	}
+
+	if !right.IsInt64() || right.Int64() > int64(left.BitLen()) {
+		right.SetInt64(int64(left.BitLen()))
+	}
+	return left.Rsh(left, uint(right.Int64()))
+}
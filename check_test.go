@@ -0,0 +1,217 @@
+package govaluate
+
+import (
+	"reflect"
+	"testing"
+)
+
+var checkSchema = TypeSchema{
+	"name":          TYPE_STRING,
+	"age":           TYPE_NUMBER,
+	"flag":          TYPE_BOOL,
+	"created":       TYPE_TIME,
+	"tags":          TYPE_ARRAY,
+	"labels":        TYPE_MAP,
+	"maybe":         TYPE_NUMBER | TYPE_NIL,
+	"unknown":       TYPE_ANY,
+	"foo":           TYPE_STRUCT,
+	"foo.Int":       TYPE_NUMBER,
+	"foo.String":    TYPE_STRING,
+	"foo.Nested":    TYPE_STRUCT,
+	"foo.Nested.Ok": TYPE_BOOL,
+}
+
+/*
+	Represents a test of checking an expression against `checkSchema`.
+	Expected is the error Check should return, or nil if it should pass.
+*/
+type CheckTest struct {
+	Name     string
+	Input    string
+	Expected error
+}
+
+func TestCheck(test *testing.T) {
+
+	checkTests := []CheckTest{
+		CheckTest{
+			Name:  "Numeric comparison",
+			Input: "age > 18 && flag",
+		},
+		CheckTest{
+			Name:  "String comparison",
+			Input: "name >= 'm'",
+		},
+		CheckTest{
+			Name:  "Concatenation",
+			Input: "name + age + 'x' == 'abc'",
+		},
+		CheckTest{
+			Name:  "Regex",
+			Input: "name =~ '^a' || name !~ name",
+		},
+		CheckTest{
+			Name:  "Membership",
+			Input: "name in tags && age in (1, 2)",
+		},
+		CheckTest{
+			Name:  "Ternary",
+			Input: "(flag ? age : 5) * 2",
+		},
+		CheckTest{
+			Name:  "Union",
+			Input: "maybe + 1 > 2 && (maybe ?? 0) - 1 > 0",
+		},
+		CheckTest{
+			Name:  "Any",
+			Input: "unknown + 1 > unknown",
+		},
+		CheckTest{
+			Name:  "Accessors",
+			Input: "foo.Int * 2 > 3 && foo.Nested.Ok && foo.Nested.Other && labels.x",
+		},
+		CheckTest{
+			Name:  "Functions",
+			Input: "-len(name)",
+		},
+		CheckTest{
+			Name:     "String compared to number",
+			Input:    "name > 5",
+			Expected: &TypeCheckError{Operator: GT, Type: TYPE_STRING, Start: 5, End: 6},
+		},
+		CheckTest{
+			Name:     "Bool added to number",
+			Input:    "flag + 1",
+			Expected: &TypeCheckError{Operator: PLUS, Type: TYPE_BOOL, Start: 5, End: 6},
+		},
+		CheckTest{
+			Name:     "Number in logical",
+			Input:    "flag && age",
+			Expected: &TypeCheckError{Operator: AND, Type: TYPE_NUMBER, Start: 5, End: 7},
+		},
+		CheckTest{
+			Name:     "Time subtracted",
+			Input:    "1 - created",
+			Expected: &TypeCheckError{Operator: MINUS, Type: TYPE_TIME, Start: 2, End: 3},
+		},
+		CheckTest{
+			Name:     "Membership of a non-array",
+			Input:    "age in name",
+			Expected: &TypeCheckError{Operator: IN, Type: TYPE_STRING, Start: 4, End: 6},
+		},
+		CheckTest{
+			Name:     "Ternary condition",
+			Input:    "age ? 1 : 2",
+			Expected: &TypeCheckError{Operator: TERNARY_TRUE, Type: TYPE_NUMBER, Start: 4, End: 5},
+		},
+		CheckTest{
+			Name:     "Nested mismatch",
+			Input:    "(name + 1) * 2",
+			Expected: &TypeCheckError{Operator: MULTIPLY, Type: TYPE_STRING, Start: 11, End: 12},
+		},
+		CheckTest{
+			Name:     "Ternary result",
+			Input:    "-(flag ? name : 'x')",
+			Expected: &TypeCheckError{Operator: NEGATE, Type: TYPE_STRING, Start: 0, End: 1},
+		},
+		CheckTest{
+			Name:     "Undeclared variable",
+			Input:    "missing > 1",
+			Expected: &MissingParameterError{Name: "missing", Start: 0, End: 7},
+		},
+		CheckTest{
+			Name:     "Undeclared accessor",
+			Input:    "missing.Field",
+			Expected: &MissingParameterError{Name: "missing", Start: 0, End: 13},
+		},
+		CheckTest{
+			Name:  "Accessor of a number",
+			Input: "age.Field",
+			Expected: &AccessorError{
+				Path:    []string{"age", "Field"},
+				Field:   "Field",
+				Message: "Unable to access 'Field', 'age' is not a struct or map",
+				Start:   0,
+				End:     9,
+			},
+		},
+	}
+
+	functions := map[string]ExpressionFunction{
+		"len": func(arguments ...interface{}) (interface{}, error) {
+			return float64(len(arguments[0].(string))), nil
+		},
+	}
+
+	for _, checkTest := range checkTests {
+
+		expression, err := NewEvaluableExpressionWithFunctions(checkTest.Input, functions)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %s", checkTest.Name, err)
+			test.Fail()
+			continue
+		}
+
+		err = expression.Check(checkSchema)
+		if !reflect.DeepEqual(err, checkTest.Expected) {
+			test.Logf("Test '%s' failed", checkTest.Name)
+			test.Logf("Expected error '%v' (%#v), got '%v' (%#v)", checkTest.Expected, checkTest.Expected, err, err)
+			test.Fail()
+		}
+	}
+}
+
+/*
+	Represents a test of inferring the result type of an expression, given `checkSchema`.
+*/
+type InferTypeTest struct {
+	Input    string
+	Expected ValueType
+}
+
+func TestInferType(test *testing.T) {
+
+	inferTypeTests := []InferTypeTest{
+		InferTypeTest{"age > 18 && flag", TYPE_BOOL},
+		InferTypeTest{"age * 2 + 1", TYPE_NUMBER},
+		InferTypeTest{"name + age", TYPE_STRING},
+		InferTypeTest{"'label: ' + (flag ? 'yes' : 'no')", TYPE_STRING},
+		InferTypeTest{"flag ? age", TYPE_NUMBER | TYPE_NIL},
+		InferTypeTest{"flag ? age : name", TYPE_NUMBER | TYPE_STRING},
+		InferTypeTest{"maybe ?? 0", TYPE_NUMBER},
+		InferTypeTest{"maybe ?? name", TYPE_NUMBER | TYPE_STRING},
+		InferTypeTest{"unknown + 1", TYPE_NUMBER | TYPE_STRING},
+		InferTypeTest{"(1, name)", TYPE_ARRAY},
+		InferTypeTest{"foo.Nested", TYPE_STRUCT},
+		InferTypeTest{"foo.Other", TYPE_ANY},
+		InferTypeTest{"created", TYPE_TIME},
+	}
+
+	for _, inferTypeTest := range inferTypeTests {
+
+		expression, err := NewEvaluableExpression(inferTypeTest.Input)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %s", inferTypeTest.Input, err)
+			test.Fail()
+			continue
+		}
+
+		actual, err := expression.InferType(checkSchema)
+		if err != nil || actual != inferTypeTest.Expected {
+			test.Logf("Expected '%s' to be %v, got %v (%v)", inferTypeTest.Input, inferTypeTest.Expected, actual, err)
+			test.Fail()
+		}
+	}
+
+	expression, _ := NewEvaluableExpression("name > 5")
+	_, err := expression.InferType(checkSchema)
+	if err == nil {
+		test.Logf("Expected inferring the type of a mismatched expression to fail")
+		test.Fail()
+	}
+
+	if (TYPE_NUMBER | TYPE_NIL).String() != "number|nil" || TYPE_ANY.String() != "any" {
+		test.Logf("Unexpected type names '%v' and '%v'", TYPE_NUMBER|TYPE_NIL, TYPE_ANY)
+		test.Fail()
+	}
+}
@@ -0,0 +1,93 @@
+package govaluate
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+/*
+	Expressions which compiled stages and programs must evaluate exactly like the planned stages they came from,
+	given `backendParameters` and `backendFunctions`.
+*/
+var backendInputs = []string{
+	"number * 2 + float / 5 - 1 % 3 ** 2",
+	"number > 5 && float <= 2.5 || text == 'abc'",
+	"number >= float",
+	"text + number",
+	"'10' == number",
+	"number != 10.0",
+	"-number + ~number",
+	"!flag ? 'no' : 'yes'",
+	"flag ? number",
+	"!flag ? number",
+	"nothing ?? text ?? 'default'",
+	"text in ('a', 'abc', number)",
+	"1.0 in list",
+	"(1, 2, (3, 4), text)",
+	"text =~ '^a' && text !~ 'z'",
+	"number & 3 | 4 ^ 1 << 2 >> 1",
+	"args(1, text, (2, 3))",
+	"args()",
+	"foo.Int + foo.FuncArgStr('x')",
+	"foo.Nested.Dunk('f') + foo.String",
+	"flag && text",
+	"text > 5",
+	"-text",
+	"missing + 1",
+	"false && missing",
+	"true || missing",
+	"foo.Missing",
+	"args(missing)",
+}
+
+var backendFunctions = map[string]ExpressionFunction{
+	"args": func(arguments ...interface{}) (interface{}, error) {
+		return fmt.Sprintf("%v", arguments), nil
+	},
+}
+
+var backendParameters = map[string]interface{}{
+	"number":  10,
+	"float":   2.5,
+	"text":    "abc",
+	"flag":    true,
+	"nothing": nil,
+	"list":    []interface{}{1.0, "b"},
+	"foo":     dummyParameterInstance,
+}
+
+/*
+	Compiled stages must behave exactly like the planned stages they were compiled from.
+	This evaluates each expression both ways, and compares the results and errors.
+*/
+func TestCompiledStages(test *testing.T) {
+
+	for _, input := range backendInputs {
+
+		expression, err := NewEvaluableExpressionWithFunctions(input, backendFunctions)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %s", input, err)
+			test.Fail()
+			continue
+		}
+
+		if expression.compiledStages == nil {
+			test.Logf("Expression '%s' was not compiled", input)
+			test.Fail()
+			continue
+		}
+
+		compiledResult, compiledErr := expression.Evaluate(backendParameters)
+
+		planned := *expression
+		planned.compiledStages = nil
+		plannedResult, plannedErr := planned.Evaluate(backendParameters)
+
+		if !reflect.DeepEqual(compiledResult, plannedResult) || !reflect.DeepEqual(compiledErr, plannedErr) {
+			test.Logf("Compiled evaluation of '%s' differs", input)
+			test.Logf("Expected '%v' (%v), got '%v' (%v)", plannedResult, plannedErr, compiledResult, compiledErr)
+			test.Fail()
+		}
+	}
+}
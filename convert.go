@@ -2,6 +2,9 @@ package govaluate
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
 	"strconv"
 )
 
@@ -50,6 +53,15 @@ func convert2Str(value interface{}) string {
 	case int64:
 		s := value.(int64)
 		return strconv.Itoa(int(s))
+	case Decimal:
+		s := value.(Decimal)
+		return s.String()
+	case *big.Int:
+		s := value.(*big.Int)
+		return s.String()
+	case *big.Float:
+		s := value.(*big.Float)
+		return s.Text('g', -1)
 	case []byte:
 		s := value.([]byte)
 		return string(s)
@@ -97,8 +109,36 @@ func convert2Float64(value interface{}) (float64, error) {
 	case int64:
 		s := value.(int64)
 		return float64(s), nil
+	case Decimal:
+		s := value.(Decimal)
+		return s.Float64(), nil
+	case *big.Int:
+		s := value.(*big.Int)
+		if s == nil {
+			return 0, errors.New("Unable to convert a nil *big.Int to a float64")
+		}
+		f, _ := new(big.Float).SetInt(s).Float64()
+		return f, nil
+	case *big.Float:
+		s := value.(*big.Float)
+		if s == nil {
+			return 0, errors.New("Unable to convert a nil *big.Float to a float64")
+		}
+		f, _ := s.Float64()
+		return f, nil
 	default:
 		s := convert2Str(value)
 		return strconv.ParseFloat(s, 64)
 	}
 }
+
+/*
+	Converts a whole number to an int64, returning false if [value] isn't whole, or is outside the range of an int64.
+*/
+func float64ToInt64(value float64) (int64, bool) {
+
+	if value != math.Trunc(value) || value < math.MinInt64 || value >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(value), true
+}
@@ -0,0 +1,254 @@
+package govaluate
+
+import (
+	"math/big"
+)
+
+/*
+	The number of decimal places kept by inexact decimal results, if `ParseOptions.DecimalPlaces` isn't set.
+*/
+const DEFAULT_DECIMAL_PLACES = 16
+
+/*
+	How decimal results which can't be held exactly (such as "1 / 3") are rounded.
+*/
+type decimalContext struct {
+	places   int
+	rounding RoundingMode
+}
+
+/*
+	Exponents larger than this aren't calculated exactly, since the result would have more digits than is reasonable.
+*/
+const maxDecimalPower = 1024
+
+/*
+	Returns the operators that replace the default ones for `NUMBERS_DECIMAL`.
+	Unlike integers, these depend on the places and rounding decimals are divided with, so are made for each expression.
+*/
+func makeDecimalStageSymbolMap(decimals decimalContext) map[OperatorSymbol]evaluationOperator {
+
+	return map[OperatorSymbol]evaluationOperator{
+		PLUS:           makeDecimalStage(addDecimal, addStage),
+		MINUS:          makeDecimalStage(subtractDecimal, subtractStage),
+		MULTIPLY:       makeDecimalStage(multiplyDecimal, multiplyStage),
+		DIVIDE:         makeDecimalStage(decimals.divide, divideStage),
+		MODULUS:        makeDecimalStage(modulusDecimal, modulusStage),
+		EXPONENT:       makeDecimalStage(decimals.exponent, exponentStage),
+		BITWISE_AND:    makeDecimalIntegerStage(bitwiseAndBigInt, bitwiseAndStage),
+		BITWISE_OR:     makeDecimalIntegerStage(bitwiseOrBigInt, bitwiseOrStage),
+		BITWISE_XOR:    makeDecimalIntegerStage(bitwiseXORBigInt, bitwiseXORStage),
+		BITWISE_LSHIFT: makeDecimalIntegerStage(leftShiftBigInt, leftShiftStage),
+		BITWISE_RSHIFT: makeDecimalIntegerStage(rightShiftBigInt, rightShiftStage),
+		NEGATE:         makeDecimalPrefixStage(negateDecimal, negateStage),
+		BITWISE_NOT:    makeDecimalPrefixStage(bitwiseNotDecimal, bitwiseNotStage),
+		GT:             makeDecimalComparator(func(comparison int) bool { return comparison > 0 }, gtStage),
+		LT:             makeDecimalComparator(func(comparison int) bool { return comparison < 0 }, ltStage),
+		GTE:            makeDecimalComparator(func(comparison int) bool { return comparison >= 0 }, gteStage),
+		LTE:            makeDecimalComparator(func(comparison int) bool { return comparison <= 0 }, lteStage),
+		EQ:             makeDecimalComparator(func(comparison int) bool { return comparison == 0 }, equalStage),
+		NEQ:            makeDecimalComparator(func(comparison int) bool { return comparison != 0 }, notEqualStage),
+		IN:             decimalInStage,
+		INDEX:          makeIndexStage(NUMBERS_DECIMAL),
+	}
+}
+
+/*
+	Makes an operator which operates exactly on two numbers as decimals, and gives anything else to [fallback].
+	[exact] returns false if it can't give a decimal result (such as division by zero), in which case [fallback] is used too.
+	Numeric results of [fallback] are converted back to decimals, if they can be.
+*/
+func makeDecimalStage(exact func(left, right Decimal) (Decimal, bool), fallback evaluationOperator) evaluationOperator {
+
+	return func(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+
+		leftDecimal, leftOk := decimalOf(left)
+		rightDecimal, rightOk := decimalOf(right)
+
+		if leftOk && rightOk {
+
+			result, ok := exact(leftDecimal, rightDecimal)
+			if ok {
+				return result, leftStage, rightStage, nil
+			}
+		}
+		return decimalFallback(fallback, left, right, leftStage, rightStage, parameters)
+	}
+}
+
+/*
+	Same as `makeDecimalStage`, for bitwise operators, which only operate on decimals with no fractional part.
+*/
+func makeDecimalIntegerStage(slow func(left, right *big.Int) *big.Int, fallback evaluationOperator) evaluationOperator {
+
+	return makeDecimalStage(func(left, right Decimal) (Decimal, bool) {
+
+		if !left.isInteger() || !right.isInteger() {
+			return Decimal{}, false
+		}
+
+		result := slow(left.trim().integer(), right.trim().integer())
+		if result == nil {
+			return Decimal{}, false
+		}
+		return Decimal{unscaled: result}, true
+	}, fallback)
+}
+
+/*
+	Same as `makeDecimalStage`, for prefixes, which only have a right side.
+*/
+func makeDecimalPrefixStage(exact func(right Decimal) (Decimal, bool), fallback evaluationOperator) evaluationOperator {
+
+	return func(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+
+		rightDecimal, ok := decimalOf(right)
+		if ok {
+
+			result, ok := exact(rightDecimal)
+			if ok {
+				return result, leftStage, rightStage, nil
+			}
+		}
+		return decimalFallback(fallback, left, right, leftStage, rightStage, parameters)
+	}
+}
+
+/*
+	Makes a comparator which compares two numbers as decimals, and gives anything else to [fallback].
+*/
+func makeDecimalComparator(compare func(comparison int) bool, fallback evaluationOperator) evaluationOperator {
+
+	return func(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+
+		leftDecimal, leftOk := decimalOf(left)
+		rightDecimal, rightOk := decimalOf(right)
+
+		if !leftOk || !rightOk {
+			return fallback(left, right, leftStage, rightStage, parameters)
+		}
+		return boolIface(compare(leftDecimal.Cmp(rightDecimal))), leftStage, rightStage, nil
+	}
+}
+
+/*
+	Same as `inStage`, except that numbers are equal to any other number of the same value, whatever their types or decimal places.
+*/
+func decimalInStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+
+	leftDecimal, leftOk := decimalOf(left)
+
+	values, _ := right.([]interface{})
+
+	for _, value := range values {
+
+		if leftOk {
+
+			valueDecimal, ok := decimalOf(value)
+			if ok && leftDecimal.Cmp(valueDecimal) == 0 {
+				return true, leftStage, rightStage, nil
+			}
+			continue
+		}
+
+		if left == value || isNil(left) && isNil(value) {
+			return true, leftStage, rightStage, nil
+		}
+	}
+	return false, leftStage, rightStage, nil
+}
+
+func decimalFallback(fallback evaluationOperator, left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+
+	result, leftStage, rightStage, err := fallback(left, right, leftStage, rightStage, parameters)
+	if err != nil {
+		return result, leftStage, rightStage, err
+	}
+	return castToDecimal(result), leftStage, rightStage, nil
+}
+
+func addDecimal(left, right Decimal) (Decimal, bool) {
+
+	leftInteger, rightInteger, scale := alignDecimals(left, right)
+	return Decimal{unscaled: new(big.Int).Add(leftInteger, rightInteger), scale: scale}, true
+}
+
+func subtractDecimal(left, right Decimal) (Decimal, bool) {
+
+	leftInteger, rightInteger, scale := alignDecimals(left, right)
+	return Decimal{unscaled: new(big.Int).Sub(leftInteger, rightInteger), scale: scale}, true
+}
+
+func multiplyDecimal(left, right Decimal) (Decimal, bool) {
+	return Decimal{unscaled: new(big.Int).Mul(left.integer(), right.integer()), scale: left.scale + right.scale}, true
+}
+
+/*
+	Quotients are kept exactly if they can be, and otherwise rounded to the context's places.
+	Either way, trailing zeros are removed, so "1 / 4" is "0.25", not "0.2500000000000000".
+*/
+func (this decimalContext) divide(left, right Decimal) (Decimal, bool) {
+
+	if right.integer().Sign() == 0 {
+		return Decimal{}, false
+	}
+
+	quotient := new(big.Rat).Quo(left.Rat(), right.Rat())
+	return roundRat(quotient, this.places, this.rounding).trim(), true
+}
+
+/*
+	Same as the float64 modulus, the result has the sign of the left side.
+*/
+func modulusDecimal(left, right Decimal) (Decimal, bool) {
+
+	if right.integer().Sign() == 0 {
+		return Decimal{}, false
+	}
+
+	leftInteger, rightInteger, scale := alignDecimals(left, right)
+	return Decimal{unscaled: new(big.Int).Rem(leftInteger, rightInteger), scale: scale}, true
+}
+
+/*
+	Integer exponents are exact, except that negative ones are rounded in the same way as division.
+	Fractional exponents (and very large ones) are calculated as float64s.
+*/
+func (this decimalContext) exponent(left, right Decimal) (Decimal, bool) {
+
+	if !right.isInteger() {
+		return Decimal{}, false
+	}
+
+	power := right.trim().integer()
+	if !power.IsInt64() || power.Int64() > maxDecimalPower || power.Int64() < -maxDecimalPower {
+		return Decimal{}, false
+	}
+
+	exponent := power.Int64()
+	if exponent < 0 {
+		exponent = -exponent
+	}
+
+	result := Decimal{
+		unscaled: new(big.Int).Exp(left.integer(), big.NewInt(exponent), nil),
+		scale:    left.scale * int(exponent),
+	}
+
+	if power.Sign() < 0 {
+		return this.divide(Decimal{unscaled: big.NewInt(1)}, result)
+	}
+	return result, true
+}
+
+func negateDecimal(right Decimal) (Decimal, bool) {
+	return Decimal{unscaled: new(big.Int).Neg(right.integer()), scale: right.scale}, true
+}
+
+func bitwiseNotDecimal(right Decimal) (Decimal, bool) {
+
+	if !right.isInteger() {
+		return Decimal{}, false
+	}
+	return Decimal{unscaled: new(big.Int).Not(right.trim().integer())}, true
+}
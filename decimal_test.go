@@ -0,0 +1,404 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+)
+
+/*
+	Represents a test of an expression parsed with `NUMBERS_DECIMAL`.
+	Expected is the string of the decimal it should evaluate to, or of any other value it should evaluate to, with its type.
+*/
+type DecimalTest struct {
+	Name       string
+	Input      string
+	Options    ParseOptions
+	Parameters map[string]interface{}
+	Expected   string
+}
+
+func TestDecimalEvaluation(test *testing.T) {
+
+	decimalTests := []DecimalTest{
+		DecimalTest{
+			Name:     "Exact addition",
+			Input:    "0.1 + 0.2",
+			Expected: "0.3",
+		},
+		DecimalTest{
+			Name:     "Exact equality",
+			Input:    "0.1 + 0.2 == 0.3",
+			Expected: "true (bool)",
+		},
+		DecimalTest{
+			Name:     "Places are kept",
+			Input:    "1.50 + 1",
+			Expected: "2.50",
+		},
+		DecimalTest{
+			Name:       "Fee",
+			Input:      "amount * 0.035 + 0.30",
+			Parameters: map[string]interface{}{"amount": 19.99},
+			Expected:   "0.99965",
+		},
+		DecimalTest{
+			Name:       "Rounded fee",
+			Input:      "round(amount * 0.035 + 0.30, 2)",
+			Options:    ParseOptions{Rounding: ROUND_HALF_UP},
+			Parameters: map[string]interface{}{"amount": 19.99},
+			Expected:   "1.00",
+		},
+		DecimalTest{
+			Name:     "Round to a whole number",
+			Input:    "round(2.5) + round(3.5)",
+			Expected: "6",
+		},
+		DecimalTest{
+			Name:       "Round named as a variable",
+			Input:      "round + 1",
+			Parameters: map[string]interface{}{"round": 1},
+			Expected:   "2",
+		},
+		DecimalTest{
+			Name:       "Integer parameters",
+			Input:      "count * 0.25",
+			Parameters: map[string]interface{}{"count": int64(9007199254740993)},
+			Expected:   "2251799813685248.25",
+		},
+		DecimalTest{
+			Name:       "Decimal parameters",
+			Input:      "price * 3",
+			Parameters: map[string]interface{}{"price": mustParseDecimal("33.33")},
+			Expected:   "99.99",
+		},
+		DecimalTest{
+			Name:     "Exact division",
+			Input:    "10 / 4",
+			Expected: "2.5",
+		},
+		DecimalTest{
+			Name:     "Inexact division",
+			Input:    "1 / 3",
+			Expected: "0.3333333333333333",
+		},
+		DecimalTest{
+			Name:     "Division with places",
+			Input:    "2 / 3",
+			Options:  ParseOptions{DecimalPlaces: 4},
+			Expected: "0.6667",
+		},
+		DecimalTest{
+			Name:     "Division with rounding",
+			Input:    "2 / 3",
+			Options:  ParseOptions{DecimalPlaces: 4, Rounding: ROUND_DOWN},
+			Expected: "0.6666",
+		},
+		DecimalTest{
+			Name:     "Negative division with rounding",
+			Input:    "-2 / 3",
+			Options:  ParseOptions{DecimalPlaces: 2, Rounding: ROUND_FLOOR},
+			Expected: "-0.67",
+		},
+		DecimalTest{
+			Name:     "Modulus",
+			Input:    "-7.5 % 2",
+			Expected: "-1.5",
+		},
+		DecimalTest{
+			Name:     "Exponent",
+			Input:    "1.1 ** 2",
+			Expected: "1.21",
+		},
+		DecimalTest{
+			Name:     "Negative exponent",
+			Input:    "2 ** -2",
+			Expected: "0.25",
+		},
+		DecimalTest{
+			Name:     "Fractional exponent",
+			Input:    "4 ** 0.5",
+			Expected: "2",
+		},
+		DecimalTest{
+			Name:     "Bitwise",
+			Input:    "(12.0 | 3) & ~1",
+			Expected: "14",
+		},
+		DecimalTest{
+			Name:     "Negation",
+			Input:    "-(0.5 - 1.25)",
+			Expected: "0.75",
+		},
+		DecimalTest{
+			Name:     "Division by zero",
+			Input:    "1 / 0",
+			Expected: "+Inf (float64)",
+		},
+		DecimalTest{
+			Name:     "Comparison",
+			Input:    "1.50 == 1.5 && 0.1 < 0.10000000000000001 && 2 >= 2.0",
+			Expected: "true (bool)",
+		},
+		DecimalTest{
+			Name:     "Membership",
+			Input:    "0.3 in (0.1, 0.30) && !(2 in (1, 3))",
+			Expected: "true (bool)",
+		},
+		DecimalTest{
+			Name:     "Concatenation",
+			Input:    "'total: ' + 1.50",
+			Expected: "total: 1.50 (string)",
+		},
+		DecimalTest{
+			Name:       "Accessors",
+			Input:      "foo.Int / 8",
+			Parameters: map[string]interface{}{"foo": dummyParameterInstance},
+			Expected:   "12.625",
+		},
+		DecimalTest{
+			Name:       "Method with numeric arguments",
+			Input:      "foo.TestArgs('sum', 2.00, 2, 3, 4, 5, 6, 7, 8, 9, 10, 1.5, 2.25, true)",
+			Parameters: map[string]interface{}{"foo": dummyParameterInstance},
+			Expected:   "sum: 59.75 (string)",
+		},
+		DecimalTest{
+			Name:  "Function given decimals",
+			Input: "half(1.5) + 1",
+			Options: ParseOptions{
+				Functions: map[string]ExpressionFunction{
+					"half": func(arguments ...interface{}) (interface{}, error) {
+						return arguments[0].(Decimal).Float64() / 2, nil
+					},
+				},
+			},
+			Expected: "1.75",
+		},
+	}
+
+	for _, decimalTest := range decimalTests {
+
+		options := decimalTest.Options
+		options.Numbers = NUMBERS_DECIMAL
+
+		expression, err := NewEvaluableExpressionWithOptions(decimalTest.Input, options)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %s", decimalTest.Name, err)
+			test.Fail()
+			continue
+		}
+
+		encoded, err := json.Marshal(expression.Program())
+		if err != nil {
+			test.Logf("Test '%s' failed to encode: %s", decimalTest.Name, err)
+			test.Fail()
+			continue
+		}
+
+		loaded, err := LoadProgram(encoded, ParseOptions{Functions: options.Functions})
+		if err != nil {
+			test.Logf("Test '%s' failed to load: %s", decimalTest.Name, err)
+			test.Fail()
+			continue
+		}
+
+		expressionResult, expressionErr := expression.Evaluate(decimalTest.Parameters)
+		loadedResult, loadedErr := loaded.Evaluate(decimalTest.Parameters)
+
+		for _, err := range []error{expressionErr, loadedErr} {
+
+			if err != nil {
+				test.Logf("Test '%s' failed: %s", decimalTest.Name, err)
+				test.Fail()
+			}
+		}
+
+		for _, result := range []interface{}{expressionResult, loadedResult} {
+
+			actual := describeDecimalResult(result)
+			if actual != decimalTest.Expected {
+				test.Logf("Test '%s' failed", decimalTest.Name)
+				test.Logf("Expected '%s', got '%s'", decimalTest.Expected, actual)
+				test.Fail()
+			}
+		}
+	}
+}
+
+func TestRoundFunction(test *testing.T) {
+
+	roundTests := []struct {
+		Rounding  RoundingMode
+		Arguments []interface{}
+		Expected  string
+	}{
+		{ROUND_HALF_EVEN, []interface{}{mustParseDecimal("2.5")}, "2"},
+		{ROUND_HALF_EVEN, []interface{}{mustParseDecimal("3.5")}, "4"},
+		{ROUND_HALF_EVEN, []interface{}{mustParseDecimal("-2.5")}, "-2"},
+		{ROUND_HALF_UP, []interface{}{mustParseDecimal("2.5")}, "3"},
+		{ROUND_HALF_UP, []interface{}{mustParseDecimal("-2.5")}, "-3"},
+		{ROUND_HALF_DOWN, []interface{}{mustParseDecimal("2.51")}, "3"},
+		{ROUND_HALF_DOWN, []interface{}{mustParseDecimal("-2.5")}, "-2"},
+		{ROUND_UP, []interface{}{mustParseDecimal("2.01")}, "3"},
+		{ROUND_DOWN, []interface{}{mustParseDecimal("-2.99")}, "-2"},
+		{ROUND_CEILING, []interface{}{mustParseDecimal("-2.9")}, "-2"},
+		{ROUND_FLOOR, []interface{}{mustParseDecimal("-2.1")}, "-3"},
+		{ROUND_HALF_UP, []interface{}{mustParseDecimal("1.005"), 2.0}, "1.01"},
+		{ROUND_HALF_UP, []interface{}{mustParseDecimal("1.5"), 2.0}, "1.5"},
+		{ROUND_HALF_EVEN, []interface{}{mustParseDecimal("1250"), -2.0}, "1200"},
+		{ROUND_HALF_UP, []interface{}{2.675, 2.0}, "2.68 (float64)"},
+		{ROUND_HALF_UP, []interface{}{int64(1250), int64(-2)}, "1300 (int64)"},
+		{ROUND_HALF_UP, []interface{}{math.Inf(1), 2.0}, "+Inf (float64)"},
+	}
+
+	for _, roundTest := range roundTests {
+
+		result, err := RoundFunction(roundTest.Rounding)(roundTest.Arguments...)
+		actual := describeDecimalResult(result)
+
+		if err != nil || actual != roundTest.Expected {
+			test.Logf("Expected rounding %v (%v) to give '%s', got '%s' (%v)", roundTest.Arguments, roundTest.Rounding, roundTest.Expected, actual, err)
+			test.Fail()
+		}
+	}
+
+	for _, arguments := range [][]interface{}{{}, {"x"}, {1.0, 1.5}, {1.0, 2.0, 3.0}} {
+
+		_, err := RoundFunction(ROUND_HALF_EVEN)(arguments...)
+		if err == nil {
+			test.Logf("Expected rounding %v to fail", arguments)
+			test.Fail()
+		}
+	}
+}
+
+/*
+	"round" is only built into decimal expressions, and can be replaced.
+*/
+func TestBuiltinRound(test *testing.T) {
+
+	_, err := NewEvaluableExpression("round(2.5)")
+	if err == nil {
+		test.Logf("Expected round not to be built into float expressions")
+		test.Fail()
+	}
+
+	functions := map[string]ExpressionFunction{
+		"round": func(arguments ...interface{}) (interface{}, error) {
+			return "custom", nil
+		},
+	}
+
+	expression, _ := NewEvaluableExpressionWithOptions("round(2.5)", ParseOptions{Numbers: NUMBERS_DECIMAL, Functions: functions})
+	result, err := expression.Evaluate(nil)
+	if result != "custom" || err != nil {
+		test.Logf("Expected the given function to be called, got %v (%v)", result, err)
+		test.Fail()
+	}
+
+	// arrays are a single argument, rather than a list of them.
+	expression, _ = NewEvaluableExpressionWithOptions("round(values)", ParseOptions{Numbers: NUMBERS_DECIMAL})
+	_, err = expression.Evaluate(map[string]interface{}{"values": []interface{}{2.5, 1}})
+	if err == nil {
+		test.Logf("Expected rounding an array to fail")
+		test.Fail()
+	}
+}
+
+func TestParseDecimal(test *testing.T) {
+
+	for input, expected := range map[string]string{
+		"0":        "0",
+		"-0.05":    "-0.05",
+		"+12.340":  "12.340",
+		".5":       "0.5",
+		"1.5e3":    "1500",
+		"15e-4":    "0.0015",
+		"00012":    "12",
+		"1.25E+01": "12.5",
+	} {
+
+		actual, err := ParseDecimal(input)
+		if err != nil || actual.String() != expected {
+			test.Logf("Expected '%s' to parse as '%s', got '%s' (%v)", input, expected, actual, err)
+			test.Fail()
+		}
+	}
+
+	for _, input := range []string{"", "-", "1.2.3", "--1", "1e", "1e99999", "0x10", "1/3", "NaN"} {
+
+		_, err := ParseDecimal(input)
+		if err == nil {
+			test.Logf("Expected '%s' not to parse", input)
+			test.Fail()
+		}
+	}
+
+	var decoded struct {
+		Amount Decimal
+	}
+
+	err := json.Unmarshal([]byte(`{"Amount": 1.10}`), &decoded)
+	if err != nil || decoded.Amount.String() != "1.10" {
+		test.Logf("Expected to unmarshal '1.10', got '%s' (%v)", decoded.Amount, err)
+		test.Fail()
+	}
+
+	encoded, _ := json.Marshal(decoded)
+	if string(encoded) != `{"Amount":1.10}` {
+		test.Logf("Unexpected JSON '%s'", encoded)
+		test.Fail()
+	}
+
+	if (Decimal{}).String() != "0" || (Decimal{}).Cmp(mustParseDecimal("0.00")) != 0 {
+		test.Logf("Expected the zero value to be zero")
+		test.Fail()
+	}
+}
+
+/*
+	Decimal expressions must still evaluate to a float64 with `EvalFloat64`, and check as numbers.
+*/
+func TestDecimalTypes(test *testing.T) {
+
+	expression, _ := NewEvaluableExpressionWithOptions("amount * 0.035 + 0.30", ParseOptions{Numbers: NUMBERS_DECIMAL})
+
+	value, err := expression.EvalFloat64(MapParameters{"amount": 100})
+	if err != nil || value != 3.8 {
+		test.Logf("Expected EvalFloat64 to give 3.8, got %v (%v)", value, err)
+		test.Fail()
+	}
+
+	valueType, err := expression.InferType(TypeSchema{"amount": TYPE_NUMBER})
+	if err != nil || valueType != TYPE_NUMBER {
+		test.Logf("Expected a number, got %v (%v)", valueType, err)
+		test.Fail()
+	}
+
+	if expression.AST().String() != "(amount * 0.035) + 0.30" {
+		test.Logf("Unexpected rendering '%s'", expression.AST().String())
+		test.Fail()
+	}
+}
+
+func mustParseDecimal(value string) Decimal {
+
+	ret, err := ParseDecimal(value)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+/*
+	Returns the string of a decimal, or the string and type of anything else.
+*/
+func describeDecimalResult(value interface{}) string {
+
+	decimal, ok := value.(Decimal)
+	if ok {
+		return decimal.String()
+	}
+	return fmt.Sprintf("%v (%T)", value, value)
+}
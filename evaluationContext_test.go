@@ -0,0 +1,165 @@
+package govaluate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+type contextKey string
+
+/*
+	A parameter whose method takes the evaluation's context.
+*/
+type contextParameter struct{}
+
+func (this contextParameter) Lookup(ctx context.Context, key string) string {
+
+	value, _ := ctx.Value(contextKey(key)).(string)
+	return value
+}
+
+func TestEvalContextCancelled(test *testing.T) {
+
+	calls := 0
+	functions := map[string]ExpressionFunction{
+		"count": func(arguments ...interface{}) (interface{}, error) {
+			calls++
+			return 1.0, nil
+		},
+	}
+
+	expression, _ := NewEvaluableExpressionWithFunctions("count() + count()", functions)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := expression.EvalContext(ctx, nil)
+	if !errors.Is(err, context.Canceled) {
+		test.Logf("Expected cancelled evaluation to return context.Canceled, got '%v'", err)
+		test.Fail()
+	}
+
+	if calls != 0 {
+		test.Logf("Expected cancelled evaluation to call no functions, called %d", calls)
+		test.Fail()
+	}
+
+	result, err := expression.EvalContext(context.Background(), nil)
+	if err != nil || result != 2.0 {
+		test.Logf("Expected uncancelled evaluation to return 2, got '%v' (%v)", result, err)
+		test.Fail()
+	}
+}
+
+func TestEvalContextDeadline(test *testing.T) {
+
+	functions := map[string]ContextExpressionFunction{
+		"slow": func(ctx context.Context, arguments ...interface{}) (interface{}, error) {
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(10 * time.Second):
+				return true, nil
+			}
+		},
+	}
+
+	expression, err := NewEvaluableExpressionWithContextFunctions("slow() && slow()", functions)
+	if err != nil {
+		test.Logf("Failed to parse expression with context functions: %v", err)
+		test.FailNow()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = expression.EvalContext(ctx, nil)
+
+	var callError *FunctionCallError
+	if !errors.As(err, &callError) || callError.Name != "slow" {
+		test.Logf("Expected the timed-out function to return a *FunctionCallError, got '%v'", err)
+		test.Fail()
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		test.Logf("Expected timed-out evaluation to return context.DeadlineExceeded, got '%v'", err)
+		test.Fail()
+	}
+}
+
+func TestEvalContextValues(test *testing.T) {
+
+	functions := map[string]ContextExpressionFunction{
+		"tenant": func(ctx context.Context, arguments ...interface{}) (interface{}, error) {
+			value, _ := ctx.Value(contextKey("tenant")).(string)
+			return value, nil
+		},
+	}
+
+	expression, _ := NewEvaluableExpressionWithContextFunctions("tenant() + '/' + foo.Lookup('user')", functions)
+
+	ctx := context.WithValue(context.Background(), contextKey("tenant"), "acme")
+	ctx = context.WithValue(ctx, contextKey("user"), "bob")
+
+	parameters := map[string]interface{}{
+		"foo": contextParameter{},
+	}
+
+	result, err := expression.EvalContext(ctx, MapParameters(parameters))
+	if err != nil || result != "acme/bob" {
+		test.Logf("Expected functions and methods to see the evaluation's context, got '%v' (%v)", result, err)
+		test.Fail()
+	}
+
+	// plain evaluation gives a background context, rather than nil.
+	result, err = expression.Evaluate(parameters)
+	if err != nil || result != "/" {
+		test.Logf("Expected evaluation without a context to use an empty one, got '%v' (%v)", result, err)
+		test.Fail()
+	}
+}
+
+/*
+	Arguments written as a list are given to functions separately, with or without a context.
+	Arrays, whether parameters or in their own parenthesis, are a single argument.
+*/
+func TestContextFunctionArguments(test *testing.T) {
+
+	functions := map[string]ContextExpressionFunction{
+		"count": func(ctx context.Context, arguments ...interface{}) (interface{}, error) {
+			return float64(len(arguments)), nil
+		},
+	}
+
+	expression, _ := NewEvaluableExpressionWithContextFunctions("count(1, x, x + 1) * 100 + count(arr, 1) * 10 + count((1, 2)) + count(arr)", functions)
+	parameters := map[string]interface{}{"x": 1, "arr": []interface{}{1, 2}}
+
+	result, err := expression.EvalContext(nil, MapParameters(parameters))
+	if err != nil || result != 322.0 {
+		test.Logf("Expected arguments to be spread, got '%v' (%v)", result, err)
+		test.Fail()
+	}
+
+	rendered := expression.AST().String()
+	if rendered != "(((count(1, x, x + 1) * 100) + (count(arr, 1) * 10)) + count((1, 2))) + count(arr)" {
+		test.Logf("Expected the AST to keep which arguments are lists, got '%s'", rendered)
+		test.Fail()
+	}
+
+	encoded, _ := json.Marshal(expression.Program())
+	program, err := LoadProgram(encoded, ParseOptions{ContextFunctions: functions})
+	if err != nil {
+		test.Logf("Failed to load program: %v", err)
+		test.FailNow()
+	}
+
+	result, err = program.EvalContext(nil, MapParameters(parameters))
+	if err != nil || result != 322.0 {
+		test.Logf("Expected a loaded program to spread arguments, got '%v' (%v)", result, err)
+		test.Fail()
+	}
+}
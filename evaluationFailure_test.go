@@ -36,6 +36,11 @@ const (
 	TOO_FEW_ARGS                    = "Too few arguments to parameter call"
 	TOO_MANY_ARGS                   = "Too many arguments to parameter call"
 	MISMATCHED_PARAMETERS           = "Argument type conversion failed"
+	FRACTIONAL_BITWISE              = "it is not a whole number"
+	OUT_OF_RANGE_BITWISE            = "it is outside the range of an int64"
+	NEGATIVE_SHIFT                  = "it is negative"
+	SHIFT_TOO_FAR                   = "it is 64 or more"
+	SHIFT_OUT_OF_RANGE              = "the result is outside the range of an int64"
 )
 
 // preset parameter map of types that can be used in an evaluation failure test to check typing.
@@ -190,6 +195,117 @@ func TestModifierTyping(test *testing.T) {
 	runEvaluationFailureTests(evaluationTests, test)
 }
 
+func TestBitwiseOperands(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"half":  0.5,
+		"large": 1e19,
+		"count": -1,
+	}
+
+	evaluationTests := []EvaluationFailureTest{
+		EvaluationFailureTest{
+
+			Name:     "BITWISE_OR fraction",
+			Input:    "1.5 | 1",
+			Expected: FRACTIONAL_BITWISE,
+		},
+		EvaluationFailureTest{
+
+			Name:       "BITWISE_AND fraction",
+			Input:      "3 & half",
+			Parameters: parameters,
+			Expected:   FRACTIONAL_BITWISE,
+		},
+		EvaluationFailureTest{
+
+			Name:       "BITWISE_XOR out of range",
+			Input:      "large ^ 1",
+			Parameters: parameters,
+			Expected:   OUT_OF_RANGE_BITWISE,
+		},
+		EvaluationFailureTest{
+
+			Name:       "BITWISE_NOT out of range",
+			Input:      "~(large)",
+			Parameters: parameters,
+			Expected:   OUT_OF_RANGE_BITWISE,
+		},
+		EvaluationFailureTest{
+
+			Name:       "BITWISE_NOT NaN",
+			Input:      "~(half * (1 / 0 - 1 / 0))",
+			Parameters: parameters,
+			Expected:   FRACTIONAL_BITWISE,
+		},
+		EvaluationFailureTest{
+
+			Name:       "BITWISE_LSHIFT negative",
+			Input:      "1 << count",
+			Parameters: parameters,
+			Expected:   NEGATIVE_SHIFT,
+		},
+		EvaluationFailureTest{
+
+			Name:       "BITWISE_RSHIFT fraction",
+			Input:      "8 >> half",
+			Parameters: parameters,
+			Expected:   FRACTIONAL_BITWISE,
+		},
+		EvaluationFailureTest{
+
+			Name:     "BITWISE_LSHIFT by 64",
+			Input:    "1 << 64",
+			Expected: SHIFT_TOO_FAR,
+		},
+		EvaluationFailureTest{
+
+			Name:     "BITWISE_RSHIFT by 64",
+			Input:    "-1 >> 64",
+			Expected: SHIFT_TOO_FAR,
+		},
+		EvaluationFailureTest{
+
+			Name:     "BITWISE_LSHIFT into the sign bit",
+			Input:    "1 << 63",
+			Expected: SHIFT_OUT_OF_RANGE,
+		},
+		EvaluationFailureTest{
+
+			Name:     "BITWISE_LSHIFT beyond int64",
+			Input:    "3 << 62",
+			Expected: SHIFT_OUT_OF_RANGE,
+		},
+		EvaluationFailureTest{
+
+			Name:     "BITWISE_LSHIFT negative beyond int64",
+			Input:    "-2 << 63",
+			Expected: SHIFT_OUT_OF_RANGE,
+		},
+	}
+
+	runEvaluationFailureTests(evaluationTests, test)
+
+	// typed evaluation must fail in the same way, and errors must say where the operator is.
+	var mismatch *TypeMismatchError
+
+	expression, _ := NewEvaluableExpression("1 << count")
+	_, err := expression.EvalFloat64(MapParameters(parameters))
+
+	if !errors.As(err, &mismatch) || mismatch.Operator != BITWISE_LSHIFT || mismatch.Start != 2 || mismatch.End != 4 {
+		test.Logf("Expected a located *TypeMismatchError, got %v", err)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpression("count << 63")
+	_, err = expression.EvalFloat64(MapParameters{"count": 1})
+
+	if !errors.As(err, &mismatch) || mismatch.Operator != BITWISE_LSHIFT {
+		test.Logf("Expected a *TypeMismatchError for a shift beyond int64, got %v", err)
+		test.Fail()
+	}
+}
+
 func TestLogicalOperatorTyping(test *testing.T) {
 
 	evaluationTests := []EvaluationFailureTest{
@@ -0,0 +1,157 @@
+package govaluate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+/*
+	Represents a test of an expression going over one of its parsing or evaluation limits.
+*/
+type BudgetTest struct {
+	Name       string
+	Input      string
+	Options    ParseOptions
+	Limits     EvaluationLimits
+	Parameters map[string]interface{}
+	Expected   BudgetKind
+}
+
+func TestBudgets(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"id": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0], nil
+		},
+	}
+
+	budgetTests := []BudgetTest{
+
+		BudgetTest{
+
+			Name:     "Expression length",
+			Input:    "'" + strings.Repeat("a", 100) + "'",
+			Options:  ParseOptions{MaxLength: 64},
+			Expected: BUDGET_LENGTH,
+		},
+		BudgetTest{
+
+			Name:     "Token count",
+			Input:    "1 + 1 + 1 + 1",
+			Options:  ParseOptions{MaxTokens: 5},
+			Expected: BUDGET_TOKENS,
+		},
+		BudgetTest{
+
+			Name:     "Nesting depth",
+			Input:    "((((1))))",
+			Options:  ParseOptions{MaxDepth: 3},
+			Expected: BUDGET_DEPTH,
+		},
+		BudgetTest{
+
+			Name:     "Nesting depth of function arguments",
+			Input:    "id((id(1)))",
+			Options:  ParseOptions{Functions: functions, MaxDepth: 2},
+			Expected: BUDGET_DEPTH,
+		},
+		BudgetTest{
+
+			Name:       "Stages",
+			Input:      "a + a + a + a",
+			Limits:     EvaluationLimits{MaxStages: 5},
+			Parameters: map[string]interface{}{"a": 1},
+			Expected:   BUDGET_STAGES,
+		},
+		BudgetTest{
+
+			Name:       "String length",
+			Input:      "a + a + a + a",
+			Limits:     EvaluationLimits{MaxStringLength: 10},
+			Parameters: map[string]interface{}{"a": "abc"},
+			Expected:   BUDGET_STRING_LENGTH,
+		},
+		BudgetTest{
+
+			Name:       "Array length",
+			Input:      "a in (1, 2, 3, 4, b)",
+			Limits:     EvaluationLimits{MaxArrayLength: 4},
+			Parameters: map[string]interface{}{"a": 1, "b": 2},
+			Expected:   BUDGET_ARRAY_LENGTH,
+		},
+		BudgetTest{
+
+			Name:       "Function calls",
+			Input:      "id(a) + id(a) + id(a)",
+			Options:    ParseOptions{Functions: functions},
+			Limits:     EvaluationLimits{MaxFunctionCalls: 2},
+			Parameters: map[string]interface{}{"a": 1},
+			Expected:   BUDGET_FUNCTION_CALLS,
+		},
+		BudgetTest{
+
+			Name:       "Method calls",
+			Input:      "foo.Func() + foo.Func()",
+			Limits:     EvaluationLimits{MaxFunctionCalls: 1},
+			Parameters: map[string]interface{}{"foo": dummyParameter{}},
+			Expected:   BUDGET_FUNCTION_CALLS,
+		},
+	}
+
+	fmt.Printf("Running %d budget test cases...\n", len(budgetTests))
+
+	for _, budgetTest := range budgetTests {
+
+		var budgetError *BudgetExceededError
+
+		expression, err := NewEvaluableExpressionWithOptions(budgetTest.Input, budgetTest.Options)
+		if err == nil {
+
+			expression.Limits = budgetTest.Limits
+			_, err = expression.Evaluate(budgetTest.Parameters)
+		}
+
+		if !errors.As(err, &budgetError) {
+			test.Logf("Test '%s' failed", budgetTest.Name)
+			test.Logf("Expected a *BudgetExceededError, got '%v'", err)
+			test.Fail()
+			continue
+		}
+
+		if budgetError.Budget != budgetTest.Expected {
+			test.Logf("Test '%s' failed", budgetTest.Name)
+			test.Logf("Expected to exceed %s, exceeded %s", budgetTest.Expected, budgetError.Budget)
+			test.Fail()
+		}
+	}
+}
+
+func TestWithinBudgets(test *testing.T) {
+
+	options := ParseOptions{
+		MaxLength: 64,
+		MaxTokens: 20,
+		MaxDepth:  2,
+	}
+
+	expression, err := NewEvaluableExpressionWithOptions("(a + 'b') in ('ab', (c))", options)
+	if err != nil {
+		test.Logf("Expected expression within parse limits to parse, got '%v'", err)
+		test.FailNow()
+	}
+
+	expression.Limits = EvaluationLimits{
+		MaxStages:        20,
+		MaxStringLength:  2,
+		MaxArrayLength:   2,
+		MaxFunctionCalls: 1,
+	}
+
+	result, err := expression.Evaluate(map[string]interface{}{"a": "a", "c": "cd"})
+	if err != nil || result != true {
+		test.Logf("Expected evaluation within limits to return true, got '%v' (%v)", result, err)
+		test.Fail()
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 )
 
 const (
@@ -15,6 +17,12 @@ const (
 	comparatorErrorFormat string = "Value '%v' cannot be used with the comparator '%v', it is not a number"
 	ternaryErrorFormat    string = "Value '%v' cannot be used with the ternary operator '%v', it is not a bool"
 	prefixErrorFormat     string = "Value '%v' cannot be used with the prefix '%v'"
+	fractionalErrorFormat string = "Value '%v' cannot be used with the bitwise operator '%v', it is not a whole number"
+	rangeErrorFormat      string = "Value '%v' cannot be used with the bitwise operator '%v', it is outside the range of an int64"
+	shiftErrorFormat      string = "Value '%v' cannot be used to shift with the operator '%v', it is negative"
+	shiftCountErrorFormat string = "Value '%v' cannot be used to shift with the operator '%v', it is 64 or more"
+	shiftRangeErrorFormat string = "Value '%v' cannot be shifted with the operator '%v', the result is outside the range of an int64"
+	indexErrorFormat      string = "Value '%v' cannot be used with the index operator '%v'"
 )
 
 type evaluationOperator func(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error)
@@ -40,6 +48,18 @@ type evaluationStage struct {
 
 	// regardless of which type check is used, this string format will be used as the error message for type errors
 	typeErrorFormat string
+
+	// the parameter or function name, or the accessor path, that this stage was planned from.
+	// Never used during evaluation, only kept so that the planned tree can be described (see `ExpressionNode.go`).
+	name string
+	path []string
+
+	// for function calls, whether the argument is a list of arguments to be given separately (see `isArgumentList`).
+	// Decided when planning, since the stages of the argument may be reordered afterwards.
+	spreads bool
+
+	// the character offsets of the token this stage was planned from, used to locate errors.
+	start, end int
 }
 
 var (
@@ -62,6 +82,10 @@ func (this *evaluationStage) setToNonStage(other evaluationStage) {
 	this.rightTypeCheck = other.rightTypeCheck
 	this.typeCheck = other.typeCheck
 	this.typeErrorFormat = other.typeErrorFormat
+	this.name = other.name
+	this.path = other.path
+	this.start = other.start
+	this.end = other.end
 }
 
 func (this *evaluationStage) isShortCircuitable() bool {
@@ -82,6 +106,13 @@ func (this *evaluationStage) isShortCircuitable() bool {
 	return false
 }
 
+/*
+	Returns true if this stage calls a function, or a method of a parameter.
+*/
+func (this *evaluationStage) isCall() bool {
+	return this.symbol == FUNCTIONAL || (this.symbol == ACCESS && this.rightStage != nil)
+}
+
 func noopStageRight(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
 	return right, leftStage, rightStage, nil
 }
@@ -93,6 +124,13 @@ func addStage(left, right, leftStage, rightStage interface{}, parameters Paramet
 		return fmt.Sprintf("%v%v", left, right), leftStage, rightStage, nil
 	}
 
+	if isBigOperand(left, right) {
+		result, ok := bigArithmetic(left, right, addBigInt, addBigFloat)
+		if ok {
+			return result, leftStage, rightStage, nil
+		}
+	}
+
 	leftFloat64, err := convert2Float64(left)
 	if err != nil {
 		return nil, leftStage, rightStage, err
@@ -107,6 +145,13 @@ func addStage(left, right, leftStage, rightStage interface{}, parameters Paramet
 }
 func subtractStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
 
+	if isBigOperand(left, right) {
+		result, ok := bigArithmetic(left, right, subtractBigInt, subtractBigFloat)
+		if ok {
+			return result, leftStage, rightStage, nil
+		}
+	}
+
 	leftFloat64, err := convert2Float64(left)
 	if err != nil {
 		return nil, leftStage, rightStage, err
@@ -121,6 +166,13 @@ func subtractStage(left, right, leftStage, rightStage interface{}, parameters Pa
 }
 func multiplyStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
 
+	if isBigOperand(left, right) {
+		result, ok := bigArithmetic(left, right, multiplyBigInt, multiplyBigFloat)
+		if ok {
+			return result, leftStage, rightStage, nil
+		}
+	}
+
 	leftFloat64, err := convert2Float64(left)
 	if err != nil {
 		return nil, leftStage, rightStage, err
@@ -135,6 +187,13 @@ func multiplyStage(left, right, leftStage, rightStage interface{}, parameters Pa
 }
 func divideStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
 
+	if isBigOperand(left, right) {
+		result, ok := bigArithmetic(left, right, divideBigInt, divideBigFloat)
+		if ok {
+			return result, leftStage, rightStage, nil
+		}
+	}
+
 	leftFloat64, err := convert2Float64(left)
 	if err != nil {
 		return nil, leftStage, rightStage, err
@@ -149,6 +208,13 @@ func divideStage(left, right, leftStage, rightStage interface{}, parameters Para
 }
 func exponentStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
 
+	if isBigOperand(left, right) {
+		result, ok := bigArithmetic(left, right, exponentBigIntUnbounded, nil)
+		if ok {
+			return result, leftStage, rightStage, nil
+		}
+	}
+
 	leftFloat64, err := convert2Float64(left)
 	if err != nil {
 		return nil, leftStage, rightStage, err
@@ -163,6 +229,13 @@ func exponentStage(left, right, leftStage, rightStage interface{}, parameters Pa
 }
 func modulusStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
 
+	if isBigOperand(left, right) {
+		result, ok := bigArithmetic(left, right, modulusBigInt, modulusBigFloat)
+		if ok {
+			return result, leftStage, rightStage, nil
+		}
+	}
+
 	leftFloat64, err := convert2Float64(left)
 	if err != nil {
 		return nil, leftStage, rightStage, err
@@ -176,10 +249,20 @@ func modulusStage(left, right, leftStage, rightStage interface{}, parameters Par
 	return math.Mod(leftFloat64, rightFloat64), leftStage, rightStage, nil
 }
 func gteStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+	if isNil(left) || isNil(right) {
+		return _false, leftStage, rightStage, nil
+	}
 	if isString(left) && isString(right) {
 		return boolIface(left.(string) >= right.(string)), leftStage, rightStage, nil
 	}
 
+	if isBigOperand(left, right) {
+		comparison, ok := compareBig(left, right)
+		if ok {
+			return boolIface(comparison >= 0), leftStage, rightStage, nil
+		}
+	}
+
 	leftFloat64, err := convert2Float64(left)
 	if err != nil {
 		return nil, leftStage, rightStage, err
@@ -192,10 +275,20 @@ func gteStage(left, right, leftStage, rightStage interface{}, parameters Paramet
 	return boolIface(leftFloat64 >= rightFloat64), leftStage, rightStage, nil
 }
 func gtStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+	if isNil(left) || isNil(right) {
+		return _false, leftStage, rightStage, nil
+	}
 	if isString(left) && isString(right) {
 		return boolIface(left.(string) > right.(string)), leftStage, rightStage, nil
 	}
 
+	if isBigOperand(left, right) {
+		comparison, ok := compareBig(left, right)
+		if ok {
+			return boolIface(comparison > 0), leftStage, rightStage, nil
+		}
+	}
+
 	leftFloat64, err := convert2Float64(left)
 	if err != nil {
 		return nil, leftStage, rightStage, err
@@ -208,9 +301,19 @@ func gtStage(left, right, leftStage, rightStage interface{}, parameters Paramete
 	return boolIface(leftFloat64 > rightFloat64), leftStage, rightStage, nil
 }
 func lteStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+	if isNil(left) || isNil(right) {
+		return _false, leftStage, rightStage, nil
+	}
 	if isString(left) && isString(right) {
 		return boolIface(left.(string) <= right.(string)), leftStage, rightStage, nil
 	}
+
+	if isBigOperand(left, right) {
+		comparison, ok := compareBig(left, right)
+		if ok {
+			return boolIface(comparison <= 0), leftStage, rightStage, nil
+		}
+	}
 	leftFloat64, err := convert2Float64(left)
 	if err != nil {
 		return nil, leftStage, rightStage, err
@@ -223,9 +326,19 @@ func lteStage(left, right, leftStage, rightStage interface{}, parameters Paramet
 	return boolIface(leftFloat64 <= rightFloat64), leftStage, rightStage, nil
 }
 func ltStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+	if isNil(left) || isNil(right) {
+		return _false, leftStage, rightStage, nil
+	}
 	if isString(left) && isString(right) {
 		return boolIface(left.(string) < right.(string)), leftStage, rightStage, nil
 	}
+
+	if isBigOperand(left, right) {
+		comparison, ok := compareBig(left, right)
+		if ok {
+			return boolIface(comparison < 0), leftStage, rightStage, nil
+		}
+	}
 	leftFloat64, err := convert2Float64(left)
 	if err != nil {
 		return nil, leftStage, rightStage, err
@@ -238,34 +351,41 @@ func ltStage(left, right, leftStage, rightStage interface{}, parameters Paramete
 	return boolIface(leftFloat64 < rightFloat64), leftStage, rightStage, nil
 }
 func equalStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
-	if isString(left) && isString(right) {
-		return boolIface(reflect.DeepEqual(left.(string), right.(string))), leftStage, rightStage, nil
-	}
-	leftFloat64, err := convert2Float64(left)
-	if err != nil {
-		return nil, leftStage, rightStage, err
-	}
-
-	rightFloat64, err := convert2Float64(right)
-	if err != nil {
-		return nil, leftStage, rightStage, err
-	}
-	return boolIface(reflect.DeepEqual(leftFloat64, rightFloat64)), leftStage, rightStage, nil
+	return boolIface(equalValues(left, right)), leftStage, rightStage, nil
 }
 func notEqualStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+	return boolIface(!equalValues(left, right)), leftStage, rightStage, nil
+}
+
+/*
+	Returns true if [left] and [right] are equal. Null is only equal to null, strings are equal to the same string,
+	and numbers (or strings of them) to any number of the same value. Anything else is equal if it's deeply equal,
+	so that booleans, times, and arrays can be compared, and values of different types are simply unequal.
+*/
+func equalValues(left, right interface{}) bool {
+
+	leftNil := isNil(left)
+	rightNil := isNil(right)
+
+	if leftNil || rightNil {
+		return leftNil && rightNil
+	}
+
 	if isString(left) && isString(right) {
-		return boolIface(!reflect.DeepEqual(left.(string), right.(string))), leftStage, rightStage, nil
+		return left.(string) == right.(string)
 	}
-	leftFloat64, err := convert2Float64(left)
-	if err != nil {
-		return nil, leftStage, rightStage, err
+
+	if isBigOperand(left, right) {
+		return equalBig(left, right)
 	}
 
-	rightFloat64, err := convert2Float64(right)
-	if err != nil {
-		return nil, leftStage, rightStage, err
+	leftFloat64, leftErr := convert2Float64(left)
+	rightFloat64, rightErr := convert2Float64(right)
+
+	if leftErr == nil && rightErr == nil {
+		return leftFloat64 == rightFloat64
 	}
-	return boolIface(!reflect.DeepEqual(leftFloat64, rightFloat64)), leftStage, rightStage, nil
+	return reflect.DeepEqual(left, right)
 }
 func andStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
 	return boolIface(left.(bool) && right.(bool)), leftStage, rightStage, nil
@@ -274,6 +394,17 @@ func orStage(left, right, leftStage, rightStage interface{}, parameters Paramete
 	return boolIface(left.(bool) || right.(bool)), leftStage, rightStage, nil
 }
 func negateStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+	switch typed := right.(type) {
+	case *big.Int:
+		if typed != nil {
+			return new(big.Int).Neg(typed), leftStage, rightStage, nil
+		}
+	case *big.Float:
+		if typed != nil {
+			return new(big.Float).Neg(typed), leftStage, rightStage, nil
+		}
+	}
+
 	rightFloat64, err := convert2Float64(right)
 	if err != nil {
 		return nil, leftStage, rightStage, err
@@ -284,11 +415,18 @@ func invertStage(left, right, leftStage, rightStage interface{}, parameters Para
 	return boolIface(!right.(bool)), leftStage, rightStage, nil
 }
 func bitwiseNotStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
-	rightFloat64, err := convert2Float64(right)
+	if isBigOperand(nil, right) {
+		integer, ok := bigIntOf(right)
+		if ok {
+			return integer.Not(integer), leftStage, rightStage, nil
+		}
+	}
+
+	rightInt64, err := bitwiseOperand(right, BITWISE_NOT)
 	if err != nil {
 		return nil, leftStage, rightStage, err
 	}
-	return float64(^int64(rightFloat64)), leftStage, rightStage, nil
+	return float64(^rightInt64), leftStage, rightStage, nil
 }
 func ternaryIfStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
 	if left.(bool) {
@@ -318,6 +456,11 @@ func regexStage(left, right, leftStage, rightStage interface{}, parameters Param
 		pattern = right.(*regexp.Regexp)
 	}
 
+	// null doesn't match any pattern.
+	if isNil(left) {
+		return false, leftStage, rightStage, nil
+	}
+
 	return pattern.Match([]byte(left.(string))), leftStage, rightStage, nil
 }
 
@@ -332,64 +475,148 @@ func notRegexStage(left, right, leftStage, rightStage interface{}, parameters Pa
 }
 
 func bitwiseOrStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
-	leftFloat64, err := convert2Float64(left)
+	if isBigOperand(left, right) {
+		result, ok := bigArithmetic(left, right, bitwiseOrBigInt, nil)
+		if ok {
+			return result, leftStage, rightStage, nil
+		}
+	}
+
+	leftInt64, err := bitwiseOperand(left, BITWISE_OR)
 	if err != nil {
 		return nil, leftStage, rightStage, err
 	}
 
-	rightFloat64, err := convert2Float64(right)
+	rightInt64, err := bitwiseOperand(right, BITWISE_OR)
 	if err != nil {
 		return nil, leftStage, rightStage, err
 	}
-	return float64(int64(leftFloat64) | int64(rightFloat64)), right, leftStage, nil
+	return float64(leftInt64 | rightInt64), right, leftStage, nil
 }
 func bitwiseAndStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
-	leftFloat64, err := convert2Float64(left)
+	if isBigOperand(left, right) {
+		result, ok := bigArithmetic(left, right, bitwiseAndBigInt, nil)
+		if ok {
+			return result, leftStage, rightStage, nil
+		}
+	}
+
+	leftInt64, err := bitwiseOperand(left, BITWISE_AND)
 	if err != nil {
 		return nil, leftStage, rightStage, err
 	}
 
-	rightFloat64, err := convert2Float64(right)
+	rightInt64, err := bitwiseOperand(right, BITWISE_AND)
 	if err != nil {
 		return nil, leftStage, rightStage, err
 	}
-	return float64(int64(leftFloat64) & int64(rightFloat64)), right, leftStage, nil
+	return float64(leftInt64 & rightInt64), right, leftStage, nil
 }
 func bitwiseXORStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
-	leftFloat64, err := convert2Float64(left)
+	if isBigOperand(left, right) {
+		result, ok := bigArithmetic(left, right, bitwiseXORBigInt, nil)
+		if ok {
+			return result, leftStage, rightStage, nil
+		}
+	}
+
+	leftInt64, err := bitwiseOperand(left, BITWISE_XOR)
 	if err != nil {
 		return nil, leftStage, rightStage, err
 	}
 
-	rightFloat64, err := convert2Float64(right)
+	rightInt64, err := bitwiseOperand(right, BITWISE_XOR)
 	if err != nil {
 		return nil, leftStage, rightStage, err
 	}
-	return float64(int64(leftFloat64) ^ int64(rightFloat64)), right, leftStage, nil
+	return float64(leftInt64 ^ rightInt64), right, leftStage, nil
 }
 func leftShiftStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
-	leftFloat64, err := convert2Float64(left)
+	if isBigOperand(left, right) {
+		result, ok := bigArithmetic(left, right, leftShiftBigIntUnbounded, nil)
+		if ok {
+			return result, leftStage, rightStage, nil
+		}
+	}
+
+	leftInt64, err := bitwiseOperand(left, BITWISE_LSHIFT)
 	if err != nil {
 		return nil, leftStage, rightStage, err
 	}
 
-	rightFloat64, err := convert2Float64(right)
+	count, err := shiftOperand(right, BITWISE_LSHIFT)
 	if err != nil {
 		return nil, leftStage, rightStage, err
 	}
-	return float64(uint64(leftFloat64) << uint64(rightFloat64)), right, leftStage, nil
+
+	// shifting back only gives the left side again if no bits (including the sign) were lost.
+	ret := leftInt64 << count
+	if ret>>count != leftInt64 {
+		return nil, leftStage, rightStage, &TypeMismatchError{Operator: BITWISE_LSHIFT, Value: left, format: shiftRangeErrorFormat}
+	}
+	return float64(ret), right, leftStage, nil
 }
 func rightShiftStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
-	leftFloat64, err := convert2Float64(left)
+	if isBigOperand(left, right) {
+		result, ok := bigArithmetic(left, right, rightShiftBigIntUnbounded, nil)
+		if ok {
+			return result, leftStage, rightStage, nil
+		}
+	}
+
+	leftInt64, err := bitwiseOperand(left, BITWISE_RSHIFT)
 	if err != nil {
 		return nil, leftStage, rightStage, err
 	}
 
-	rightFloat64, err := convert2Float64(right)
+	count, err := shiftOperand(right, BITWISE_RSHIFT)
 	if err != nil {
 		return nil, leftStage, rightStage, err
 	}
-	return float64(uint64(leftFloat64) >> uint64(rightFloat64)), right, leftStage, nil
+	return float64(leftInt64 >> count), right, leftStage, nil
+}
+
+/*
+	Converts an operand of a bitwise operator to the int64 it operates on. Every bitwise operator treats numbers as signed
+	(two's complement) int64s, so "~0" is -1, and ">>" keeps the sign of its left side.
+	Returns a *TypeMismatchError if [value] isn't a whole number, or can't be held by an int64, rather than letting the conversion truncate or wrap.
+*/
+func bitwiseOperand(value interface{}, symbol OperatorSymbol) (int64, error) {
+
+	number, err := convert2Float64(value)
+	if err != nil {
+		return 0, err
+	}
+
+	// NaN isn't equal to anything, so it isn't whole either.
+	if number != math.Trunc(number) {
+		return 0, &TypeMismatchError{Operator: symbol, Value: value, format: fractionalErrorFormat}
+	}
+
+	ret, ok := float64ToInt64(number)
+	if !ok {
+		return 0, &TypeMismatchError{Operator: symbol, Value: value, format: rangeErrorFormat}
+	}
+	return ret, nil
+}
+
+/*
+	Same as `bitwiseOperand`, for the number of bits a shift moves its left side by, which can't be negative, or 64 or more.
+*/
+func shiftOperand(value interface{}, symbol OperatorSymbol) (uint64, error) {
+
+	ret, err := bitwiseOperand(value, symbol)
+	if err != nil {
+		return 0, err
+	}
+
+	if ret < 0 {
+		return 0, &TypeMismatchError{Operator: symbol, Value: value, format: shiftErrorFormat}
+	}
+	if ret >= 64 {
+		return 0, &TypeMismatchError{Operator: symbol, Value: value, format: shiftCountErrorFormat}
+	}
+	return uint64(ret), nil
 }
 
 func makeParameterStage(parameterName string) evaluationOperator {
@@ -404,34 +631,108 @@ func makeParameterStage(parameterName string) evaluationOperator {
 	}
 }
 
+/*
+	The value of FUNCTION tokens for "exists" and "has", which are planned as an EXISTS stage rather than a function call.
+*/
+type presenceTest struct{}
+
+/*
+	Makes the operator for "exists(foo)" or "has(foo.Bar)", which is true if the parameter at the start of [path] was given,
+	and every field or map key after it is present. Whatever the evaluation's `MissingMode`, defaults don't count as given.
+*/
+func makePresenceStage(path []string) evaluationOperator {
+
+	var access evaluationOperator
+
+	// optional links would make missing paths nil, rather than missing.
+	path, _ = splitAccessorPath(path)
+
+	if len(path) > 1 {
+		access = makeAccessorStage(path, false, NUMBERS_FLOAT)
+	}
+
+	return func(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+
+		var err error
+
+		state, ok := parameters.(*evaluationState)
+		if ok {
+			parameters = state.Parameters
+		}
+
+		if access != nil {
+			_, _, _, err = access(nil, nil, nil, nil, parameters)
+		} else {
+			_, err = parameters.Get(path[0])
+		}
+		return boolIface(err == nil), leftStage, rightStage, nil
+	}
+}
+
 func makeLiteralStage(literal interface{}) evaluationOperator {
 	return func(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
 		return literal, leftStage, rightStage, nil
 	}
 }
 
-func makeFunctionStage(function ExpressionFunction) evaluationOperator {
+/*
+	Makes the operator for a call to [function].
+	If [spread] is true, its argument is a list of arguments (see `isArgumentList`) to be given to the function separately.
+*/
+func makeFunctionStage(name string, function ExpressionFunction, spread bool) evaluationOperator {
 
 	return func(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
 
-		if right == nil {
-			res, err := function()
-			return res, leftStage, rightStage, err
+		res, err := function(functionArguments(right, spread)...)
+		if err != nil {
+			return res, leftStage, rightStage, &FunctionCallError{Name: name, Err: err}
 		}
+		return res, leftStage, rightStage, nil
+	}
+}
 
-		switch right.(type) {
-		case []interface{}:
-			if rightStage == nil {
-				res, err := function(right)
-				return res, leftStage, rightStage, err
-			}
-			res, err := function(right.([]interface{})...)
-			return res, leftStage, rightStage, err
-		default:
-			res, err := function(right)
-			return res, leftStage, rightStage, err
+func makeContextFunctionStage(name string, function ContextExpressionFunction, spread bool) evaluationOperator {
+
+	return func(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+
+		res, err := function(contextOf(parameters), functionArguments(right, spread)...)
+		if err != nil {
+			return res, leftStage, rightStage, &FunctionCallError{Name: name, Err: err}
+		}
+		return res, leftStage, rightStage, nil
+	}
+}
+
+/*
+	Functions are given their arguments as a single [right] value.
+	If that value is a list of arguments ([spread]), each element is its own argument.
+	Anything else, including arrays from parameters or in their own parenthesis, is a single argument.
+*/
+func functionArguments(right interface{}, spread bool) []interface{} {
+
+	if right == nil {
+		return nil
+	}
+
+	if spread {
+		arguments, ok := right.([]interface{})
+		if ok {
+			return arguments
 		}
 	}
+	return []interface{}{right}
+}
+
+/*
+	Returns true if the argument [stage] of a function was written as a list of arguments, as in "foo(1, 2)",
+	rather than a single argument which might be an array, as in "foo(x)" or "foo((1, 2))".
+*/
+func isArgumentList(stage *evaluationStage) bool {
+
+	return stage != nil &&
+		stage.symbol == NOOP &&
+		stage.rightStage != nil &&
+		stage.rightStage.symbol == SEPARATE
 }
 
 func typeConvertParam(p reflect.Value, t reflect.Type) (ret reflect.Value, err error) {
@@ -443,9 +744,36 @@ func typeConvertParam(p reflect.Value, t reflect.Type) (ret reflect.Value, err e
 		}
 	}()
 
+	if decimal, ok := p.Interface().(Decimal); ok {
+		p = decimalParam(decimal, t)
+	}
 	return p.Convert(t), nil
 }
 
+/*
+	Decimals can't be converted to other numeric types by reflection, so this converts [decimal] to a number which can be converted to [t].
+	Whole decimals are given as an int64 (if they fit) to integer types, and everything else as the nearest float64,
+	which is converted the same way a float64 argument would be.
+	Non-numeric types (such as interface{}, or Decimal itself) are given the decimal as it is.
+*/
+func decimalParam(decimal Decimal, t reflect.Type) reflect.Value {
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+
+		rat := decimal.Rat()
+		if rat.IsInt() && rat.Num().IsInt64() {
+			return reflect.ValueOf(rat.Num().Int64())
+		}
+		return reflect.ValueOf(decimal.Float64())
+
+	case reflect.Float32, reflect.Float64:
+		return reflect.ValueOf(decimal.Float64())
+	}
+	return reflect.ValueOf(decimal)
+}
+
 func typeConvertParams(method reflect.Value, params []reflect.Value) ([]reflect.Value, error) {
 
 	methodType := method.Type()
@@ -476,17 +804,80 @@ func typeConvertParams(method reflect.Value, params []reflect.Value) ([]reflect.
 	return params, nil
 }
 
-func makeAccessorStage(pair []string, isFunction bool) evaluationOperator {
+/*
+	Splits an accessor [path] into the names it accesses, and whether each was accessed optionally (with "?.").
+	Optional links are kept in paths by starting their names with '?', so "user?.profile" is []string{"user", "?profile"}.
+*/
+func splitAccessorPath(path []string) ([]string, []bool) {
+
+	names := make([]string, len(path))
+	optional := make([]bool, len(path))
+
+	for i, name := range path {
+		optional[i] = strings.HasPrefix(name, "?")
+		names[i] = strings.TrimPrefix(name, "?")
+	}
+	return names, optional
+}
+
+/*
+	Returns the accessor path written as it would be in an expression, such as "user?.profile.age".
+*/
+func joinAccessorPath(path []string) string {
+
+	ret := strings.Join(path, ".")
+	return strings.Replace(ret, ".?", "?.", -1)
+}
+
+/*
+	Makes the operator for an accessor of [path], such as "foo.Nested.Bar".
+	If [isFunction], the last element of [path] is a method, called with the stage's right value as its arguments;
+	every other element is a field or map key.
+*/
+func makeAccessorStage(path []string, isFunction bool, numbers NumericMode) evaluationOperator {
 
+	pair, optional := splitAccessorPath(path)
 	reconstructed := strings.Join(pair, ".")
 
+	accessorError := func(index int, message string, cause error) error {
+		return &AccessorError{
+			Path:    pair,
+			Field:   pair[index],
+			Message: message,
+			Err:     cause,
+		}
+	}
+
+	// a missing parameter, field or key makes the whole path missing, so that defaults are looked up by the whole path.
+	missing := func(parameters Parameters, err error) (interface{}, interface{}, interface{}, error) {
+
+		state, ok := parameters.(*evaluationState)
+		if !ok {
+			return nil, nil, nil, err
+		}
+
+		value, err := state.missingValue(reconstructed, err)
+		return value, nil, nil, err
+	}
+
 	return func(left, right, leftStage, rightStage interface{}, parameters Parameters) (ret, leftStageRet, rightStageRet interface{}, err error) {
 
 		var params []reflect.Value
+		var i int
+		var value interface{}
+
+		state, ok := parameters.(*evaluationState)
+		if ok {
+			value, err = state.Parameters.Get(pair[0])
+		} else {
+			value, err = parameters.Get(pair[0])
+		}
 
-		value, err := parameters.Get(pair[0])
 		if err != nil {
-			return nil, leftStage, rightStage, err
+			if len(pair) > 1 && optional[1] {
+				return nil, leftStage, rightStage, nil
+			}
+			return missing(parameters, err)
 		}
 
 		// while this library generally tries to handle panic-inducing cases on its own,
@@ -494,15 +885,25 @@ func makeAccessorStage(pair []string, isFunction bool) evaluationOperator {
 		// therefore every call to an accessor sets up a defer that tries to recover from panics, converting them to errors.
 		defer func() {
 			if r := recover(); r != nil {
-				errorMsg := fmt.Sprintf("Failed to access '%s': %v", reconstructed, r.(string))
-				err = errors.New(errorMsg)
+
+				cause, isError := r.(error)
+				if !isError {
+					cause = fmt.Errorf("%v", r)
+				}
+
+				err = accessorError(i, fmt.Sprintf("Failed to access '%s'", reconstructed), cause)
 				leftStageRet = leftStage
 				rightStageRet = rightStage
 				ret = nil
 			}
 		}()
 
-		for i := 1; i < len(pair); i++ {
+		for i = 1; i < len(pair); i++ {
+
+			// optional links stop at the first nil, rather than failing to access it.
+			if optional[i] && isNil(value) {
+				return nil, leftStage, rightStage, nil
+			}
 
 			coreValue := reflect.ValueOf(value)
 
@@ -515,13 +916,16 @@ func makeAccessorStage(pair []string, isFunction bool) evaluationOperator {
 			}
 
 			if coreValue.Kind() == reflect.Struct {
-				if !isFunction {
+				if !isFunction || i < len(pair)-1 {
 					field := coreValue.FieldByName(pair[i])
 					if field != (reflect.Value{}) {
 						value = field.Interface()
 						continue
 					}
-					return nil, leftStage, rightStage, errors.New("No field '" + pair[i] + "' present on parameter '" + pair[i-1] + "'")
+					if optional[i] {
+						return nil, leftStage, rightStage, nil
+					}
+					return missing(parameters, accessorError(i, "No field '"+pair[i]+"' present on parameter '"+pair[i-1]+"'", nil))
 				} else {
 					method := coreValue.MethodByName(pair[i])
 					if method == (reflect.Value{}) {
@@ -529,7 +933,7 @@ func makeAccessorStage(pair []string, isFunction bool) evaluationOperator {
 							method = corePtrVal.MethodByName(pair[i])
 						}
 						if method == (reflect.Value{}) {
-							return nil, leftStage, rightStage, errors.New("No method or field '" + pair[i] + "' present on parameter '" + pair[i-1] + "'")
+							return nil, leftStage, rightStage, accessorError(i, "No method or field '"+pair[i]+"' present on parameter '"+pair[i-1]+"'", nil)
 						}
 					}
 
@@ -552,17 +956,22 @@ func makeAccessorStage(pair []string, isFunction bool) evaluationOperator {
 						params = []reflect.Value{reflect.ValueOf(right.(interface{}))}
 					}
 
+					// methods which take a context first are given the evaluation's context.
+					if method.Type().NumIn() > 0 && method.Type().In(0) == contextType {
+						params = append([]reflect.Value{reflect.ValueOf(contextOf(parameters))}, params...)
+					}
+
 					params, err = typeConvertParams(method, params)
 
 					if err != nil {
-						return nil, leftStage, rightStage, errors.New("Method call failed - '" + pair[0] + "." + pair[1] + "': " + err.Error())
+						return nil, leftStage, rightStage, accessorError(i, "Method call failed - '"+pair[0]+"."+pair[1]+"'", err)
 					}
 
 					returned := method.Call(params)
 					retLength := len(returned)
 
 					if retLength == 0 {
-						return nil, leftStage, rightStage, errors.New("Method call '" + pair[i-1] + "." + pair[i] + "' did not return any values.")
+						return nil, leftStage, rightStage, accessorError(i, "Method call '"+pair[i-1]+"."+pair[i]+"' did not return any values.", nil)
 					}
 
 					if retLength == 1 {
@@ -577,40 +986,198 @@ func makeAccessorStage(pair []string, isFunction bool) evaluationOperator {
 						err, validType := errIface.(error)
 
 						if validType && errIface != nil {
-							return returned[0].Interface(), leftStage, rightStage, err
+							return returned[0].Interface(), leftStage, rightStage, accessorError(i, "Method call '"+pair[i-1]+"."+pair[i]+"' failed", err)
 						}
 
 						value = returned[0].Interface()
 						continue
 					}
-					return nil, leftStage, rightStage, errors.New("No method '" + pair[i] + "' present on parameter '" + pair[i-1] + "'")
+					return nil, leftStage, rightStage, accessorError(i, "No method '"+pair[i]+"' present on parameter '"+pair[i-1]+"'", nil)
 				}
 			} else if coreValue.Kind() == reflect.Map {
-				if !isFunction {
+				if !isFunction || i < len(pair)-1 {
 					var key = reflect.ValueOf(pair[i])
 					valueValue := coreValue.MapIndex(key)
 					if !valueValue.IsValid() {
-						return nil, leftStage, rightStage, errors.New("No field '" + pair[i] + "' present on parameter '" + pair[i-1] + "'")
+						if optional[i] {
+							return nil, leftStage, rightStage, nil
+						}
+						return missing(parameters, accessorError(i, "No field '"+pair[i]+"' present on parameter '"+pair[i-1]+"'", nil))
 					}
 					value = valueValue.Interface()
 					continue
 				} else {
-					return nil, leftStage, rightStage, errors.New("No method '" + pair[i] + "' present on parameter '" + pair[i-1] + "'")
+					return nil, leftStage, rightStage, accessorError(i, "No method '"+pair[i]+"' present on parameter '"+pair[i-1]+"'", nil)
 				}
 
 			}
 
 			// return nil, leftStage, rightStage, errors.New("Method call '" + pair[0] + "." + pair[1] + "' did not return either one value, or a value and an error. Cannot interpret meaning.")
 
-			return nil, leftStage, rightStage, errors.New("Unable to access '" + pair[i] + "', '" + pair[i-1] + "' is not a struct or map")
+			return nil, leftStage, rightStage, accessorError(i, "Unable to access '"+pair[i]+"', '"+pair[i-1]+"' is not a struct or map", nil)
 
 		}
 
-		value = castToFloat64(value)
+		value = numbers.sanitize(value)
 		return value, leftStage, rightStage, nil
 	}
 }
 
+/*
+	Makes the operator for "value[key]", which gives the element of an array (or slice) or the character of a string at a whole number index,
+	the value of a map with the given key, or the field of a struct with the given name. Values it gives are converted to [numbers].
+*/
+func makeIndexStage(numbers NumericMode) evaluationOperator {
+
+	return func(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+
+		value, err := indexValue(left, right)
+		if err != nil {
+			return nil, leftStage, rightStage, err
+		}
+		return numbers.sanitize(value), leftStage, rightStage, nil
+	}
+}
+
+func indexValue(value interface{}, key interface{}) (interface{}, error) {
+
+	indexError := func(format string, arguments ...interface{}) error {
+		return &IndexError{
+			Value:   value,
+			Key:     key,
+			Message: fmt.Sprintf(format, arguments...),
+		}
+	}
+
+	if !isIndexable(value) {
+		return nil, indexError("Unable to index '%v', it is not an array, map, string or struct", value)
+	}
+
+	// strings are indexed by character, rather than by byte.
+	text, isText := value.(string)
+	if isText {
+
+		characters := []rune(text)
+
+		index, ok := wholeNumberOf(key)
+		if !ok {
+			return nil, indexError("Index '%v' cannot be used with a string, it is not a whole number", key)
+		}
+		if index < 0 || index >= int64(len(characters)) {
+			return nil, indexError("Index %v is out of range for a string of length %d", key, len(characters))
+		}
+		return string(characters[index]), nil
+	}
+
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() == reflect.Ptr {
+		reflected = reflected.Elem()
+	}
+
+	switch reflected.Kind() {
+
+	case reflect.Slice, reflect.Array:
+
+		index, ok := wholeNumberOf(key)
+		if !ok {
+			return nil, indexError("Index '%v' cannot be used with an array, it is not a whole number", key)
+		}
+		if index < 0 || index >= int64(reflected.Len()) {
+			return nil, indexError("Index %v is out of range for an array of length %d", key, reflected.Len())
+		}
+		return reflected.Index(int(index)).Interface(), nil
+
+	case reflect.Map:
+
+		mapKey, ok := mapKeyOf(key, reflected.Type().Key())
+		if !ok {
+			return nil, indexError("Key '%v' cannot be used with a map of %v keys", key, reflected.Type().Key())
+		}
+
+		element := reflected.MapIndex(mapKey)
+		if !element.IsValid() {
+			return nil, indexError("No key '%v' present in map", key)
+		}
+		return element.Interface(), nil
+	}
+
+	name, ok := key.(string)
+	if !ok {
+		return nil, indexError("Key '%v' cannot be used with a struct, it is not a field name", key)
+	}
+
+	field := reflected.FieldByName(name)
+	if !field.IsValid() || !field.CanInterface() {
+		return nil, indexError("No field '%v' present on struct", key)
+	}
+	return field.Interface(), nil
+}
+
+/*
+	Returns [key] as a value which can be used as a key of maps whose keys are of [keyType].
+	Numbers are converted to other types of number, if they can be held without losing anything.
+*/
+func mapKeyOf(key interface{}, keyType reflect.Type) (reflect.Value, bool) {
+
+	if key == nil {
+		return reflect.Value{}, false
+	}
+
+	reflected := reflect.ValueOf(key)
+	if reflected.Type().AssignableTo(keyType) {
+		return reflected, true
+	}
+
+	switch keyType.Kind() {
+
+	case reflect.String:
+		if isString(key) {
+			return reflected.Convert(keyType), true
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		whole, ok := wholeNumberOf(key)
+		if ok && !reflect.Zero(keyType).OverflowInt(whole) {
+			return reflect.ValueOf(whole).Convert(keyType), true
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		whole, ok := wholeNumberOf(key)
+		if ok && whole >= 0 && !reflect.Zero(keyType).OverflowUint(uint64(whole)) {
+			return reflect.ValueOf(uint64(whole)).Convert(keyType), true
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if !isString(key) && isNumber(key) {
+			number, _ := convert2Float64(key)
+			return reflect.ValueOf(number).Convert(keyType), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+/*
+	Returns [value] as an int64, if it's a number with no fractional part which an int64 can hold.
+*/
+func wholeNumberOf(value interface{}) (int64, bool) {
+
+	var whole *big.Int
+	var ok bool
+
+	switch typed := value.(type) {
+	case Decimal:
+		rational := typed.Rat()
+		whole, ok = rational.Num(), rational.IsInt()
+	default:
+		whole, ok = bigIntOf(value)
+	}
+
+	if !ok || !whole.IsInt64() {
+		return 0, false
+	}
+	return whole.Int64(), true
+}
+
 func separatorStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
 
 	var ret []interface{}
@@ -637,16 +1204,43 @@ func separatorStage(left, right, leftStage, rightStage interface{}, parameters P
 	return ret, ret, rightStage, nil
 }
 
+/*
+	Nothing is in null, and null is only in arrays which contain null.
+*/
 func inStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
 
-	for _, value := range right.([]interface{}) {
-		if left == value {
+	values, _ := right.([]interface{})
+
+	for _, value := range values {
+		if left == value || isNil(left) && isNil(value) {
+			return true, leftStage, rightStage, nil
+		}
+
+		// big numbers are pointers, which are only equal to the same number.
+		if isBigOperand(left, value) && equalBig(left, value) {
 			return true, leftStage, rightStage, nil
 		}
 	}
 	return false, leftStage, rightStage, nil
 }
 
+/*
+	Returns true if [value] is null, either because it's nil or because it's a nil pointer, map, slice, or similar.
+*/
+func isNil(value interface{}) bool {
+
+	if value == nil {
+		return true
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return reflected.IsNil()
+	}
+	return false
+}
+
 func isString(value interface{}) bool {
 
 	switch value.(type) {
@@ -656,6 +1250,48 @@ func isString(value interface{}) bool {
 	return false
 }
 
+func isStringOrNil(value interface{}) bool {
+	return isString(value) || isNil(value)
+}
+
+func isArrayOrNil(value interface{}) bool {
+	return isArray(value) || isNil(value)
+}
+
+/*
+	Returns true if [value] is an array (or slice), map, string or struct, or a pointer to one, which can be indexed.
+*/
+func isIndexable(value interface{}) bool {
+
+	switch value.(type) {
+	case string:
+		return true
+
+	// numbers and times which happen to be structs (or pointers to them) aren't indexed as such.
+	case Decimal, *big.Int, *big.Float, time.Time:
+		return false
+	}
+
+	if isNil(value) {
+		return false
+	}
+
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() == reflect.Ptr {
+		reflected = reflected.Elem()
+	}
+
+	switch reflected.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		return true
+	}
+	return false
+}
+
+func isIndexKey(value interface{}) bool {
+	return isString(value) || isNumber(value)
+}
+
 func isRegexOrString(value interface{}) bool {
 
 	switch value.(type) {
@@ -710,10 +1346,14 @@ func additionTypeCheck(left interface{}, right interface{}) bool {
 
 /*
 	Comparison can either be between numbers, or lexicographic between two strings,
-	but never between the two.
+	but never between the two. Either side may be null, in which case the comparison is false.
 */
 func comparatorTypeCheck(left interface{}, right interface{}) bool {
 
+	if isNil(left) || isNil(right) {
+		return true
+	}
+
 	if isNumber(left) && isNumber(right) {
 		return true
 	}
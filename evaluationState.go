@@ -0,0 +1,93 @@
+package govaluate
+
+import (
+	"context"
+	"reflect"
+)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+/*
+	Everything a single evaluation needs, other than the expression itself.
+	This is passed to every stage operator as its Parameters, so that operators which need more than parameters
+	(such as context-aware functions) can get at it.
+*/
+type evaluationState struct {
+	Parameters
+
+	ctx context.Context
+
+	// the context's Done channel, kept to avoid an interface call per stage. Nil if the context can never be cancelled.
+	done <-chan struct{}
+
+	// nil if the evaluation has no limits.
+	limits        *EvaluationLimits
+	stages        int
+	functionCalls int
+
+	// whether operands should have their types checked, see `EvaluableExpression.ChecksTypes`.
+	checksTypes bool
+
+	// what arithmetic operators do with non-finite results, see `EvaluableExpression.NonFinite`.
+	nonFinite NonFiniteMode
+
+	// what missing parameters are, see `EvaluableExpression.Missing`. Defaults are nil unless the mode is MISSING_DEFAULT.
+	missing  MissingMode
+	defaults Parameters
+
+	// the trace of the stage being evaluated, nil unless evaluating with `EvalWithTrace`.
+	trace *EvaluationTrace
+}
+
+/*
+	Creates the state of an evaluation of [parameters], stopped if [ctx] is cancelled.
+	A nil [ctx] is the same as context.Background().
+*/
+func newEvaluationState(ctx context.Context, parameters Parameters, limits EvaluationLimits, checksTypes bool) *evaluationState {
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ret := &evaluationState{
+		Parameters:  parameters,
+		ctx:         ctx,
+		done:        ctx.Done(),
+		checksTypes: checksTypes,
+	}
+
+	if limits != (EvaluationLimits{}) {
+		ret.limits = &limits
+	}
+	return ret
+}
+
+/*
+	Returns an error if the evaluation's context has been cancelled or has passed its deadline.
+*/
+func (this *evaluationState) checkCancelled() error {
+
+	if this.done == nil {
+		return nil
+	}
+
+	select {
+	case <-this.done:
+		return this.ctx.Err()
+	default:
+		return nil
+	}
+}
+
+/*
+	Returns the context of the evaluation which is using [parameters],
+	or context.Background() if [parameters] didn't come from an evaluation (such as when literals are elided during planning).
+*/
+func contextOf(parameters Parameters) context.Context {
+
+	state, ok := parameters.(*evaluationState)
+	if ok {
+		return state.ctx
+	}
+	return context.Background()
+}
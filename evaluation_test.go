@@ -76,6 +76,30 @@ func TestNoParameterEvaluation(test *testing.T) {
 			Input:    "~10",
 			Expected: -11.0,
 		},
+		EvaluationTest{
+
+			Name:     "Signed shift left",
+			Input:    "-3 << 2",
+			Expected: -12.0,
+		},
+		EvaluationTest{
+
+			Name:     "Signed shift right",
+			Input:    "-8 >> 1",
+			Expected: -4.0,
+		},
+		EvaluationTest{
+
+			Name:     "Shift left to the edge of int64",
+			Input:    "(1 << 62) + (-1 << 63)",
+			Expected: -4611686018427387904.0,
+		},
+		EvaluationTest{
+
+			Name:     "Shift right by 63",
+			Input:    "(-8 >> 63) + (8 >> 63)",
+			Expected: -1.0,
+		},
 		EvaluationTest{
 
 			Name:     "Single MULTIPLY",
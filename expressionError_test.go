@@ -0,0 +1,197 @@
+package govaluate
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTokenPositions(test *testing.T) {
+
+	expression, err := NewEvaluableExpression("foo.Bar >= 0x1F &&\n\t[escaped var] == 'it\\'s'")
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	expected := [][2]int{
+		{0, 7},
+		{8, 10},
+		{11, 15},
+		{16, 18},
+		{20, 33},
+		{34, 36},
+		{37, 44},
+	}
+
+	tokens := expression.Tokens()
+	if len(tokens) != len(expected) {
+		test.Fatalf("Expected %d tokens, found %d", len(expected), len(tokens))
+	}
+
+	for i, token := range tokens {
+
+		if token.Start != expected[i][0] || token.End != expected[i][1] {
+			test.Logf("Token %d (%v) spans [%d, %d), expected [%d, %d)", i, token.Value, token.Start, token.End, expected[i][0], expected[i][1])
+			test.Fail()
+		}
+	}
+}
+
+func TestParsingErrorLocations(test *testing.T) {
+
+	parsingTests := []ParsingFailureTest{
+
+		ParsingFailureTest{
+
+			Name:     "Invalid transition",
+			Input:    "1 x",
+			Expected: "(line 1, column 3)\n1 x\n  ^",
+		},
+		ParsingFailureTest{
+
+			Name:     "Invalid token on a later line",
+			Input:    "a &&\n\tb =!= c",
+			Expected: "(line 2, column 4)\n\tb =!= c\n\t  ^^^",
+		},
+		ParsingFailureTest{
+
+			Name:     "Unclosed parenthesis",
+			Input:    "(a || (b && c)",
+			Expected: "(line 1, column 1)\n(a || (b && c)\n^",
+		},
+		ParsingFailureTest{
+
+			Name:     "Unexpected end",
+			Input:    "a +",
+			Expected: "(line 1, column 4)\na +\n   ^",
+		},
+		ParsingFailureTest{
+
+			Name:     "Undefined function",
+			Input:    "1 + frobnicate(2)",
+			Expected: "(line 1, column 5)\n1 + frobnicate(2)\n    ^^^^^^^^^^",
+		},
+		ParsingFailureTest{
+
+			Name:     "Unclosed string after multi-byte characters",
+			Input:    "'héllo' + 'wörld",
+			Expected: "(line 1, column 11)\n'héllo' + 'wörld\n          ^^^^^^",
+		},
+	}
+
+	runParsingFailureTests(parsingTests, test)
+}
+
+func TestParsingErrorWithoutSource(test *testing.T) {
+
+	// tokens built by hand have no positions, so errors shouldn't claim any.
+	_, err := NewEvaluableExpressionFromTokens([]ExpressionToken{
+		ExpressionToken{
+			Kind:  NUMERIC,
+			Value: 1.0,
+		},
+		ExpressionToken{
+			Kind:  NUMERIC,
+			Value: 2.0,
+		},
+	})
+
+	if err == nil {
+		test.Fatal("Expected a parsing error, found no error.")
+	}
+
+	if strings.Contains(err.Error(), "line") {
+		test.Logf("Expected no location in error, got '%s'", err)
+		test.Fail()
+	}
+}
+
+func TestTypedParsingErrors(test *testing.T) {
+
+	var parseError *ParseError
+	var undefinedFunction *UndefinedFunctionError
+	var numError *strconv.NumError
+
+	_, err := NewEvaluableExpression("1 + frobnicate(2)")
+
+	if !errors.As(err, &parseError) || parseError.Line != 1 || parseError.Column != 5 || parseError.Start != 4 || parseError.End != 14 {
+		test.Logf("Expected a ParseError at line 1, column 5, got: %#v", err)
+		test.Fail()
+	}
+
+	if !errors.As(err, &undefinedFunction) || undefinedFunction.Name != "frobnicate" {
+		test.Logf("Expected an UndefinedFunctionError for 'frobnicate', got: %v", err)
+		test.Fail()
+	}
+
+	_, err = NewEvaluableExpression("1.2.3 > 0")
+
+	if !errors.As(err, &numError) {
+		test.Logf("Expected a ParseError wrapping a strconv.NumError, got: %v", err)
+		test.Fail()
+	}
+}
+
+func TestTypedEvaluationErrors(test *testing.T) {
+
+	var typeMismatch *TypeMismatchError
+	var missingParameter *MissingParameterError
+	var accessorError *AccessorError
+	var functionError *FunctionCallError
+
+	failure := errors.New("connection refused")
+	functions := map[string]ExpressionFunction{
+		"lookup": func(arguments ...interface{}) (interface{}, error) {
+			return nil, failure
+		},
+	}
+
+	err := evaluateForError(test, "1 + (name > 5)", nil, map[string]interface{}{"name": "bob"})
+	if !errors.As(err, &typeMismatch) || typeMismatch.Operator != GT || typeMismatch.Value != "bob" || typeMismatch.Start != 10 {
+		test.Logf("Expected a TypeMismatchError on '>' for 'bob', got: %#v", err)
+		test.Fail()
+	}
+
+	err = evaluateForError(test, "a &&\n  discount > 0", nil, map[string]interface{}{"a": true})
+	if !errors.As(err, &missingParameter) || missingParameter.Name != "discount" || missingParameter.Start != 7 || missingParameter.End != 15 {
+		test.Logf("Expected a located MissingParameterError for 'discount', got: %#v", err)
+		test.Fail()
+	}
+
+	err = evaluateForError(test, "foo.Nested.Missing", nil, fooFailureParameters)
+	if !errors.As(err, &accessorError) || accessorError.Field != "Missing" || len(accessorError.Path) != 3 {
+		test.Logf("Expected an AccessorError on field 'Missing', got: %#v", err)
+		test.Fail()
+	}
+
+	err = evaluateForError(test, "foo.AlwaysFail()", nil, fooFailureParameters)
+	if !errors.As(err, &accessorError) || accessorError.Err == nil || accessorError.Err.Error() != "function should always fail" {
+		test.Logf("Expected an AccessorError wrapping the method's error, got: %#v", err)
+		test.Fail()
+	}
+
+	err = evaluateForError(test, "lookup('x') == 1", functions, nil)
+	if !errors.As(err, &functionError) || functionError.Name != "lookup" || functionError.Start != 0 {
+		test.Logf("Expected a FunctionCallError for 'lookup', got: %#v", err)
+		test.Fail()
+	}
+
+	if !errors.Is(err, failure) {
+		test.Logf("Expected FunctionCallError to wrap the function's error, got: %v", err)
+		test.Fail()
+	}
+}
+
+func evaluateForError(test *testing.T, input string, functions map[string]ExpressionFunction, parameters map[string]interface{}) error {
+
+	expression, err := NewEvaluableExpressionWithFunctions(input, functions)
+	if err != nil {
+		test.Logf("Failed to parse '%s': %v", input, err)
+		test.Fail()
+		return nil
+	}
+
+	_, err = expression.Evaluate(parameters)
+	return err
+}
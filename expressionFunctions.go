@@ -1,8 +1,34 @@
 package govaluate
 
+import (
+	"context"
+)
+
 /*
 	Represents a function that can be called from within an expression.
 	This method must return an error if, for any reason, it is unable to produce exactly one unambiguous result.
 	An error returned will halt execution of the expression.
+	Numbers are given as float64s, unless the expression uses another `NumericMode`: with NUMBERS_INTEGER, whole numbers are int64s
+	(or uint64s), and with NUMBERS_DECIMAL, every number is a `Decimal`, which can be converted with `Decimal.Float64()`.
 */
 type ExpressionFunction func(arguments ...interface{}) (interface{}, error)
+
+/*
+	Same as ExpressionFunction, except that it is given the context of the evaluation which called it.
+	This is the context given to `EvalContext`, or context.Background() for `Eval` and `Evaluate`.
+	Functions which do I/O, or otherwise may take a while, should use it to stop when the evaluation is cancelled.
+*/
+type ContextExpressionFunction func(ctx context.Context, arguments ...interface{}) (interface{}, error)
+
+/*
+	Returns the function named [name] which is built into expressions using [numbers], if there is one.
+	Decimal expressions have "round", which rounds with [rounding] (see `RoundFunction`).
+	Functions given to an expression take precedence over these.
+*/
+func findBuiltinFunction(name string, numbers NumericMode, rounding RoundingMode) (ExpressionFunction, bool) {
+
+	if numbers == NUMBERS_DECIMAL && name == "round" {
+		return RoundFunction(rounding), true
+	}
+	return nil, false
+}
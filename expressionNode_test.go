@@ -0,0 +1,292 @@
+package govaluate
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+/*
+	Represents a test of the AST produced for a given expression
+*/
+type ExpressionNodeTest struct {
+	Name      string
+	Input     string
+	Functions map[string]ExpressionFunction
+	Expected  ExpressionNode
+}
+
+func TestExpressionNodes(test *testing.T) {
+
+	expressionNodeTests := []ExpressionNodeTest{
+
+		ExpressionNodeTest{
+
+			Name:     "Folded literals",
+			Input:    "1 + 2",
+			Expected: &LiteralNode{Value: 3.0},
+		},
+		ExpressionNodeTest{
+
+			Name:  "Precedence",
+			Input: "a + b * 2",
+			Expected: &BinaryNode{
+				Operator: PLUS,
+				Left:     &VariableNode{Name: "a"},
+				Right: &BinaryNode{
+					Operator: MULTIPLY,
+					Left:     &VariableNode{Name: "b"},
+					Right:    &LiteralNode{Value: 2.0},
+				},
+			},
+		},
+		ExpressionNodeTest{
+
+			Name:  "Left-associative same precedence",
+			Input: "a - b - c",
+			Expected: &BinaryNode{
+				Operator: MINUS,
+				Left: &BinaryNode{
+					Operator: MINUS,
+					Left:     &VariableNode{Name: "a"},
+					Right:    &VariableNode{Name: "b"},
+				},
+				Right: &VariableNode{Name: "c"},
+			},
+		},
+		ExpressionNodeTest{
+
+			Name:  "Prefix",
+			Input: "!(a && b)",
+			Expected: &UnaryNode{
+				Operator: INVERT,
+				Operand: &BinaryNode{
+					Operator: AND,
+					Left:     &VariableNode{Name: "a"},
+					Right:    &VariableNode{Name: "b"},
+				},
+			},
+		},
+		ExpressionNodeTest{
+
+			Name:  "Ternary",
+			Input: "a ? 'yes' : 'no'",
+			Expected: &TernaryNode{
+				Condition: &VariableNode{Name: "a"},
+				Then:      &LiteralNode{Value: "yes"},
+				Else:      &LiteralNode{Value: "no"},
+			},
+		},
+		ExpressionNodeTest{
+
+			Name:  "Ternary without else",
+			Input: "a ? 1",
+			Expected: &TernaryNode{
+				Condition: &VariableNode{Name: "a"},
+				Then:      &LiteralNode{Value: 1.0},
+			},
+		},
+		ExpressionNodeTest{
+
+			Name:  "Membership",
+			Input: "country in ('CN', 'US', x)",
+			Expected: &BinaryNode{
+				Operator: IN,
+				Left:     &VariableNode{Name: "country"},
+				Right: &ArrayNode{
+					Elements: []ExpressionNode{
+						&LiteralNode{Value: "CN"},
+						&LiteralNode{Value: "US"},
+						&VariableNode{Name: "x"},
+					},
+				},
+			},
+		},
+		ExpressionNodeTest{
+
+			Name:  "Function",
+			Input: "max(a, 2) > 1",
+			Functions: map[string]ExpressionFunction{
+				"max": noop,
+			},
+			Expected: &BinaryNode{
+				Operator: GT,
+				Left: &FunctionNode{
+					Name: "max",
+					Arguments: []ExpressionNode{
+						&VariableNode{Name: "a"},
+						&LiteralNode{Value: 2.0},
+					},
+				},
+				Right: &LiteralNode{Value: 1.0},
+			},
+		},
+		ExpressionNodeTest{
+
+			Name:  "Function without arguments",
+			Input: "now()",
+			Functions: map[string]ExpressionFunction{
+				"now": noop,
+			},
+			Expected: &FunctionNode{
+				Name:      "now",
+				Arguments: []ExpressionNode{},
+			},
+		},
+		ExpressionNodeTest{
+
+			Name:  "Accessors",
+			Input: "foo.Nested.Funk == foo.FuncArgStr('x')",
+			Expected: &BinaryNode{
+				Operator: EQ,
+				Left: &AccessorNode{
+					Path: []string{"foo", "Nested", "Funk"},
+				},
+				Right: &AccessorNode{
+					Path:         []string{"foo", "FuncArgStr"},
+					IsMethodCall: true,
+					Arguments: []ExpressionNode{
+						&LiteralNode{Value: "x"},
+					},
+				},
+			},
+		},
+	}
+
+	fmt.Printf("Running %d AST test cases...\n", len(expressionNodeTests))
+
+	for _, nodeTest := range expressionNodeTests {
+
+		var expression *EvaluableExpression
+		var err error
+
+		if nodeTest.Functions != nil {
+			expression, err = NewEvaluableExpressionWithFunctions(nodeTest.Input, nodeTest.Functions)
+		} else {
+			expression, err = NewEvaluableExpression(nodeTest.Input)
+		}
+
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %s", nodeTest.Name, err)
+			test.Fail()
+			continue
+		}
+
+		actual := expression.AST()
+		if !reflect.DeepEqual(actual, nodeTest.Expected) {
+			test.Logf("Test '%s' failed", nodeTest.Name)
+			test.Logf("Expected AST '%s', got '%s'", nodeTest.Expected, actual)
+			test.Fail()
+		}
+	}
+}
+
+func TestExpressionNodeString(test *testing.T) {
+
+	inputs := []string{
+		"a + b * 2 > 10 && !c",
+		"(a - b) - (c - d)",
+		"[escaped name] ?? 'it\\'s'",
+		"a ? b : c ? d : e",
+		"foo.Bar(1, (2, 3)) =~ '^x.*$'",
+		"-x ** 2",
+		"name == 'tab\\tnew\\nline\\x00\\xff' + `C:\\dir` + \"it's\"",
+	}
+
+	for _, input := range inputs {
+
+		expression, err := NewEvaluableExpression(input)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %s", input, err)
+			test.Fail()
+			continue
+		}
+
+		rendered := expression.AST().String()
+
+		reparsed, err := NewEvaluableExpression(rendered)
+		if err != nil {
+			test.Logf("Rendered form '%s' of '%s' failed to parse: %s", rendered, input, err)
+			test.Fail()
+			continue
+		}
+
+		if !reflect.DeepEqual(reparsed.AST(), expression.AST()) {
+			test.Logf("Rendered form '%s' of '%s' does not parse to the same AST", rendered, input)
+			test.Fail()
+		}
+	}
+}
+
+/*
+	Literals which can't be written in an expression are written as close to one as they can be.
+*/
+func TestLiteralNodeString(test *testing.T) {
+
+	date := time.Date(2014, 1, 2, 15, 4, 5, 0, time.UTC)
+	rendered := (&LiteralNode{Value: date}).String()
+
+	if rendered != "'2014-01-02T15:04:05Z'" {
+		test.Logf("Unexpected rendering '%s' of a time", rendered)
+		test.Fail()
+	}
+
+	expression, err := NewEvaluableExpression(rendered)
+	if err != nil {
+		test.Logf("Rendered time '%s' failed to parse: %s", rendered, err)
+		test.Fail()
+	} else if !reflect.DeepEqual(expression.AST(), &LiteralNode{Value: float64(date.Unix())}) {
+		test.Logf("Rendered time '%s' parsed to %v, not its date literal", rendered, expression.AST())
+		test.Fail()
+	}
+
+	// these have no literal form.
+	nonFinite := []float64{math.NaN(), math.Inf(1), math.Inf(-1)}
+	expected := []string{"NaN", "+Inf", "-Inf"}
+
+	for i, value := range nonFinite {
+
+		rendered = (&LiteralNode{Value: value}).String()
+		if rendered != expected[i] {
+			test.Logf("Expected %v to be written as '%s', got '%s'", value, expected[i], rendered)
+			test.Fail()
+		}
+	}
+}
+
+func TestInspect(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("age > 18 && country in ('CN', 'US') && score >= limit")
+
+	var variables []string
+	Inspect(expression.AST(), func(node ExpressionNode) bool {
+
+		variable, ok := node.(*VariableNode)
+		if ok {
+			variables = append(variables, variable.Name)
+		}
+		return true
+	})
+
+	expected := []string{"age", "country", "score", "limit"}
+	if !reflect.DeepEqual(variables, expected) {
+		test.Logf("Expected to inspect variables %v, got %v", expected, variables)
+		test.Fail()
+	}
+
+	// pruning children should stop at the top-level node.
+	visited := 0
+	Inspect(expression.AST(), func(node ExpressionNode) bool {
+		if node != nil {
+			visited++
+		}
+		return false
+	})
+
+	if visited != 1 {
+		test.Logf("Expected pruned inspection to visit one node, visited %d", visited)
+		test.Fail()
+	}
+}
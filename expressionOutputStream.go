@@ -31,6 +31,10 @@ func (this *expressionOutputStream) createString(delimiter string) string {
 	var retBuffer bytes.Buffer
 	var transaction string
 
+	if len(this.transactions) == 0 {
+		return ""
+	}
+
 	penultimate := len(this.transactions) - 1
 
 	for i := 0; i < penultimate; i++ {
@@ -0,0 +1,248 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+/*
+	Represents a test of an expression with indexes, evaluated in every numeric mode.
+	Expected is the result formatted with its type, such as "a (string)", or the message of the error it should fail with.
+	Results which are whole numbers are given as they'd be written, and converted to the mode's type before being compared.
+*/
+type IndexTest struct {
+	Name     string
+	Input    string
+	Expected string
+}
+
+func TestIndexEvaluation(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"items":    []interface{}{"a", map[string]interface{}{"name": "b"}, 3},
+		"ints":     []int{10, 20},
+		"pair":     [2]string{"x", "y"},
+		"prices":   map[string]interface{}{"EUR": 2, "a.b c": 1},
+		"numbered": map[int]string{1: "one"},
+		"word":     "héllo",
+		"foo":      dummyParameterInstance,
+		"currency": "EUR",
+		"i":        1,
+		"my var":   []interface{}{"escaped"},
+	}
+
+	indexTests := []IndexTest{
+		IndexTest{
+			Name:     "Array",
+			Input:    "items[0]",
+			Expected: "a (string)",
+		},
+		IndexTest{
+			Name:     "Slice",
+			Input:    "ints[1] + pair[0]",
+			Expected: "20x (string)",
+		},
+		IndexTest{
+			Name:     "Computed index",
+			Input:    "items[i + 1]",
+			Expected: "3",
+		},
+		IndexTest{
+			Name:     "Nested",
+			Input:    "items[1]['name'] + items[0][0]",
+			Expected: "ba (string)",
+		},
+		IndexTest{
+			Name:     "Map key which isn't a name",
+			Input:    "prices['a.b c']",
+			Expected: "1",
+		},
+		IndexTest{
+			Name:     "Dynamic key",
+			Input:    "prices[currency] * 2",
+			Expected: "4",
+		},
+		IndexTest{
+			Name:     "Numeric key",
+			Input:    "numbered[1]",
+			Expected: "one (string)",
+		},
+		IndexTest{
+			Name:     "String",
+			Input:    "word[1] + 'abc'[2]",
+			Expected: "éc (string)",
+		},
+		IndexTest{
+			Name:     "Struct field",
+			Input:    "foo['String']",
+			Expected: "string! (string)",
+		},
+		IndexTest{
+			Name:     "Method result",
+			Input:    "foo.Func()[1]",
+			Expected: "u (string)",
+		},
+		IndexTest{
+			Name:     "Array literal",
+			Input:    "(1, 2, 3)[1]",
+			Expected: "2",
+		},
+		IndexTest{
+			Name:     "Escaped variable",
+			Input:    "[my var][0]",
+			Expected: "escaped (string)",
+		},
+		IndexTest{
+			Name:     "Precedence",
+			Input:    "-ints[0] ** 2",
+			Expected: "100",
+		},
+		IndexTest{
+			Name:     "Index in index",
+			Input:    "items[ints[0] - 10]",
+			Expected: "a (string)",
+		},
+		IndexTest{
+			Name:     "Out of range",
+			Input:    "items[3]",
+			Expected: "Index 3 is out of range for an array of length 3",
+		},
+		IndexTest{
+			Name:     "Negative",
+			Input:    "items[-1]",
+			Expected: "Index -1 is out of range for an array of length 3",
+		},
+		IndexTest{
+			Name:     "Fractional",
+			Input:    "items[0.5]",
+			Expected: "Index '0.5' cannot be used with an array, it is not a whole number",
+		},
+		IndexTest{
+			Name:     "Missing key",
+			Input:    "prices['USD']",
+			Expected: "No key 'USD' present in map",
+		},
+		IndexTest{
+			Name:     "Wrong key type",
+			Input:    "numbered['1']",
+			Expected: "Key '1' cannot be used with a map of int keys",
+		},
+		IndexTest{
+			Name:     "Not indexable",
+			Input:    "i[0]",
+			Expected: "Value '1' cannot be used with the index operator '[]'",
+		},
+	}
+
+	for _, indexTest := range indexTests {
+
+		for _, numbers := range []NumericMode{NUMBERS_FLOAT, NUMBERS_INTEGER, NUMBERS_DECIMAL} {
+
+			expression, err := NewEvaluableExpressionWithOptions(indexTest.Input, ParseOptions{Numbers: numbers})
+			if err != nil {
+				test.Logf("Test '%s' failed to parse: %s", indexTest.Name, err)
+				test.Fail()
+				break
+			}
+
+			expected := indexTest.Expected
+			if number, err := strconv.ParseInt(indexTest.Expected, 10, 64); err == nil {
+				expected = describeNullResult(numbers.sanitize(number), nil)
+			}
+
+			expressionResult, expressionErr := expression.Evaluate(parameters)
+			programResult, programErr := expression.Program().Evaluate(parameters)
+			traceResult, _, traceErr := expression.EvalWithTrace(MapParameters(parameters))
+
+			actuals := []string{
+				describeNullResult(expressionResult, expressionErr),
+				describeNullResult(programResult, programErr),
+				describeNullResult(traceResult, traceErr),
+			}
+
+			for _, actual := range actuals {
+
+				if actual != expected {
+					test.Logf("Test '%s' failed with %v", indexTest.Name, numbers)
+					test.Logf("Expected '%s', got '%s'", expected, actual)
+					test.Fail()
+				}
+			}
+		}
+	}
+}
+
+/*
+	Indexes must be kept by everything which reads or writes expressions, and fail where they are.
+*/
+func TestIndexExpressions(test *testing.T) {
+
+	var indexError *IndexError
+
+	expression, _ := NewEvaluableExpression("items[i] > -items[0][1]")
+
+	if expression.AST().String() != "items[i] > -items[0][1]" {
+		test.Logf("Unexpected AST '%s'", expression.AST().String())
+		test.Fail()
+	}
+
+	residual := expression.PartialEval(MapParameters{"i": 2})
+	if residual.String() != "items[2] > -items[0][1]" {
+		test.Logf("Unexpected residual '%s'", residual.String())
+		test.Fail()
+	}
+
+	valueType, err := expression.InferType(TypeSchema{"items": TYPE_ARRAY, "i": TYPE_NUMBER})
+	if err != nil || valueType != TYPE_BOOL {
+		test.Logf("Expected a bool, got %v (%v)", valueType, err)
+		test.Fail()
+	}
+
+	err = expression.Check(TypeSchema{"items": TYPE_NUMBER, "i": TYPE_NUMBER})
+	if err == nil {
+		test.Logf("Expected indexing a number to fail the check")
+		test.Fail()
+	}
+
+	_, err = expression.ToSQLQuery()
+	if err == nil {
+		test.Logf("Expected indexes not to be converted to SQL")
+		test.Fail()
+	}
+
+	encoded, err := json.Marshal(expression.Program())
+	if err != nil {
+		test.Logf("Failed to encode program: %v", err)
+		test.Fail()
+		return
+	}
+
+	program, err := LoadProgram(encoded, ParseOptions{Numbers: NUMBERS_INTEGER})
+	if err != nil {
+		test.Logf("Failed to load program: %v", err)
+		test.Fail()
+		return
+	}
+
+	result, err := program.Evaluate(map[string]interface{}{"i": 1, "items": []interface{}{[]int{1, 2}, 3}})
+	if result != true || err != nil {
+		test.Logf("Expected the loaded program to give true, got %v (%v)", result, err)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpression("total + items[5]")
+	_, err = expression.Evaluate(map[string]interface{}{"total": 1, "items": []interface{}{}})
+
+	if !errors.As(err, &indexError) {
+		test.Logf("Expected an *IndexError, got %v", err)
+		test.Fail()
+		return
+	}
+
+	if indexError.Key != 5.0 || indexError.Start != 13 || indexError.End != 16 {
+		test.Logf("Unexpected error %+v", indexError)
+		test.Fail()
+	}
+}
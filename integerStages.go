@@ -0,0 +1,486 @@
+package govaluate
+
+import (
+	"math"
+	"math/big"
+)
+
+/*
+	The operators used by `NUMBERS_INTEGER` in place of those in `stageSymbolMap`.
+	Each operates exactly when given two integers, and otherwise does the same as the operator it replaces.
+*/
+var integerStageSymbolMap = map[OperatorSymbol]evaluationOperator{
+	PLUS:           makeIntegerStage(addInt64, addBigInt, addStage),
+	MINUS:          makeIntegerStage(subtractInt64, subtractBigInt, subtractStage),
+	MULTIPLY:       makeIntegerStage(multiplyInt64, multiplyBigInt, multiplyStage),
+	DIVIDE:         makeIntegerStage(divideInt64, divideBigInt, divideStage),
+	MODULUS:        makeIntegerStage(modulusInt64, modulusBigInt, modulusStage),
+	EXPONENT:       makeIntegerStage(nil, exponentBigInt, exponentStage),
+	BITWISE_AND:    makeIntegerStage(bitwiseAndInt64, bitwiseAndBigInt, bitwiseAndStage),
+	BITWISE_OR:     makeIntegerStage(bitwiseOrInt64, bitwiseOrBigInt, bitwiseOrStage),
+	BITWISE_XOR:    makeIntegerStage(bitwiseXORInt64, bitwiseXORBigInt, bitwiseXORStage),
+	BITWISE_LSHIFT: makeIntegerStage(leftShiftInt64, leftShiftBigInt, leftShiftStage),
+	BITWISE_RSHIFT: makeIntegerStage(rightShiftInt64, rightShiftBigInt, rightShiftStage),
+	NEGATE:         makeIntegerPrefixStage(negateInt64, negateBigInt, negateStage),
+	BITWISE_NOT:    makeIntegerPrefixStage(bitwiseNotInt64, bitwiseNotBigInt, bitwiseNotStage),
+	GT:             makeIntegerComparator(func(comparison int) bool { return comparison > 0 }, gtStage),
+	LT:             makeIntegerComparator(func(comparison int) bool { return comparison < 0 }, ltStage),
+	GTE:            makeIntegerComparator(func(comparison int) bool { return comparison >= 0 }, gteStage),
+	LTE:            makeIntegerComparator(func(comparison int) bool { return comparison <= 0 }, lteStage),
+	EQ:             makeIntegerComparator(func(comparison int) bool { return comparison == 0 }, equalStage),
+	NEQ:            makeIntegerComparator(func(comparison int) bool { return comparison != 0 }, notEqualStage),
+	IN:             integerInStage,
+	INDEX:          makeIndexStage(NUMBERS_INTEGER),
+}
+
+type integerKind int
+
+const (
+	notInteger integerKind = iota
+
+	// held by an int64.
+	signedInteger
+
+	// too large for an int64, held by a uint64.
+	unsignedInteger
+)
+
+/*
+	Makes an operator which operates exactly on two integers, and gives anything else to [fallback].
+	[fast] operates on two int64s, and returns false if the result can't be held by an int64, or isn't an integer.
+	[slow] operates on any two integers, and returns nil if the result isn't an integer, in which case [fallback] is used too.
+	Integer results too large for 64 bits are *big.Ints.
+*/
+func makeIntegerStage(fast func(left, right int64) (int64, bool), slow func(left, right *big.Int) *big.Int, fallback evaluationOperator) evaluationOperator {
+
+	return func(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+
+		leftInteger, leftKind := integerOf(left)
+		rightInteger, rightKind := integerOf(right)
+
+		if leftKind == notInteger || rightKind == notInteger {
+			return integerFallback(fallback, left, right, leftStage, rightStage, parameters)
+		}
+
+		if fast != nil && leftKind == signedInteger && rightKind == signedInteger {
+
+			result, ok := fast(leftInteger, rightInteger)
+			if ok {
+				return result, leftStage, rightStage, nil
+			}
+		}
+
+		result := slow(bigIntegerOf(left), bigIntegerOf(right))
+		if result == nil {
+			return fallback(left, right, leftStage, rightStage, parameters)
+		}
+		return normalizeInteger(result), leftStage, rightStage, nil
+	}
+}
+
+/*
+	Same as `makeIntegerStage`, for prefixes, which only have a right side.
+*/
+func makeIntegerPrefixStage(fast func(right int64) (int64, bool), slow func(right *big.Int) *big.Int, fallback evaluationOperator) evaluationOperator {
+
+	return func(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+
+		rightInteger, rightKind := integerOf(right)
+
+		switch rightKind {
+		case notInteger:
+			return integerFallback(fallback, left, right, leftStage, rightStage, parameters)
+		case signedInteger:
+			result, ok := fast(rightInteger)
+			if ok {
+				return result, leftStage, rightStage, nil
+			}
+		}
+		return normalizeInteger(slow(bigIntegerOf(right))), leftStage, rightStage, nil
+	}
+}
+
+/*
+	Calls [fallback] with operands which aren't both integers, such as a *big.Int (see `isBigOperand`) and an int64.
+	Whole *big.Int results are normalized like any other integer result, so that they're int64s if they fit.
+*/
+func integerFallback(fallback evaluationOperator, left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+
+	result, leftStage, rightStage, err := fallback(left, right, leftStage, rightStage, parameters)
+
+	integer, isBig := result.(*big.Int)
+	if isBig && err == nil {
+		return normalizeInteger(integer), leftStage, rightStage, nil
+	}
+	return result, leftStage, rightStage, err
+}
+
+/*
+	Makes a comparator which compares two integers exactly, and gives anything else to [fallback].
+	[compare] is given -1, 0 or 1, if the left side is less than, equal to, or greater than the right side.
+*/
+func makeIntegerComparator(compare func(comparison int) bool, fallback evaluationOperator) evaluationOperator {
+
+	return func(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+
+		comparison, ok := compareIntegers(left, right)
+		if !ok {
+			return fallback(left, right, leftStage, rightStage, parameters)
+		}
+		return boolIface(compare(comparison)), leftStage, rightStage, nil
+	}
+}
+
+/*
+	Same as `inStage`, except that integers are equal to any other integer (or float64) of the same value, whatever their types.
+*/
+func integerInStage(left, right, leftStage, rightStage interface{}, parameters Parameters) (interface{}, interface{}, interface{}, error) {
+
+	_, leftKind := integerOf(left)
+
+	values, _ := right.([]interface{})
+
+	for _, value := range values {
+
+		if left == value || isNil(left) && isNil(value) {
+			return true, leftStage, rightStage, nil
+		}
+
+		// results too large for 64 bits are big numbers.
+		if isBigOperand(left, value) {
+
+			comparison, ok := compareBig(left, value)
+			if ok && comparison == 0 {
+				return true, leftStage, rightStage, nil
+			}
+			continue
+		}
+
+		if leftKind == notInteger {
+			continue
+		}
+
+		comparison, ok := compareIntegers(left, value)
+		if ok && comparison == 0 {
+			return true, leftStage, rightStage, nil
+		}
+
+		number, isFloat := value.(float64)
+		if isFloat && number == toFloat64(left) {
+			return true, leftStage, rightStage, nil
+		}
+	}
+	return false, leftStage, rightStage, nil
+}
+
+/*
+	Compares two integers, returning false if either isn't one.
+*/
+func compareIntegers(left, right interface{}) (int, bool) {
+
+	leftInteger, leftKind := integerOf(left)
+	rightInteger, rightKind := integerOf(right)
+
+	if leftKind == notInteger || rightKind == notInteger {
+		return 0, false
+	}
+
+	if leftKind == signedInteger && rightKind == signedInteger {
+
+		switch {
+		case leftInteger < rightInteger:
+			return -1, true
+		case leftInteger > rightInteger:
+			return 1, true
+		}
+		return 0, true
+	}
+	return bigIntegerOf(left).Cmp(bigIntegerOf(right)), true
+}
+
+/*
+	Returns the integer held by [value] as an int64, and which kind of integer it is.
+	Integers too large for an int64 are `unsignedInteger`, and must be read with `bigIntegerOf`.
+*/
+func integerOf(value interface{}) (int64, integerKind) {
+
+	switch typed := value.(type) {
+	case int64:
+		return typed, signedInteger
+	case int:
+		return int64(typed), signedInteger
+	case int8:
+		return int64(typed), signedInteger
+	case int16:
+		return int64(typed), signedInteger
+	case int32:
+		return int64(typed), signedInteger
+	case uint8:
+		return int64(typed), signedInteger
+	case uint16:
+		return int64(typed), signedInteger
+	case uint32:
+		return int64(typed), signedInteger
+	case uint:
+		return unsignedIntegerOf(uint64(typed))
+	case uint64:
+		return unsignedIntegerOf(typed)
+	}
+	return 0, notInteger
+}
+
+func unsignedIntegerOf(value uint64) (int64, integerKind) {
+
+	if value > math.MaxInt64 {
+		return 0, unsignedInteger
+	}
+	return int64(value), signedInteger
+}
+
+/*
+	Returns the integer held by [value], which must be one.
+*/
+func bigIntegerOf(value interface{}) *big.Int {
+
+	switch typed := value.(type) {
+	case uint:
+		return new(big.Int).SetUint64(uint64(typed))
+	case uint64:
+		return new(big.Int).SetUint64(typed)
+	}
+
+	integer, _ := integerOf(value)
+	return big.NewInt(integer)
+}
+
+/*
+	Returns [value] as an int64 if it fits in one, a uint64 if it fits in that, and otherwise as the *big.Int itself,
+	so that no integer result is rounded (see `isBigOperand`).
+*/
+func normalizeInteger(value *big.Int) interface{} {
+
+	if value.IsInt64() {
+		return value.Int64()
+	}
+	if value.IsUint64() {
+		return value.Uint64()
+	}
+	return value
+}
+
+/*
+	Converts any integer to an int64 (or a uint64, if it's too large for an int64), and float32s to float64s.
+	Anything else is returned as-is.
+*/
+func castToInteger(value interface{}) interface{} {
+
+	switch typed := value.(type) {
+	case int64, float64:
+		return value
+	case float32:
+		return float64(typed)
+	case uint:
+		return normalizeUnsigned(uint64(typed))
+	case uint64:
+		return normalizeUnsigned(typed)
+	}
+
+	integer, kind := integerOf(value)
+	if kind == signedInteger {
+		return integer
+	}
+	return value
+}
+
+func normalizeUnsigned(value uint64) interface{} {
+
+	if value > math.MaxInt64 {
+		return value
+	}
+	return int64(value)
+}
+
+func toFloat64(value interface{}) float64 {
+
+	ret, _ := convert2Float64(value)
+	return ret
+}
+
+func addInt64(left, right int64) (int64, bool) {
+
+	ret := left + right
+	return ret, (right > 0) == (ret > left) || right == 0
+}
+
+func subtractInt64(left, right int64) (int64, bool) {
+
+	ret := left - right
+	return ret, (right > 0) == (ret < left) || right == 0
+}
+
+func multiplyInt64(left, right int64) (int64, bool) {
+
+	if left == 0 || right == 0 {
+		return 0, true
+	}
+
+	ret := left * right
+	if ret/right != left || (left == -1 && right == math.MinInt64) || (right == -1 && left == math.MinInt64) {
+		return 0, false
+	}
+	return ret, true
+}
+
+func divideInt64(left, right int64) (int64, bool) {
+
+	if right == 0 || left%right != 0 || (left == math.MinInt64 && right == -1) {
+		return 0, false
+	}
+	return left / right, true
+}
+
+func modulusInt64(left, right int64) (int64, bool) {
+
+	if right == 0 {
+		return 0, false
+	}
+	return left % right, true
+}
+
+func bitwiseAndInt64(left, right int64) (int64, bool) {
+	return left & right, true
+}
+
+func bitwiseOrInt64(left, right int64) (int64, bool) {
+	return left | right, true
+}
+
+func bitwiseXORInt64(left, right int64) (int64, bool) {
+	return left ^ right, true
+}
+
+func leftShiftInt64(left, right int64) (int64, bool) {
+
+	if right < 0 || right >= 63 {
+		return 0, false
+	}
+
+	ret := left << uint(right)
+	return ret, ret>>uint(right) == left
+}
+
+func rightShiftInt64(left, right int64) (int64, bool) {
+
+	if right < 0 {
+		return 0, false
+	}
+	if right >= 63 {
+		right = 63
+	}
+	return left >> uint(right), true
+}
+
+func negateInt64(right int64) (int64, bool) {
+	return -right, right != math.MinInt64
+}
+
+func bitwiseNotInt64(right int64) (int64, bool) {
+	return ^right, true
+}
+
+func addBigInt(left, right *big.Int) *big.Int {
+	return left.Add(left, right)
+}
+
+func subtractBigInt(left, right *big.Int) *big.Int {
+	return left.Sub(left, right)
+}
+
+func multiplyBigInt(left, right *big.Int) *big.Int {
+	return left.Mul(left, right)
+}
+
+/*
+	Integers only divide into an integer if there's no remainder, otherwise the result is a float64.
+*/
+func divideBigInt(left, right *big.Int) *big.Int {
+
+	var remainder big.Int
+
+	if right.Sign() == 0 {
+		return nil
+	}
+
+	left.QuoRem(left, right, &remainder)
+	if remainder.Sign() != 0 {
+		return nil
+	}
+	return left
+}
+
+/*
+	Same as the float64 modulus, the result has the sign of the left side.
+*/
+func modulusBigInt(left, right *big.Int) *big.Int {
+
+	if right.Sign() == 0 {
+		return nil
+	}
+	return left.Rem(left, right)
+}
+
+/*
+	Negative exponents can't give an integer. Any exponent which gives more than 64 bits is a float64 anyway,
+	so large exponents are left to float64s rather than calculated exactly.
+*/
+func exponentBigInt(left, right *big.Int) *big.Int {
+
+	if right.Sign() < 0 || (right.Cmp(big.NewInt(64)) > 0 && left.CmpAbs(big.NewInt(1)) > 0) {
+		return nil
+	}
+
+	return left.Exp(left, right, nil)
+}
+
+func bitwiseAndBigInt(left, right *big.Int) *big.Int {
+	return left.And(left, right)
+}
+
+func bitwiseOrBigInt(left, right *big.Int) *big.Int {
+	return left.Or(left, right)
+}
+
+func bitwiseXORBigInt(left, right *big.Int) *big.Int {
+	return left.Xor(left, right)
+}
+
+/*
+	Shifts which give more than 64 bits are a float64, as with any other operator.
+	Shifts by negative amounts (or more than any float64 could hold) are left to float64s.
+*/
+func leftShiftBigInt(left, right *big.Int) *big.Int {
+
+	if right.Sign() < 0 || right.Cmp(big.NewInt(1024)) > 0 {
+		return nil
+	}
+	return left.Lsh(left, uint(right.Int64()))
+}
+
+func rightShiftBigInt(left, right *big.Int) *big.Int {
+
+	if right.Sign() < 0 {
+		return nil
+	}
+	if right.Cmp(big.NewInt(64)) > 0 {
+		right.SetInt64(64)
+	}
+	return left.Rsh(left, uint(right.Int64()))
+}
+
+func negateBigInt(right *big.Int) *big.Int {
+	return right.Neg(right)
+}
+
+func bitwiseNotBigInt(right *big.Int) *big.Int {
+	return right.Not(right)
+}
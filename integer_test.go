@@ -0,0 +1,390 @@
+package govaluate
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+/*
+	Tests of expressions parsed with `NUMBERS_INTEGER`. Results are compared by type as well as value,
+	and each is also evaluated as a program, and as a program saved and loaded again.
+*/
+func TestIntegerEvaluation(test *testing.T) {
+
+	twoTo64, _ := new(big.Int).SetString("18446744073709551616", 10)
+
+	evaluationTests := []EvaluationTest{
+		EvaluationTest{
+			Name:     "Integer literal",
+			Input:    "9007199254740993",
+			Expected: int64(9007199254740993),
+		},
+		EvaluationTest{
+			Name:     "Hex literal beyond 2^53",
+			Input:    "0x20000000000001",
+			Expected: int64(9007199254740993),
+		},
+		EvaluationTest{
+			Name:     "Literal too large for an int64",
+			Input:    "18446744073709551615",
+			Expected: uint64(math.MaxUint64),
+		},
+		EvaluationTest{
+			Name:     "Literal too large for 64 bits",
+			Input:    "18446744073709551616",
+			Expected: twoTo64,
+		},
+		EvaluationTest{
+			Name:     "Exponent literal",
+			Input:    "1e3",
+			Expected: int64(1000),
+		},
+		EvaluationTest{
+			Name:     "Exponent literal beyond uint64",
+			Input:    "1_000e20 / 1000",
+			Expected: new(big.Int).Exp(big.NewInt(10), big.NewInt(20), nil),
+		},
+		EvaluationTest{
+			Name:     "Negative exponent literal",
+			Input:    "1e-2",
+			Expected: 0.01,
+		},
+		EvaluationTest{
+			Name:     "Exponent literal with a decimal point",
+			Input:    "1.5e2",
+			Expected: 150.0,
+		},
+		EvaluationTest{
+			Name:     "Float literal",
+			Input:    "1.5 + 1",
+			Expected: 2.5,
+		},
+		EvaluationTest{
+			Name:     "Exact arithmetic",
+			Input:    "9007199254740993 + 2 * 3 - 1",
+			Expected: int64(9007199254740998),
+		},
+		EvaluationTest{
+			Name:     "Exact division",
+			Input:    "6 / 2",
+			Expected: int64(3),
+		},
+		EvaluationTest{
+			Name:     "Inexact division",
+			Input:    "7 / 2",
+			Expected: 3.5,
+		},
+		EvaluationTest{
+			Name:     "Modulus",
+			Input:    "-7 % 3",
+			Expected: int64(-1),
+		},
+		EvaluationTest{
+			Name:     "Exponent",
+			Input:    "2 ** 62",
+			Expected: int64(4611686018427387904),
+		},
+		EvaluationTest{
+			Name:     "Negative exponent",
+			Input:    "2 ** -1",
+			Expected: 0.5,
+		},
+		EvaluationTest{
+			Name:     "Shift beyond int64",
+			Input:    "1 << 63",
+			Expected: uint64(1 << 63),
+		},
+		EvaluationTest{
+			Name:     "Overflow beyond uint64",
+			Input:    "18446744073709551615 + 1",
+			Expected: twoTo64,
+		},
+		EvaluationTest{
+			Name:     "Overflow below int64",
+			Input:    "-9223372036854775808 - 1",
+			Expected: new(big.Int).Sub(big.NewInt(math.MinInt64), big.NewInt(1)),
+		},
+		EvaluationTest{
+			Name:     "Exponent beyond uint64",
+			Input:    "2 ** 64",
+			Expected: twoTo64,
+		},
+		EvaluationTest{
+			Name:     "Bitwise NOT beyond int64",
+			Input:    "~0xFFFFFFFFFFFFFFFF",
+			Expected: new(big.Int).Neg(twoTo64),
+		},
+		EvaluationTest{
+			Name:     "Multiplication beyond uint64",
+			Input:    "9223372036854775807 * 4",
+			Expected: new(big.Int).Mul(big.NewInt(math.MaxInt64), big.NewInt(4)),
+		},
+		EvaluationTest{
+			Name:     "Back from beyond 64 bits",
+			Input:    "(2 ** 64 + 7) % 10",
+			Expected: int64(3),
+		},
+		EvaluationTest{
+			Name:     "Comparison beyond 64 bits",
+			Input:    "2 ** 64 > 18446744073709551615 && 2 ** 64 IN (1, 18446744073709551616)",
+			Expected: true,
+		},
+		EvaluationTest{
+			Name:     "Unsigned arithmetic",
+			Input:    "18446744073709551615 - 1",
+			Expected: uint64(math.MaxUint64 - 1),
+		},
+		EvaluationTest{
+			Name:     "Back to int64",
+			Input:    "18446744073709551615 - 18446744073709551614",
+			Expected: int64(1),
+		},
+		EvaluationTest{
+			Name:     "Negative minimum",
+			Input:    "-9223372036854775807 - 1",
+			Expected: int64(math.MinInt64),
+		},
+		EvaluationTest{
+			Name:     "Bitwise",
+			Input:    "(0xFF00 | 0xF) & ~0xF00 ^ 1",
+			Expected: int64(0xF00E),
+		},
+		EvaluationTest{
+			Name:     "Comparison beyond 2^53",
+			Input:    "9007199254740993 > 9007199254740992",
+			Expected: true,
+		},
+		EvaluationTest{
+			Name:     "Equality of int64 and uint64",
+			Input:    "18446744073709551615 != 9223372036854775807 && 5 == 5",
+			Expected: true,
+		},
+		EvaluationTest{
+			Name:     "Mixed comparison",
+			Input:    "3 < 3.5 && 3 == 3.0",
+			Expected: true,
+		},
+		EvaluationTest{
+			Name:     "Membership",
+			Input:    "3 in (1, 2.0, 3.0) && 2 in (1, 2)",
+			Expected: true,
+		},
+		EvaluationTest{
+			Name:     "Concatenation",
+			Input:    "'id-' + 9007199254740993",
+			Expected: "id-9007199254740993",
+		},
+		EvaluationTest{
+			Name:  "Integer parameters",
+			Input: "a + b + c + d + e",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{Name: "a", Value: int8(1)},
+				EvaluationParameter{Name: "b", Value: uint16(2)},
+				EvaluationParameter{Name: "c", Value: int32(3)},
+				EvaluationParameter{Name: "d", Value: 4},
+				EvaluationParameter{Name: "e", Value: uint(5)},
+			},
+			Expected: int64(15),
+		},
+		EvaluationTest{
+			Name:  "Large parameters",
+			Input: "id + 1 == 9007199254740994 && big > 9223372036854775807",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{Name: "id", Value: int64(9007199254740993)},
+				EvaluationParameter{Name: "big", Value: uint64(1 << 63)},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+			Name:  "Float parameters",
+			Input: "a * 2",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{Name: "a", Value: float32(1.5)},
+			},
+			Expected: 3.0,
+		},
+		EvaluationTest{
+			Name:  "Accessors",
+			Input: "foo.Int + 1",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{Name: "foo", Value: dummyParameterInstance},
+			},
+			Expected: int64(102),
+		},
+	}
+
+	options := ParseOptions{Numbers: NUMBERS_INTEGER}
+
+	for _, evaluationTest := range evaluationTests {
+
+		expression, err := NewEvaluableExpressionWithOptions(evaluationTest.Input, options)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %s", evaluationTest.Name, err)
+			test.Fail()
+			continue
+		}
+
+		parameters := make(map[string]interface{})
+		for _, parameter := range evaluationTest.Parameters {
+			parameters[parameter.Name] = parameter.Value
+		}
+
+		program := expression.Program()
+
+		encoded, err := program.MarshalJSON()
+		if err != nil {
+			test.Logf("Test '%s' failed to encode: %s", evaluationTest.Name, err)
+			test.Fail()
+			continue
+		}
+
+		loaded, err := LoadProgram(encoded, ParseOptions{})
+		if err != nil {
+			test.Logf("Test '%s' failed to load: %s", evaluationTest.Name, err)
+			test.Fail()
+			continue
+		}
+
+		expressionResult, expressionErr := expression.Evaluate(parameters)
+		programResult, programErr := program.Evaluate(parameters)
+		loadedResult, loadedErr := loaded.Evaluate(parameters)
+
+		for _, result := range []interface{}{expressionResult, programResult, loadedResult} {
+
+			if !reflect.DeepEqual(result, evaluationTest.Expected) {
+				test.Logf("Test '%s' failed", evaluationTest.Name)
+				test.Logf("Expected '%v' (%T), got '%v' (%T)", evaluationTest.Expected, evaluationTest.Expected, result, result)
+				test.Fail()
+			}
+		}
+
+		for _, err := range []error{expressionErr, programErr, loadedErr} {
+
+			if err != nil {
+				test.Logf("Test '%s' failed: %s", evaluationTest.Name, err)
+				test.Fail()
+			}
+		}
+	}
+}
+
+/*
+	Expressions parsed without a numeric mode must keep using float64s for everything.
+*/
+func TestIntegerModeIsOptIn(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("6 / 2 + id")
+
+	result, err := expression.Evaluate(map[string]interface{}{"id": int64(1)})
+	if err != nil || result != 4.0 {
+		test.Logf("Expected 4.0, got '%v' (%T): %v", result, result, err)
+		test.Fail()
+	}
+}
+
+/*
+	Shifts are limited to the range of an int64 only with float64s. Integers and decimals, which aren't, give exact results instead.
+	Each expected result is either the value and its type, or the error it fails with.
+*/
+func TestShiftsInEachMode(test *testing.T) {
+
+	shiftTests := []struct {
+		Input    string
+		Expected map[NumericMode]string
+	}{
+		{
+			Input: "1 << 63",
+			Expected: map[NumericMode]string{
+				NUMBERS_FLOAT:   "Value '1' cannot be shifted with the operator '<<', the result is outside the range of an int64",
+				NUMBERS_INTEGER: "9223372036854775808 (uint64)",
+				NUMBERS_DECIMAL: "9223372036854775808 (govaluate.Decimal)",
+			},
+		},
+		{
+			Input: "1 << 64",
+			Expected: map[NumericMode]string{
+				NUMBERS_FLOAT:   "Value '64' cannot be used to shift with the operator '<<', it is 64 or more",
+				NUMBERS_INTEGER: "18446744073709551616 (*big.Int)",
+				NUMBERS_DECIMAL: "18446744073709551616 (govaluate.Decimal)",
+			},
+		},
+		{
+			Input: "-1 >> 64",
+			Expected: map[NumericMode]string{
+				NUMBERS_FLOAT:   "Value '64' cannot be used to shift with the operator '>>', it is 64 or more",
+				NUMBERS_INTEGER: "-1 (int64)",
+				NUMBERS_DECIMAL: "-1 (govaluate.Decimal)",
+			},
+		},
+		{
+			Input: "1 << 1025",
+			Expected: map[NumericMode]string{
+				NUMBERS_FLOAT:   "Value '1025' cannot be used to shift with the operator '<<', it is 64 or more",
+				NUMBERS_INTEGER: "Value '1025' cannot be used to shift with the operator '<<', it is 64 or more",
+				NUMBERS_DECIMAL: "Value '1025' cannot be used to shift with the operator '<<', it is 64 or more",
+			},
+		},
+		{
+			Input: "1 << -1",
+			Expected: map[NumericMode]string{
+				NUMBERS_FLOAT:   "Value '-1' cannot be used to shift with the operator '<<', it is negative",
+				NUMBERS_INTEGER: "Value '-1' cannot be used to shift with the operator '<<', it is negative",
+				NUMBERS_DECIMAL: "Value '-1' cannot be used to shift with the operator '<<', it is negative",
+			},
+		},
+	}
+
+	for _, shiftTest := range shiftTests {
+		for mode, expected := range shiftTest.Expected {
+
+			expression, err := NewEvaluableExpressionWithOptions(shiftTest.Input, ParseOptions{Numbers: mode})
+			if err != nil {
+				test.Logf("Test '%s' (%v) failed to parse: %v", shiftTest.Input, mode, err)
+				test.Fail()
+				continue
+			}
+
+			var actual string
+
+			result, err := expression.Evaluate(nil)
+			if err != nil {
+				actual = err.Error()
+			} else {
+				actual = fmt.Sprintf("%v (%T)", result, result)
+			}
+
+			if actual != expected {
+				test.Logf("Test '%s' (%v) failed", shiftTest.Input, mode)
+				test.Logf("Expected '%s', got '%s'", expected, actual)
+				test.Fail()
+			}
+		}
+	}
+}
+
+func TestIntegerPartialEvaluation(test *testing.T) {
+
+	expression, _ := NewEvaluableExpressionWithOptions("id == 9007199254740993 + offset && amount > 1", ParseOptions{Numbers: NUMBERS_INTEGER})
+
+	residual := expression.PartialEval(MapParameters{"offset": int64(2)})
+	if residual.String() != "(id == 9007199254740995) && (amount > 1)" {
+		test.Logf("Unexpected residual expression '%s'", residual.String())
+		test.Fail()
+	}
+
+	result, err := residual.Evaluate(map[string]interface{}{"id": uint64(9007199254740995), "amount": 2})
+	if err != nil || result != true {
+		test.Logf("Expected residual to be true, got '%v': %v", result, err)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpressionWithOptions("6 / 2", ParseOptions{Numbers: NUMBERS_INTEGER})
+	value, err := expression.EvalFloat64(nil)
+	if err != nil || value != 3 {
+		test.Logf("Expected EvalFloat64 to convert an integer, got %v: %v", value, err)
+		test.Fail()
+	}
+}
@@ -25,6 +25,7 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			VARIABLE,
 			PATTERN,
 			FUNCTION,
@@ -45,6 +46,7 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			VARIABLE,
 			PATTERN,
 			FUNCTION,
@@ -67,6 +69,7 @@ var validLexerStates = []lexerState{
 			MODIFIER,
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			VARIABLE,
 			STRING,
 			PATTERN,
@@ -76,6 +79,8 @@ var validLexerStates = []lexerState{
 			LOGICALOP,
 			TERNARY,
 			SEPARATOR,
+			BRACKET,
+			BRACKET_CLOSE,
 		},
 	},
 
@@ -92,6 +97,7 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			BRACKET_CLOSE,
 		},
 	},
 	lexerState{
@@ -107,6 +113,23 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			BRACKET_CLOSE,
+		},
+	},
+	lexerState{
+
+		kind:       NULL,
+		isEOF:      true,
+		isNullable: true,
+		validNextKinds: []TokenKind{
+
+			MODIFIER,
+			COMPARATOR,
+			LOGICALOP,
+			CLAUSE_CLOSE,
+			TERNARY,
+			SEPARATOR,
+			BRACKET_CLOSE,
 		},
 	},
 	lexerState{
@@ -122,6 +145,8 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			BRACKET,
+			BRACKET_CLOSE,
 		},
 	},
 	lexerState{
@@ -136,6 +161,7 @@ var validLexerStates = []lexerState{
 			LOGICALOP,
 			CLAUSE_CLOSE,
 			SEPARATOR,
+			BRACKET_CLOSE,
 		},
 	},
 	lexerState{
@@ -150,6 +176,7 @@ var validLexerStates = []lexerState{
 			LOGICALOP,
 			CLAUSE_CLOSE,
 			SEPARATOR,
+			BRACKET_CLOSE,
 		},
 	},
 	lexerState{
@@ -165,6 +192,8 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			BRACKET,
+			BRACKET_CLOSE,
 		},
 	},
 	lexerState{
@@ -181,6 +210,7 @@ var validLexerStates = []lexerState{
 			ACCESSOR,
 			STRING,
 			BOOLEAN,
+			NULL,
 			CLAUSE,
 			CLAUSE_CLOSE,
 		},
@@ -195,6 +225,7 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			VARIABLE,
 			FUNCTION,
 			ACCESSOR,
@@ -215,6 +246,7 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			VARIABLE,
 			FUNCTION,
 			ACCESSOR,
@@ -233,6 +265,7 @@ var validLexerStates = []lexerState{
 
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			VARIABLE,
 			FUNCTION,
 			ACCESSOR,
@@ -251,6 +284,7 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			STRING,
 			TIME,
 			VARIABLE,
@@ -282,6 +316,44 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			BRACKET,
+			BRACKET_CLOSE,
+		},
+	},
+	lexerState{
+
+		kind:       BRACKET,
+		isEOF:      false,
+		isNullable: false,
+		validNextKinds: []TokenKind{
+
+			PREFIX,
+			NUMERIC,
+			BOOLEAN,
+			NULL,
+			VARIABLE,
+			FUNCTION,
+			ACCESSOR,
+			STRING,
+			TIME,
+			CLAUSE,
+		},
+	},
+	lexerState{
+
+		kind:       BRACKET_CLOSE,
+		isEOF:      true,
+		isNullable: false,
+		validNextKinds: []TokenKind{
+
+			MODIFIER,
+			COMPARATOR,
+			LOGICALOP,
+			CLAUSE_CLOSE,
+			TERNARY,
+			SEPARATOR,
+			BRACKET,
+			BRACKET_CLOSE,
 		},
 	},
 	lexerState{
@@ -294,6 +366,7 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			STRING,
 			TIME,
 			VARIABLE,
@@ -322,6 +395,11 @@ func checkExpressionSyntax(tokens []ExpressionToken) error {
 	var lastToken ExpressionToken
 	var err error
 
+	// an expression with nothing in it (or only comments) has nothing to evaluate, and evaluates to nil.
+	if len(tokens) == 0 {
+		return nil
+	}
+
 	state = validLexerStates[0]
 
 	for _, token := range tokens {
@@ -330,13 +408,14 @@ func checkExpressionSyntax(tokens []ExpressionToken) error {
 
 			// call out a specific error for tokens looking like they want to be functions.
 			if lastToken.Kind == VARIABLE && token.Kind == CLAUSE {
-				return errors.New("Undefined function " + lastToken.Value.(string))
+				undefined := &UndefinedFunctionError{Name: lastToken.Value.(string)}
+				return newTokenError(lastToken, "%v", undefined).causedBy(undefined)
 			}
 
 			firstStateName := fmt.Sprintf("%s [%v]", state.kind.String(), lastToken.Value)
 			nextStateName := fmt.Sprintf("%s [%v]", token.Kind.String(), token.Value)
 
-			return errors.New("Cannot transition token types from " + firstStateName + " to " + nextStateName)
+			return newTokenError(token, "Cannot transition token types from %s to %s", firstStateName, nextStateName)
 		}
 
 		state, err = getLexerStateForToken(token.Kind)
@@ -345,16 +424,16 @@ func checkExpressionSyntax(tokens []ExpressionToken) error {
 		}
 
 		if !state.isNullable && token.Value == nil {
-
-			errorMsg := fmt.Sprintf("Token kind '%v' cannot have a nil value", token.Kind.String())
-			return errors.New(errorMsg)
+			return newTokenError(token, "Token kind '%v' cannot have a nil value", token.Kind.String())
 		}
 
 		lastToken = token
 	}
 
 	if !state.isEOF {
-		return errors.New("Unexpected end of expression")
+
+		// point just past the last token, where the rest of the expression was expected.
+		return newTokenError(ExpressionToken{Start: lastToken.End, End: lastToken.End}, "Unexpected end of expression")
 	}
 	return nil
 }
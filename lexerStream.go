@@ -30,6 +30,13 @@ func (this *lexerStream) readCharacter() rune {
 	return character
 }
 
+/*
+	Returns the next character without reading it. Only valid if `canRead` is true.
+*/
+func (this lexerStream) peekCharacter() rune {
+	return this.source[this.position]
+}
+
 func (this *lexerStream) rewind(amount int) {
 	this.position -= amount
 }
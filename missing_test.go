@@ -0,0 +1,215 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+/*
+	Represents a test of an expression evaluated with a `MissingMode`.
+	Expected is the result formatted with its type, such as "0 (float64)", or the message of the error it should fail with.
+*/
+type MissingTest struct {
+	Name     string
+	Input    string
+	Missing  MissingMode
+	Defaults MapParameters
+	Expected string
+}
+
+func TestMissingModes(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"x":     1,
+		"none":  nil,
+		"order": map[string]interface{}{"total": 10},
+		"foo":   dummyParameterInstance,
+	}
+
+	missingTests := []MissingTest{
+		MissingTest{
+			Name:     "Error",
+			Input:    "discount ?? 0",
+			Expected: "No parameter 'discount' found.",
+		},
+		MissingTest{
+			Name:     "Nil",
+			Input:    "discount ?? 0",
+			Missing:  MISSING_NIL,
+			Expected: "0 (float64)",
+		},
+		MissingTest{
+			Name:     "Nil arithmetic",
+			Input:    "discount * 2",
+			Missing:  MISSING_NIL,
+			Expected: "Value '<nil>' cannot be used with the modifier '*', it is not a number",
+		},
+		MissingTest{
+			Name:     "Nil accessor",
+			Input:    "order.discount ?? order.total",
+			Missing:  MISSING_NIL,
+			Expected: "10 (float64)",
+		},
+		MissingTest{
+			Name:     "Default",
+			Input:    "discount * 2",
+			Missing:  MISSING_DEFAULT,
+			Defaults: MapParameters{"discount": 5},
+			Expected: "10 (float64)",
+		},
+		MissingTest{
+			Name:     "Given parameters before defaults",
+			Input:    "x",
+			Missing:  MISSING_DEFAULT,
+			Defaults: MapParameters{"x": 5},
+			Expected: "1 (float64)",
+		},
+		MissingTest{
+			Name:     "Default accessor",
+			Input:    "order.discount",
+			Missing:  MISSING_DEFAULT,
+			Defaults: MapParameters{"order.discount": 7},
+			Expected: "7 (float64)",
+		},
+		MissingTest{
+			Name:     "Missing default",
+			Input:    "discount",
+			Missing:  MISSING_DEFAULT,
+			Defaults: MapParameters{"rate": 5},
+			Expected: "No parameter 'discount' found.",
+		},
+		MissingTest{
+			Name:     "Exists",
+			Input:    "exists(x) && !exists(discount) && exists(none)",
+			Expected: "true (bool)",
+		},
+		MissingTest{
+			Name:     "Has",
+			Input:    "has(order.total) && !has(order.discount) && !has(customer.name)",
+			Expected: "true (bool)",
+		},
+		MissingTest{
+			Name:     "Has field",
+			Input:    "has(foo.String) && !has(foo.Missing)",
+			Expected: "true (bool)",
+		},
+		MissingTest{
+			Name:     "Exists without defaults",
+			Input:    "exists(discount)",
+			Missing:  MISSING_DEFAULT,
+			Defaults: MapParameters{"discount": 5},
+			Expected: "false (bool)",
+		},
+		MissingTest{
+			Name:     "Exists guard",
+			Input:    "exists(discount) ? discount : -1",
+			Expected: "-1 (float64)",
+		},
+	}
+
+	for _, missingTest := range missingTests {
+
+		expression, err := NewEvaluableExpression(missingTest.Input)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %s", missingTest.Name, err)
+			test.Fail()
+			continue
+		}
+		expression.Missing = missingTest.Missing
+		expression.Defaults = missingTest.Defaults
+
+		expressionResult, expressionErr := expression.Evaluate(parameters)
+		programResult, programErr := expression.Program().Evaluate(parameters)
+		traceResult, _, traceErr := expression.EvalWithTrace(MapParameters(parameters))
+
+		actuals := []string{
+			describeMissingResult(expressionResult, expressionErr),
+			describeMissingResult(programResult, programErr),
+			describeMissingResult(traceResult, traceErr),
+		}
+
+		for _, actual := range actuals {
+
+			if actual != missingTest.Expected {
+				test.Logf("Test '%s' failed", missingTest.Name)
+				test.Logf("Expected '%s', got '%s'", missingTest.Expected, actual)
+				test.Fail()
+			}
+		}
+	}
+}
+
+/*
+	Presence tests must be kept by everything which reads or writes expressions.
+*/
+func TestPresenceTests(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("exists(x) && !has(order.discount)")
+
+	if expression.AST().String() != "exists(x) && !has(order.discount)" {
+		test.Logf("Unexpected AST '%s'", expression.AST().String())
+		test.Fail()
+	}
+
+	residual := expression.PartialEval(MapParameters{"x": 1})
+	if residual.String() != "true && !has(order.discount)" {
+		test.Logf("Unexpected residual '%s'", residual.String())
+		test.Fail()
+	}
+
+	// absent parameters may still be given later.
+	residual = expression.PartialEval(MapParameters{})
+	if residual.String() != "exists(x) && !has(order.discount)" {
+		test.Logf("Unexpected residual '%s'", residual.String())
+		test.Fail()
+	}
+
+	valueType, err := expression.InferType(TypeSchema{})
+	if err != nil || valueType != TYPE_BOOL {
+		test.Logf("Expected a bool, got %v (%v)", valueType, err)
+		test.Fail()
+	}
+
+	encoded, err := json.Marshal(expression.Program())
+	if err != nil {
+		test.Logf("Failed to encode program: %v", err)
+		test.Fail()
+		return
+	}
+
+	program, err := LoadProgram(encoded, ParseOptions{})
+	if err != nil {
+		test.Logf("Failed to load program: %v", err)
+		test.Fail()
+		return
+	}
+
+	result, err := program.Evaluate(map[string]interface{}{"x": 1, "order": map[string]interface{}{}})
+	if result != true || err != nil {
+		test.Logf("Expected the loaded program to give true, got %v (%v)", result, err)
+		test.Fail()
+	}
+
+	// functions of the same name take precedence.
+	functions := map[string]ExpressionFunction{
+		"exists": func(arguments ...interface{}) (interface{}, error) {
+			return "custom", nil
+		},
+	}
+
+	expression, _ = NewEvaluableExpressionWithFunctions("exists(x)", functions)
+	result, err = expression.Evaluate(map[string]interface{}{"x": 1})
+	if result != "custom" || err != nil {
+		test.Logf("Expected the custom function to be called, got %v (%v)", result, err)
+		test.Fail()
+	}
+}
+
+func describeMissingResult(result interface{}, err error) string {
+
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("%v (%T)", result, result)
+}
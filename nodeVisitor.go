@@ -0,0 +1,49 @@
+package govaluate
+
+/*
+	A NodeVisitor's Visit method is invoked for each node encountered by `Walk`.
+	If the returned visitor is non-nil, `Walk` visits each of the children of [node] with it, followed by a call of Visit(nil).
+*/
+type NodeVisitor interface {
+	Visit(node ExpressionNode) NodeVisitor
+}
+
+/*
+	Traverses an expression tree in depth-first order, starting with [node].
+	This follows the same contract as `go/ast.Walk`.
+*/
+func Walk(visitor NodeVisitor, node ExpressionNode) {
+
+	if node == nil {
+		return
+	}
+
+	visitor = visitor.Visit(node)
+	if visitor == nil {
+		return
+	}
+
+	for _, child := range node.Children() {
+		Walk(visitor, child)
+	}
+
+	visitor.Visit(nil)
+}
+
+type inspector func(ExpressionNode) bool
+
+func (this inspector) Visit(node ExpressionNode) NodeVisitor {
+
+	if this(node) {
+		return this
+	}
+	return nil
+}
+
+/*
+	Traverses an expression tree in depth-first order, calling [visit] for each node.
+	If [visit] returns true, the children of that node are inspected too, followed by a call of visit(nil).
+*/
+func Inspect(node ExpressionNode, visit func(ExpressionNode) bool) {
+	Walk(inspector(visit), node)
+}
@@ -0,0 +1,206 @@
+package govaluate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+)
+
+/*
+	Represents a test of an expression evaluated with a `NonFiniteMode`.
+	Expected is the result formatted with its type, such as "<nil> (<nil>)", or the message of the error it should fail with.
+*/
+type NonFiniteTest struct {
+	Name       string
+	Input      string
+	Numbers    NumericMode
+	NonFinite  NonFiniteMode
+	Parameters map[string]interface{}
+	Expected   string
+}
+
+func TestNonFiniteModes(test *testing.T) {
+
+	nonFiniteTests := []NonFiniteTest{
+		NonFiniteTest{
+			Name:     "IEEE division by zero",
+			Input:    "1 / 0",
+			Expected: "+Inf (float64)",
+		},
+		NonFiniteTest{
+			Name:       "IEEE comparison",
+			Input:      "x / y > 1",
+			Parameters: map[string]interface{}{"x": 0, "y": 0},
+			Expected:   "false (bool)",
+		},
+		NonFiniteTest{
+			Name:      "Division by zero",
+			Input:     "1 / 0",
+			NonFinite: NONFINITE_ERROR,
+			Expected:  "Operator '/' cannot divide 1 by zero",
+		},
+		NonFiniteTest{
+			Name:       "Modulus by zero",
+			Input:      "x % y",
+			NonFinite:  NONFINITE_ERROR,
+			Parameters: map[string]interface{}{"x": 5, "y": 0},
+			Expected:   "Operator '%' cannot divide 5 by zero",
+		},
+		NonFiniteTest{
+			Name:       "Overflow",
+			Input:      "x * x",
+			NonFinite:  NONFINITE_ERROR,
+			Parameters: map[string]interface{}{"x": 1e200},
+			Expected:   "Operator '*' gave +Inf for 1e+200 and 1e+200",
+		},
+		NonFiniteTest{
+			Name:       "NaN parameter",
+			Input:      "x + 1",
+			NonFinite:  NONFINITE_ERROR,
+			Parameters: map[string]interface{}{"x": math.NaN()},
+			Expected:   "Operator '+' gave NaN for NaN and 1",
+		},
+		NonFiniteTest{
+			Name:       "Negation",
+			Input:      "-x",
+			NonFinite:  NONFINITE_ERROR,
+			Parameters: map[string]interface{}{"x": math.Inf(1)},
+			Expected:   "Operator '-' gave -Inf for +Inf",
+		},
+		NonFiniteTest{
+			Name:       "Finite results",
+			Input:      "(x / 4) ** 2",
+			NonFinite:  NONFINITE_ERROR,
+			Parameters: map[string]interface{}{"x": 2},
+			Expected:   "0.25 (float64)",
+		},
+		NonFiniteTest{
+			Name:       "Short-circuited",
+			Input:      "y != 0 && x / y > 1",
+			NonFinite:  NONFINITE_ERROR,
+			Parameters: map[string]interface{}{"x": 1, "y": 0},
+			Expected:   "false (bool)",
+		},
+		NonFiniteTest{
+			Name:       "Nil",
+			Input:      "x / y",
+			NonFinite:  NONFINITE_NIL,
+			Parameters: map[string]interface{}{"x": 1, "y": 0},
+			Expected:   "<nil> (<nil>)",
+		},
+		NonFiniteTest{
+			Name:       "Coalesced nil",
+			Input:      "(x / y) ?? -1",
+			NonFinite:  NONFINITE_NIL,
+			Parameters: map[string]interface{}{"x": 0, "y": 0},
+			Expected:   "-1 (float64)",
+		},
+		NonFiniteTest{
+			Name:      "Integer division by zero",
+			Input:     "7 / 0",
+			Numbers:   NUMBERS_INTEGER,
+			NonFinite: NONFINITE_ERROR,
+			Expected:  "Operator '/' cannot divide 7 by zero",
+		},
+		NonFiniteTest{
+			Name:      "Decimal division by zero",
+			Input:     "1.50 / 0",
+			Numbers:   NUMBERS_DECIMAL,
+			NonFinite: NONFINITE_ERROR,
+			Expected:  "Operator '/' cannot divide 1.50 by zero",
+		},
+		NonFiniteTest{
+			Name:       "Big division by zero",
+			Input:      "x / 0",
+			NonFinite:  NONFINITE_NIL,
+			Parameters: map[string]interface{}{"x": big.NewInt(3)},
+			Expected:   "<nil> (<nil>)",
+		},
+		NonFiniteTest{
+			Name:       "Infinite big float",
+			Input:      "x * 2",
+			NonFinite:  NONFINITE_ERROR,
+			Parameters: map[string]interface{}{"x": new(big.Float).SetInf(false)},
+			Expected:   "Operator '*' gave +Inf for +Inf and 2",
+		},
+	}
+
+	for _, nonFiniteTest := range nonFiniteTests {
+
+		expression, err := NewEvaluableExpressionWithOptions(nonFiniteTest.Input, ParseOptions{Numbers: nonFiniteTest.Numbers})
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %s", nonFiniteTest.Name, err)
+			test.Fail()
+			continue
+		}
+		expression.NonFinite = nonFiniteTest.NonFinite
+
+		expressionResult, expressionErr := expression.Evaluate(nonFiniteTest.Parameters)
+		programResult, programErr := expression.Program().Evaluate(nonFiniteTest.Parameters)
+		traceResult, _, traceErr := expression.EvalWithTrace(MapParameters(nonFiniteTest.Parameters))
+
+		actuals := []string{
+			describeNonFiniteResult(expressionResult, expressionErr),
+			describeNonFiniteResult(programResult, programErr),
+			describeNonFiniteResult(traceResult, traceErr),
+		}
+
+		for _, actual := range actuals {
+
+			if actual != nonFiniteTest.Expected {
+				test.Logf("Test '%s' failed", nonFiniteTest.Name)
+				test.Logf("Expected '%s', got '%s'", nonFiniteTest.Expected, actual)
+				test.Fail()
+			}
+		}
+	}
+}
+
+/*
+	Errors must say where the operator is, and typed evaluation must not skip the policy.
+*/
+func TestNonFiniteErrors(test *testing.T) {
+
+	var arithmeticError *ArithmeticError
+
+	expression, _ := NewEvaluableExpression("total / count")
+	expression.NonFinite = NONFINITE_ERROR
+
+	_, err := expression.EvalFloat64(MapParameters{"total": 10, "count": 0})
+	if !errors.As(err, &arithmeticError) {
+		test.Logf("Expected an *ArithmeticError, got %v", err)
+		test.Fail()
+		return
+	}
+
+	if arithmeticError.Operator != DIVIDE || arithmeticError.Start != 6 || arithmeticError.End != 7 {
+		test.Logf("Unexpected error %+v", arithmeticError)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpression("total / count > 1")
+	expression.NonFinite = NONFINITE_ERROR
+
+	_, err = expression.EvalBool(MapParameters{"total": 10, "count": 0})
+	if !errors.As(err, &arithmeticError) {
+		test.Logf("Expected EvalBool to fail with an *ArithmeticError, got %v", err)
+		test.Fail()
+	}
+
+	// partial evaluation leaves failing operators to fail again later.
+	residual := expression.PartialEval(MapParameters{"count": 0})
+	if residual.String() != "(total / 0) > 1" || residual.NonFinite != NONFINITE_ERROR {
+		test.Logf("Unexpected residual '%s'", residual.String())
+		test.Fail()
+	}
+}
+
+func describeNonFiniteResult(result interface{}, err error) string {
+
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("%v (%T)", result, result)
+}
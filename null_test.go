@@ -0,0 +1,189 @@
+package govaluate
+
+import (
+	"fmt"
+	"testing"
+)
+
+/*
+	Represents a test of an expression given null operands, either from the null literal or from nil parameters.
+	Expected is the result formatted with its type, such as "true (bool)", or the message of the error it should fail with.
+*/
+type NullTest struct {
+	Name     string
+	Input    string
+	Expected string
+}
+
+func TestNullEvaluation(test *testing.T) {
+
+	var missing *dummyParameter
+
+	parameters := map[string]interface{}{
+		"none":    nil,
+		"pointer": missing,
+		"count":   2,
+		"name":    "foo",
+		"flag":    true,
+		"null":    "named",
+	}
+
+	nullTests := []NullTest{
+		NullTest{
+			Name:     "Null literal",
+			Input:    "null",
+			Expected: "<nil> (<nil>)",
+		},
+		NullTest{
+			Name:     "Upper case null literal",
+			Input:    "NULL == none",
+			Expected: "true (bool)",
+		},
+		NullTest{
+			Name:     "Nil parameter equality",
+			Input:    "none == null && null == none && !(none != null)",
+			Expected: "true (bool)",
+		},
+		NullTest{
+			Name:     "Nil pointer equality",
+			Input:    "pointer == null",
+			Expected: "true (bool)",
+		},
+		NullTest{
+			Name:     "Number equality",
+			Input:    "count == null || null == count",
+			Expected: "false (bool)",
+		},
+		NullTest{
+			Name:     "String equality",
+			Input:    "name != null && name != none",
+			Expected: "true (bool)",
+		},
+		NullTest{
+			Name:     "Boolean equality",
+			Input:    "flag == true && flag != null",
+			Expected: "true (bool)",
+		},
+		NullTest{
+			Name:     "Mixed type equality",
+			Input:    "flag == 1 || name == 1",
+			Expected: "false (bool)",
+		},
+		NullTest{
+			Name:     "Ternary without else",
+			Input:    "(!flag ? 1) == null",
+			Expected: "true (bool)",
+		},
+		NullTest{
+			Name:     "Comparators",
+			Input:    "none > 1 || none < 1 || none >= 1 || 1 <= none || name < null",
+			Expected: "false (bool)",
+		},
+		NullTest{
+			Name:     "Regex comparators",
+			Input:    "!(none =~ 'foo') && none !~ 'foo'",
+			Expected: "true (bool)",
+		},
+		NullTest{
+			Name:     "Coalescence",
+			Input:    "null ?? none ?? 'default'",
+			Expected: "default (string)",
+		},
+		NullTest{
+			Name:     "Membership",
+			Input:    "none in (1, null) && !(none in (1, 2))",
+			Expected: "true (bool)",
+		},
+		NullTest{
+			Name:     "Membership in null",
+			Input:    "count in none",
+			Expected: "false (bool)",
+		},
+		NullTest{
+			Name:     "Parameter named null",
+			Input:    "null == none && [null] == 'named'",
+			Expected: "true (bool)",
+		},
+		NullTest{
+			Name:     "Null literal shadows a parameter",
+			Input:    "null ?? 'literal'",
+			Expected: "literal (string)",
+		},
+		NullTest{
+			Name:     "Arithmetic",
+			Input:    "none + 1",
+			Expected: "Value '<nil>' cannot be used with the modifier '+', it is not a number",
+		},
+	}
+
+	for _, nullTest := range nullTests {
+
+		for _, numbers := range []NumericMode{NUMBERS_FLOAT, NUMBERS_INTEGER, NUMBERS_DECIMAL} {
+
+			expression, err := NewEvaluableExpressionWithOptions(nullTest.Input, ParseOptions{Numbers: numbers})
+			if err != nil {
+				test.Logf("Test '%s' failed to parse: %s", nullTest.Name, err)
+				test.Fail()
+				break
+			}
+
+			expressionResult, expressionErr := expression.Evaluate(parameters)
+			programResult, programErr := expression.Program().Evaluate(parameters)
+
+			actuals := []string{
+				describeNullResult(expressionResult, expressionErr),
+				describeNullResult(programResult, programErr),
+			}
+
+			for _, actual := range actuals {
+
+				if actual != nullTest.Expected {
+					test.Logf("Test '%s' failed with %v", nullTest.Name, numbers)
+					test.Logf("Expected '%s', got '%s'", nullTest.Expected, actual)
+					test.Fail()
+				}
+			}
+		}
+	}
+}
+
+/*
+	Null must be kept as a literal by everything which reads or writes expressions.
+*/
+func TestNullLiterals(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("a == b && [null] != null")
+
+	residual := expression.PartialEval(MapParameters{"b": nil})
+	if residual.String() != "(a == null) && ([null] != null)" {
+		test.Logf("Unexpected residual '%s'", residual.String())
+		test.Fail()
+	}
+
+	query, err := expression.ToSQLQuery()
+	if err != nil || query != "[a] = [b] AND [null] IS NOT NULL" {
+		test.Logf("Unexpected query '%s' (%v)", query, err)
+		test.Fail()
+	}
+
+	valueType, err := expression.InferType(TypeSchema{"a": TYPE_NUMBER, "b": TYPE_NIL, "null": TYPE_STRING})
+	if err != nil || valueType != TYPE_BOOL {
+		test.Logf("Expected a bool, got %v (%v)", valueType, err)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpression("a > null ?? 1")
+	_, err = expression.InferType(TypeSchema{"a": TYPE_NUMBER | TYPE_NIL})
+	if err != nil {
+		test.Logf("Expected null to be comparable, got %v", err)
+		test.Fail()
+	}
+}
+
+func describeNullResult(result interface{}, err error) string {
+
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("%v (%T)", result, result)
+}
@@ -0,0 +1,168 @@
+package govaluate
+
+import (
+	"reflect"
+	"testing"
+)
+
+/*
+	Represents a test of an expression with optional accessor links.
+	Expected is the result formatted with its type, such as "0 (float64)", or the message of the error it should fail with.
+*/
+type OptionalTest struct {
+	Name     string
+	Input    string
+	Expected string
+}
+
+func TestOptionalAccessors(test *testing.T) {
+
+	var missing *dummyParameter
+
+	parameters := map[string]interface{}{
+		"user": map[string]interface{}{
+			"profile": map[string]interface{}{"age": 30},
+			"account": nil,
+		},
+		"none":    nil,
+		"pointer": missing,
+		"foo":     dummyParameterInstance,
+		"count":   2,
+	}
+
+	optionalTests := []OptionalTest{
+		OptionalTest{
+			Name:     "Present",
+			Input:    "user?.profile?.age ?? 0",
+			Expected: "30 (float64)",
+		},
+		OptionalTest{
+			Name:     "Missing key",
+			Input:    "user?.address?.city ?? 'unknown'",
+			Expected: "unknown (string)",
+		},
+		OptionalTest{
+			Name:     "Missing parameter",
+			Input:    "customer?.profile?.age ?? 0",
+			Expected: "0 (float64)",
+		},
+		OptionalTest{
+			Name:     "Nil value",
+			Input:    "user.account?.balance",
+			Expected: "<nil> (<nil>)",
+		},
+		OptionalTest{
+			Name:     "Nil value without optional link",
+			Input:    "user.account.balance",
+			Expected: "Unable to access 'balance', 'account' is not a struct or map",
+		},
+		OptionalTest{
+			Name:     "Nil parameter",
+			Input:    "none?.foo",
+			Expected: "<nil> (<nil>)",
+		},
+		OptionalTest{
+			Name:     "Nil pointer",
+			Input:    "pointer?.String ?? pointer?.Func() ?? 'empty'",
+			Expected: "empty (string)",
+		},
+		OptionalTest{
+			Name:     "Struct field",
+			Input:    "foo?.String",
+			Expected: "string! (string)",
+		},
+		OptionalTest{
+			Name:     "Method",
+			Input:    "foo?.Func()",
+			Expected: "funk (string)",
+		},
+		OptionalTest{
+			Name:     "Later link",
+			Input:    "user?.address.city",
+			Expected: "<nil> (<nil>)",
+		},
+		OptionalTest{
+			Name:     "Not a struct or map",
+			Input:    "count?.foo",
+			Expected: "Unable to access 'foo', 'count' is not a struct or map",
+		},
+		OptionalTest{
+			Name:     "Presence",
+			Input:    "has(user?.profile) && !has(user?.address) && !has(customer?.profile)",
+			Expected: "true (bool)",
+		},
+		OptionalTest{
+			Name:     "Ternary",
+			Input:    "user?.profile?.age > 18 ? 'adult' : 'minor'",
+			Expected: "adult (string)",
+		},
+	}
+
+	for _, optionalTest := range optionalTests {
+
+		expression, err := NewEvaluableExpression(optionalTest.Input)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %s", optionalTest.Name, err)
+			test.Fail()
+			continue
+		}
+
+		expressionResult, expressionErr := expression.Evaluate(parameters)
+		programResult, programErr := expression.Program().Evaluate(parameters)
+		traceResult, _, traceErr := expression.EvalWithTrace(MapParameters(parameters))
+
+		actuals := []string{
+			describeNullResult(expressionResult, expressionErr),
+			describeNullResult(programResult, programErr),
+			describeNullResult(traceResult, traceErr),
+		}
+
+		for _, actual := range actuals {
+
+			if actual != optionalTest.Expected {
+				test.Logf("Test '%s' failed", optionalTest.Name)
+				test.Logf("Expected '%s', got '%s'", optionalTest.Expected, actual)
+				test.Fail()
+			}
+		}
+	}
+}
+
+/*
+	Optional links must be kept by everything which reads or writes expressions.
+*/
+func TestOptionalAccessorPaths(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("user?.profile.age ?? 0")
+
+	path := expression.Tokens()[0].Value
+	if !reflect.DeepEqual(path, []string{"user", "?profile", "age"}) {
+		test.Logf("Unexpected accessor path %v", path)
+		test.Fail()
+	}
+
+	if expression.AST().String() != "user?.profile.age ?? 0" {
+		test.Logf("Unexpected AST '%s'", expression.AST().String())
+		test.Fail()
+	}
+
+	// the parameter may still be given to the residual.
+	residual := expression.PartialEval(MapParameters{})
+	if residual.String() != "user?.profile.age ?? 0" {
+		test.Logf("Unexpected residual '%s'", residual.String())
+		test.Fail()
+	}
+
+	residual = expression.PartialEval(MapParameters{"user": map[string]interface{}{}})
+	if residual.String() != "0" {
+		test.Logf("Unexpected residual '%s'", residual.String())
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpression("user?.profile.age")
+	valueType, err := expression.InferType(TypeSchema{"user.profile.age": TYPE_NUMBER})
+	if err != nil || valueType != TYPE_NUMBER|TYPE_NIL {
+		test.Logf("Expected a number or nil, got %v (%v)", valueType, err)
+		test.Fail()
+	}
+}
@@ -2,16 +2,17 @@ package govaluate
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
+	"math/big"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 )
 
-func parseTokens(expression string, functions map[string]ExpressionFunction) ([]ExpressionToken, error) {
+func parseTokens(expression string, options ParseOptions) ([]ExpressionToken, error) {
 
 	var ret []ExpressionToken
 	var token ExpressionToken
@@ -25,7 +26,7 @@ func parseTokens(expression string, functions map[string]ExpressionFunction) ([]
 
 	for stream.canRead() {
 
-		token, err, found = readToken(stream, state, functions)
+		token, err, found = readToken(stream, state, options)
 
 		if err != nil {
 			return ret, err
@@ -35,6 +36,12 @@ func parseTokens(expression string, functions map[string]ExpressionFunction) ([]
 			break
 		}
 
+		// comments are kept in the stream, but can be anywhere, so they don't change what may follow.
+		if token.Kind == COMMENT {
+			ret = append(ret, token)
+			continue
+		}
+
 		state, err = getLexerStateForToken(token.Kind)
 		if err != nil {
 			return ret, err
@@ -52,9 +59,10 @@ func parseTokens(expression string, functions map[string]ExpressionFunction) ([]
 	return ret, nil
 }
 
-func readToken(stream *lexerStream, state lexerState, functions map[string]ExpressionFunction) (ExpressionToken, error, bool) {
+func readToken(stream *lexerStream, state lexerState, options ParseOptions) (ExpressionToken, error, bool) {
 
 	var function ExpressionFunction
+	var contextFunction ContextExpressionFunction
 	var ret ExpressionToken
 	var tokenValue interface{}
 	var tokenTime time.Time
@@ -63,12 +71,13 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 	var character rune
 	var found bool
 	var completed bool
+	var start int
 	var err error
 
-	// numeric is 0-9, or . or 0x followed by digits
+	// numeric is 0-9 or ., or 0x, 0b or 0o followed by digits
 	// string starts with '
 	// variable is alphanumeric, always starts with a letter
-	// bracket always means variable
+	// bracket means variable, unless it follows something which can be indexed
 	// symbols are anything non-alphanumeric
 	// all others read into a buffer until they reach the end of the stream
 	for stream.canRead() {
@@ -80,36 +89,14 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 		}
 
 		kind = UNKNOWN
+		start = stream.position - 1
 
 		// numeric constant
 		if isNumeric(character) {
 
-			if stream.canRead() && character == '0' {
-				character = stream.readCharacter()
-
-				if stream.canRead() && character == 'x' {
-					tokenString, _ = readUntilFalse(stream, false, true, true, isHexDigit)
-					tokenValueInt, err := strconv.ParseUint(tokenString, 16, 64)
-
-					if err != nil {
-						errorMsg := fmt.Sprintf("Unable to parse hex value '%v' to uint64\n", tokenString)
-						return ExpressionToken{}, errors.New(errorMsg), false
-					}
-
-					kind = NUMERIC
-					tokenValue = float64(tokenValueInt)
-					break
-				} else {
-					stream.rewind(1)
-				}
-			}
-
-			tokenString = readTokenUntilFalse(stream, isNumeric)
-			tokenValue, err = strconv.ParseFloat(tokenString, 64)
-
+			tokenValue, err = readNumber(stream, start, options)
 			if err != nil {
-				errorMsg := fmt.Sprintf("Unable to parse numeric value '%v' to float64\n", tokenString)
-				return ExpressionToken{}, errors.New(errorMsg), false
+				return ExpressionToken{}, err, false
 			}
 			kind = NUMERIC
 			break
@@ -123,6 +110,20 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			break
 		}
 
+		// index, such as "items[0]", when following something which can be indexed.
+		// Anywhere else, brackets escape a variable name.
+		if character == '[' && state.canTransitionTo(BRACKET) {
+			tokenValue = character
+			kind = BRACKET
+			break
+		}
+
+		if character == ']' {
+			tokenValue = character
+			kind = BRACKET_CLOSE
+			break
+		}
+
 		// escaped variable
 		if character == '[' {
 
@@ -130,7 +131,7 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			kind = VARIABLE
 
 			if !completed {
-				return ExpressionToken{}, errors.New("Unclosed parameter bracket"), false
+				return ExpressionToken{}, newParseError(start, stream.position, "Unclosed parameter bracket"), false
 			}
 
 			// above method normally rewinds us to the closing bracket, which we want to skip.
@@ -143,6 +144,15 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 
 			tokenString = readTokenUntilFalse(stream, isVariableName)
 
+			// optional links ("user?.profile") carry on the same accessor.
+			for isOptionalLink(stream) {
+
+				stream.readCharacter()
+				stream.readCharacter()
+				stream.readCharacter()
+				tokenString += "?." + readTokenUntilFalse(stream, isVariableName)
+			}
+
 			tokenValue = tokenString
 			kind = VARIABLE
 
@@ -160,6 +170,13 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 				}
 			}
 
+			// null?
+			if tokenValue == "null" || tokenValue == "NULL" {
+
+				kind = NULL
+				tokenValue = nil
+			}
+
 			// textual operator?
 			if tokenValue == "in" || tokenValue == "IN" {
 
@@ -169,10 +186,36 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			}
 
 			// function?
-			function, found = functions[tokenString]
+			function, found = options.Functions[tokenString]
 			if found {
 				kind = FUNCTION
 				tokenValue = function
+				ret.name = tokenString
+			}
+
+			contextFunction, found = options.ContextFunctions[tokenString]
+			if found {
+				kind = FUNCTION
+				tokenValue = contextFunction
+				ret.name = tokenString
+			}
+
+			// built-in function of the numeric mode? Also only when called.
+			if kind == VARIABLE && isFollowedByClause(stream) {
+
+				function, found = findBuiltinFunction(tokenString, options.Numbers, options.Rounding)
+				if found {
+					kind = FUNCTION
+					tokenValue = function
+					ret.name = tokenString
+				}
+			}
+
+			// built-in presence test? Only when called, so that "exists" and "has" can still be variables.
+			if kind == VARIABLE && (tokenString == "exists" || tokenString == "has") && isFollowedByClause(stream) {
+				kind = FUNCTION
+				tokenValue = presenceTest{}
+				ret.name = tokenString
 			}
 
 			// accessor?
@@ -181,12 +224,11 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 
 				// check that it doesn't end with a hanging period
 				if tokenString[len(tokenString)-1] == '.' {
-					errorMsg := fmt.Sprintf("Hanging accessor on token '%s'", tokenString)
-					return ExpressionToken{}, errors.New(errorMsg), false
+					return ExpressionToken{}, newParseError(start, stream.position, "Hanging accessor on token '%s'", tokenString), false
 				}
 
 				kind = ACCESSOR
-				splits := strings.Split(tokenString, ".")
+				splits := strings.Split(strings.Replace(tokenString, "?.", ".?", -1), ".")
 				tokenValue = splits
 
 				//// check that none of them are unexported
@@ -204,15 +246,12 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 		}
 
 		if !isNotQuote(character) {
-			tokenValue, completed = readUntilFalse(stream, true, false, true, isNotQuote)
 
-			if !completed {
-				return ExpressionToken{}, errors.New("Unclosed string literal"), false
+			tokenValue, err = readString(stream, start, character, options.LegacyEscapes)
+			if err != nil {
+				return ExpressionToken{}, err, false
 			}
 
-			// advance the stream one position, since reading until false assumes the terminator is a real token
-			stream.rewind(-1)
-
 			// check to see if this can be parsed as a time.
 			tokenTime, found = tryParseTime(tokenValue.(string))
 			if found {
@@ -224,6 +263,17 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			break
 		}
 
+		// comments, either to the end of the line or in a block
+		if character == '/' && stream.canRead() && (stream.peekCharacter() == '/' || stream.peekCharacter() == '*') {
+
+			tokenValue, err = readComment(stream, start)
+			if err != nil {
+				return ExpressionToken{}, err, false
+			}
+			kind = COMMENT
+			break
+		}
+
 		if character == '(' {
 			tokenValue = character
 			kind = CLAUSE
@@ -278,16 +328,131 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			break
 		}
 
-		errorMessage := fmt.Sprintf("Invalid token: '%s'", tokenString)
-		return ret, errors.New(errorMessage), false
+		return ret, newParseError(start, stream.position, "Invalid token: '%s'", tokenString), false
 	}
 
 	ret.Kind = kind
 	ret.Value = tokenValue
+	ret.Start = start
+	ret.End = stream.position
 
 	return ret, nil, (kind != UNKNOWN)
 }
 
+/*
+	Reads a comment, the opening '/' of which was just read, and returns its text without the markers around it.
+	Line comments end before the next newline (or at the end of the expression), and block comments at the first closing marker;
+	they don't nest.
+*/
+func readComment(stream *lexerStream, start int) (string, error) {
+
+	var buffer bytes.Buffer
+	var character rune
+
+	if stream.readCharacter() == '/' {
+
+		for stream.canRead() {
+
+			character = stream.readCharacter()
+			if character == '\n' {
+				stream.rewind(1)
+				break
+			}
+			buffer.WriteRune(character)
+		}
+		return buffer.String(), nil
+	}
+
+	for stream.canRead() {
+
+		character = stream.readCharacter()
+		if character == '*' && stream.canRead() && stream.peekCharacter() == '/' {
+			stream.readCharacter()
+			return buffer.String(), nil
+		}
+		buffer.WriteRune(character)
+	}
+	return "", newParseError(start, stream.position, "Unclosed block comment")
+}
+
+/*
+	Reads a string literal, the opening [quote] of which was just read, up to the same quote.
+	Strings quoted with ' or " may contain the other quote as-is, and have Go's escape sequences ("\n", "\t", "\u00e9", "\x41", and so on),
+	as well as "\'" and "\"" in either. Strings quoted with backticks are raw: they have no escapes, and end at the first backtick.
+	With [legacyEscapes], a backslash instead escapes whatever follows it, which is kept as-is (see `ParseOptions.LegacyEscapes`).
+*/
+func readString(stream *lexerStream, start int, quote rune, legacyEscapes bool) (string, error) {
+
+	var buffer bytes.Buffer
+	var character rune
+
+	for stream.canRead() {
+
+		character = stream.readCharacter()
+
+		if character == quote {
+			return buffer.String(), nil
+		}
+
+		if character != '\\' || quote == '`' {
+			buffer.WriteRune(character)
+			continue
+		}
+
+		if !stream.canRead() {
+			break
+		}
+
+		if legacyEscapes {
+			buffer.WriteRune(stream.readCharacter())
+			continue
+		}
+
+		err := readEscape(stream, &buffer)
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", newParseError(start, stream.position, "Unclosed string literal")
+}
+
+/*
+	Reads an escape sequence, the backslash of which was just read, and writes the character it represents to [buffer].
+*/
+func readEscape(stream *lexerStream, buffer *bytes.Buffer) error {
+
+	start := stream.position - 1
+
+	// quotes can be escaped in any string, not only those they would end.
+	character := stream.readCharacter()
+	if character == '\'' || character == '"' {
+		buffer.WriteRune(character)
+		return nil
+	}
+
+	// no escape sequence is longer than "\UXXXXXXXX". Escapes are all ASCII, so each rune read is one byte of [sequence].
+	end := start + 10
+	if end > stream.length {
+		end = stream.length
+	}
+	sequence := string(stream.source[start:end])
+
+	value, multibyte, tail, err := strconv.UnquoteChar(sequence, 0)
+	if err != nil {
+		sequence = string(stream.source[start:stream.position])
+		return newParseError(start, stream.position, "Invalid escape sequence '%s' in string literal, write '\\%s' for a backslash, or use a raw string", sequence, sequence)
+	}
+	stream.position = start + len(sequence) - len(tail)
+
+	// "\x" and octal escapes are bytes, as they are in Go, even if they aren't valid UTF-8 on their own.
+	if value < utf8.RuneSelf || !multibyte {
+		buffer.WriteByte(byte(value))
+		return nil
+	}
+	buffer.WriteRune(value)
+	return nil
+}
+
 func readTokenUntilFalse(stream *lexerStream, condition func(rune) bool) string {
 
 	var ret string
@@ -325,6 +490,7 @@ func readUntilFalse(stream *lexerStream, includeWhitespace bool, breakWhitespace
 
 			if breakWhitespace && tokenBuffer.Len() > 0 {
 				conditioned = true
+				stream.rewind(1)
 				break
 			}
 			if !includeWhitespace {
@@ -367,7 +533,12 @@ func optimizeTokens(tokens []ExpressionToken) ([]ExpressionToken, error) {
 			continue
 		}
 
+		// the right-hand value may come after comments.
 		index++
+		for tokens[index].Kind == COMMENT {
+			index++
+		}
+
 		token = tokens[index]
 		if token.Kind == STRING {
 
@@ -375,7 +546,7 @@ func optimizeTokens(tokens []ExpressionToken) ([]ExpressionToken, error) {
 			token.Value, err = regexp.Compile(token.Value.(string))
 
 			if err != nil {
-				return tokens, err
+				return tokens, newTokenError(tokens[index], "Unable to compile regexp pattern '%v': %v", tokens[index].Value, err).causedBy(err)
 			}
 
 			tokens[index] = token
@@ -384,51 +555,377 @@ func optimizeTokens(tokens []ExpressionToken) ([]ExpressionToken, error) {
 	return tokens, nil
 }
 
+/*
+	Returns the given [tokens] without any comments, which are kept in an expression's tokens, but have no part in evaluating it.
+	The same slice is returned if there are none.
+*/
+func withoutComments(tokens []ExpressionToken) []ExpressionToken {
+
+	var ret []ExpressionToken
+
+	for index, token := range tokens {
+
+		if token.Kind != COMMENT {
+			if ret != nil {
+				ret = append(ret, token)
+			}
+			continue
+		}
+
+		if ret == nil {
+			ret = make([]ExpressionToken, index, len(tokens))
+			copy(ret, tokens[:index])
+		}
+	}
+
+	if ret == nil {
+		return tokens
+	}
+	return ret
+}
+
 /*
 	Checks the balance of tokens which have multiple parts, such as parenthesis.
 */
 func checkBalance(tokens []ExpressionToken) error {
 
+	err := checkPairBalance(tokens, CLAUSE, CLAUSE_CLOSE, "Unbalanced parenthesis")
+	if err != nil {
+		return err
+	}
+	return checkPairBalance(tokens, BRACKET, BRACKET_CLOSE, "Unbalanced brackets")
+}
+
+/*
+	Checks that every token of the [open] kind is closed by one of the [close] kind, failing with [message] if not.
+*/
+func checkPairBalance(tokens []ExpressionToken, open TokenKind, close TokenKind, message string) error {
+
 	var stream *tokenStream
 	var token ExpressionToken
-	var parens int
+	var openClauses, closedClauses []ExpressionToken
 
 	stream = newTokenStream(tokens)
 
+	// only the overall count matters here, misordered parens are caught by the syntax check.
+	// The unmatched ones are kept so the error can point at one of them.
 	for stream.hasNext() {
 
 		token = stream.next()
-		if token.Kind == CLAUSE {
-			parens++
+		if token.Kind == open {
+			openClauses = append(openClauses, token)
 			continue
 		}
-		if token.Kind == CLAUSE_CLOSE {
-			parens--
+		if token.Kind == close {
+
+			if len(openClauses) == 0 {
+				closedClauses = append(closedClauses, token)
+				continue
+			}
+			openClauses = openClauses[:len(openClauses)-1]
 			continue
 		}
 	}
 
-	if parens != 0 {
-		return errors.New("Unbalanced parenthesis")
+	if len(openClauses) > len(closedClauses) {
+		return newTokenError(openClauses[len(openClauses)-1], "%s", message)
+	}
+	if len(openClauses) < len(closedClauses) {
+		return newTokenError(closedClauses[len(closedClauses)-1], "%s", message)
 	}
 	return nil
 }
 
+/*
+	Returns true if the next character of [stream], other than whitespace, opens a clause.
+*/
+func isFollowedByClause(stream *lexerStream) bool {
+
+	for position := stream.position; position < stream.length; position++ {
+
+		if !unicode.IsSpace(stream.source[position]) {
+			return stream.source[position] == '('
+		}
+	}
+	return false
+}
+
+/*
+	Returns true if the stream is at an optional accessor link, such as the "?.profile" of "user?.profile".
+	Links must start with a letter, the same as the parameter names they follow.
+*/
+func isOptionalLink(stream *lexerStream) bool {
+
+	position := stream.position
+	return position+2 < stream.length &&
+		stream.source[position] == '?' &&
+		stream.source[position+1] == '.' &&
+		unicode.IsLetter(stream.source[position+2])
+}
+
 func isDigit(character rune) bool {
 	return unicode.IsDigit(character)
 }
 
-func isHexDigit(character rune) bool {
+/*
+	Reads a numeric literal, the first character of which was just read. Numbers may be written as decimals,
+	with an optional fraction and exponent ("12", "1.5", ".5", "6.02e23", "1e-6"), or as whole numbers in hex, binary or octal
+	("0xFF", "0b1010", "0o755"). Any of these may have underscores between digits, to make them easier to read ("1_000_000").
+*/
+func readNumber(stream *lexerStream, start int, options ParseOptions) (interface{}, error) {
+
+	var ret interface{}
+	var found bool
+	var err error
+
+	stream.rewind(1)
+	literal := readNumberLiteral(stream)
+
+	base, name := findNumberBase(literal)
+	if base != 10 {
+
+		digits, problem := checkDigits(literal[2:], base, name)
+		if problem != "" {
+			return nil, newParseError(start, stream.position, "Unable to parse %s value '%v', %s", name, literal, problem).causedBy(newSyntaxError("ParseUint", literal))
+		}
+
+		value, err := strconv.ParseUint(digits, base, 64)
+		if err != nil {
+			return nil, newParseError(start, stream.position, "Unable to parse %s value '%v' to uint64", name, literal).causedBy(err)
+		}
+
+		if options.Numbers == NUMBERS_FLOAT {
+			return float64(value), nil
+		}
+		return options.Numbers.sanitize(value), nil
+	}
+
+	number, problem := checkDecimalLiteral(literal)
+	if problem != "" {
+		return nil, newParseError(start, stream.position, "Unable to parse numeric value '%v', %s", literal, problem).causedBy(newSyntaxError("ParseFloat", literal))
+	}
+
+	if options.Numbers == NUMBERS_INTEGER {
+		ret, found = parseInteger(number)
+		if found {
+			return ret, nil
+		}
+	}
+
+	if options.Numbers == NUMBERS_DECIMAL {
+
+		ret, err = ParseDecimal(number)
+		if err != nil {
+			return nil, newParseError(start, stream.position, "Unable to parse numeric value '%v' to a decimal", literal).causedBy(err)
+		}
+		return ret, nil
+	}
+
+	ret, err = strconv.ParseFloat(number, 64)
+	if err != nil {
+		return nil, newParseError(start, stream.position, "Unable to parse numeric value '%v' to float64", literal).causedBy(err)
+	}
+	return ret, nil
+}
+
+/*
+	Malformed literals are wrapped around the same error strconv would give for them, so that callers can still find it with errors.As.
+*/
+func newSyntaxError(function string, literal string) error {
+	return &strconv.NumError{Func: function, Num: literal, Err: strconv.ErrSyntax}
+}
+
+/*
+	Reads everything that could be part of a numeric literal, so that malformed ones (such as "1.2.3", or "0x12g1")
+	are reported as a whole. Letters are included, as is a sign straight after the exponent of a decimal ("1e-6").
+*/
+func readNumberLiteral(stream *lexerStream) string {
+
+	var buffer bytes.Buffer
+	var character, previous rune
+	var based bool
 
-	character = unicode.ToLower(character)
+	for stream.canRead() {
+
+		character = stream.readCharacter()
+
+		exponentSign := !based && (character == '+' || character == '-') && (previous == 'e' || previous == 'E')
+		if !isNumeric(character) && !unicode.IsLetter(character) && character != '_' && !exponentSign {
+			stream.rewind(1)
+			break
+		}
+
+		buffer.WriteRune(character)
+		previous = character
+
+		if buffer.Len() == 2 {
+			base, _ := findNumberBase(buffer.String())
+			based = base != 10
+		}
+	}
+	return buffer.String()
+}
+
+/*
+	Returns the base of a numeric literal from its prefix, and the name of that base for errors.
+*/
+func findNumberBase(literal string) (int, string) {
 
-	return unicode.IsDigit(character) ||
-		character == 'a' ||
-		character == 'b' ||
-		character == 'c' ||
-		character == 'd' ||
-		character == 'e' ||
-		character == 'f'
+	if len(literal) < 2 || literal[0] != '0' {
+		return 10, "numeric"
+	}
+
+	switch literal[1] {
+	case 'x', 'X':
+		return 16, "hex"
+	case 'b', 'B':
+		return 2, "binary"
+	case 'o', 'O':
+		return 8, "octal"
+	}
+	return 10, "numeric"
+}
+
+/*
+	Checks that [literal] is a decimal number, with at most one decimal point and exponent, and underscores only between digits.
+	Returns it without underscores, or a description of what's wrong with it.
+*/
+func checkDecimalLiteral(literal string) (string, string) {
+
+	var mantissa, exponent string
+	var problem string
+
+	mantissa = literal
+	index := strings.IndexAny(literal, "eE")
+	if index >= 0 {
+		mantissa = literal[:index]
+		exponent = strings.TrimLeft(literal[index+1:], "+-")
+	}
+
+	// the mantissa is checked first, so that whatever follows a number (such as ".Twice" after a method call) is reported
+	// as the character that doesn't belong, rather than mistaken for an exponent.
+	if strings.Count(mantissa, ".") > 1 {
+		return "", "it has more than one decimal point"
+	}
+
+	for _, part := range strings.SplitN(mantissa, ".", 2) {
+
+		// either side of the decimal point may be empty (".5", "5."), but not both.
+		if part == "" {
+			continue
+		}
+
+		_, problem = checkDigits(part, 10, "")
+		if problem != "" {
+			return "", problem
+		}
+	}
+
+	if strings.Trim(mantissa, ".") == "" {
+		return "", "it has no digits"
+	}
+
+	if index >= 0 {
+
+		if strings.ContainsAny(literal[index+1:], "eE") {
+			return "", "it has more than one exponent"
+		}
+		if strings.Contains(exponent, ".") {
+			return "", "its exponent must be a whole number"
+		}
+		if exponent == "" {
+			return "", "its exponent has no digits"
+		}
+
+		_, problem = checkDigits(exponent, 10, "")
+		if problem != "" {
+			return "", problem
+		}
+	}
+	return strings.Replace(literal, "_", "", -1), ""
+}
+
+/*
+	Checks that [digits] are all valid in [base], with underscores only between them.
+	Returns the digits without underscores, or a description of what's wrong with them.
+*/
+func checkDigits(digits string, base int, name string) (string, string) {
+
+	if digits == "" {
+		return "", "it has no digits"
+	}
+
+	valid := "0123456789abcdef"[:base]
+
+	for i, character := range digits {
+
+		if character == '_' {
+			if i == 0 || i == len(digits)-1 || digits[i-1] == '_' {
+				return "", "underscores must be between digits"
+			}
+			continue
+		}
+
+		if !strings.ContainsRune(valid, unicode.ToLower(character)) {
+
+			if name == "" {
+				return "", fmt.Sprintf("'%c' is not a digit", character)
+			}
+			return "", fmt.Sprintf("'%c' is not a valid %s digit", character, name)
+		}
+	}
+	return strings.Replace(digits, "_", "", -1), ""
+}
+
+/*
+	Parses an integer literal as an int64, or a uint64 if it's too large for an int64, or a *big.Int if it's too large for either.
+	Literals with an exponent but no decimal point (such as "1e3") are integers too, if the exponent isn't negative.
+	Returns false if it isn't a whole number, in which case it should be parsed as a float64.
+*/
+func parseInteger(tokenString string) (interface{}, bool) {
+
+	tokenString, ok := expandExponent(tokenString)
+	if !ok {
+		return nil, false
+	}
+
+	signed, err := strconv.ParseInt(tokenString, 10, 64)
+	if err == nil {
+		return signed, true
+	}
+
+	unsigned, err := strconv.ParseUint(tokenString, 10, 64)
+	if err == nil {
+		return unsigned, true
+	}
+
+	large, ok := new(big.Int).SetString(tokenString, 10)
+	if ok {
+		return large, true
+	}
+	return nil, false
+}
+
+/*
+	Writes out the exponent of a literal such as "1e3" as zeroes ("1000"). Literals without an exponent are returned as-is.
+	Returns false for literals with a decimal point or a negative exponent, which aren't written as whole numbers,
+	and for exponents giving more digits than a big number is allowed bits.
+*/
+func expandExponent(literal string) (string, bool) {
+
+	index := strings.IndexAny(literal, "eE")
+	if index < 0 {
+		return literal, !strings.Contains(literal, ".")
+	}
+	if strings.Contains(literal, ".") {
+		return "", false
+	}
+
+	exponent, err := strconv.Atoi(literal[index+1:])
+	if err != nil || exponent < 0 || exponent > maxBigBits {
+		return "", false
+	}
+	return literal[:index] + strings.Repeat("0", exponent), true
 }
 
 func isNumeric(character rune) bool {
@@ -438,7 +935,7 @@ func isNumeric(character rune) bool {
 
 func isNotQuote(character rune) bool {
 
-	return character != '\'' && character != '"'
+	return character != '\'' && character != '"' && character != '`'
 }
 
 func isNotAlphanumeric(character rune) bool {
@@ -1,6 +1,7 @@
 package govaluate
 
 import (
+	"errors"
 	"fmt"
 	"regexp/syntax"
 	"strings"
@@ -19,6 +20,16 @@ const (
 	HANGING_ACCESSOR                = "Hanging accessor on token"
 	UNEXPORTED_ACCESSOR             = "Unable to access unexported"
 	INVALID_HEX                     = "Unable to parse hex value"
+	INVALID_BINARY                  = "Unable to parse binary value"
+	INVALID_OCTAL                   = "Unable to parse octal value"
+	MULTIPLE_DECIMAL_POINTS         = "it has more than one decimal point"
+	EMPTY_EXPONENT                  = "its exponent has no digits"
+	UNEXPECTED_AFTER_CALL           = "Unable to parse numeric value '.Twice', 'T' is not a digit"
+	MISPLACED_SEPARATOR             = "underscores must be between digits"
+	INVALID_ESCAPE                  = "Invalid escape sequence"
+	UNCLOSED_COMMENT                = "Unclosed block comment"
+	INVALID_PRESENCE_TEST           = "can only test a single parameter or accessor"
+	UNBALANCED_BRACKETS             = "Unbalanced brackets"
 )
 
 /*
@@ -188,7 +199,7 @@ func TestParsingFailure(test *testing.T) {
 		ParsingFailureTest{
 			Name:     "Incomplete Hex",
 			Input:    "0x",
-			Expected: INVALID_TOKEN_TRANSITION,
+			Expected: INVALID_HEX,
 		},
 		ParsingFailureTest{
 			Name:     "Invalid Hex literal",
@@ -198,18 +209,186 @@ func TestParsingFailure(test *testing.T) {
 		ParsingFailureTest{
 			Name:     "Hex float (Unsupported)",
 			Input:    "0x1.1",
-			Expected: INVALID_TOKEN_TRANSITION,
+			Expected: INVALID_HEX,
 		},
 		ParsingFailureTest{
 			Name:     "Hex invalid letter",
 			Input:    "0x12g1",
+			Expected: INVALID_HEX,
+		},
+		ParsingFailureTest{
+			Name:     "Multiple decimal points",
+			Input:    "1.2.3",
+			Expected: MULTIPLE_DECIMAL_POINTS,
+		},
+		ParsingFailureTest{
+			Name:     "Empty exponent",
+			Input:    "1e + 2",
+			Expected: EMPTY_EXPONENT,
+		},
+		ParsingFailureTest{
+			Name:     "Accessor after a method call",
+			Input:    "s.Inner().Twice()",
+			Expected: UNEXPECTED_AFTER_CALL,
+		},
+		ParsingFailureTest{
+			Name:     "Fractional exponent",
+			Input:    "1e1.5",
+			Expected: INVALID_NUMERIC,
+		},
+		ParsingFailureTest{
+			Name:     "Invalid binary digit",
+			Input:    "0b102",
+			Expected: INVALID_BINARY,
+		},
+		ParsingFailureTest{
+			Name:     "Invalid octal digit",
+			Input:    "0o78",
+			Expected: INVALID_OCTAL,
+		},
+		ParsingFailureTest{
+			Name:     "Doubled digit separator",
+			Input:    "1__000",
+			Expected: MISPLACED_SEPARATOR,
+		},
+		ParsingFailureTest{
+			Name:     "Trailing digit separator",
+			Input:    "1_.5",
+			Expected: MISPLACED_SEPARATOR,
+		},
+		ParsingFailureTest{
+			Name:     "Unknown escape sequence",
+			Input:    "'\\d+'",
+			Expected: INVALID_ESCAPE,
+		},
+		ParsingFailureTest{
+			Name:     "Short unicode escape",
+			Input:    "'\\u12'",
+			Expected: INVALID_ESCAPE,
+		},
+		ParsingFailureTest{
+			Name:     "Escaped closing quote",
+			Input:    "'foo\\'",
+			Expected: UNCLOSED_QUOTES,
+		},
+		ParsingFailureTest{
+			Name:     "Unclosed raw string",
+			Input:    "`foo' == 'foo'",
+			Expected: UNCLOSED_QUOTES,
+		},
+		ParsingFailureTest{
+			Name:     "Unclosed block comment",
+			Input:    "1 + 2 /* three",
+			Expected: UNCLOSED_COMMENT,
+		},
+		ParsingFailureTest{
+			Name:     "Presence test of an expression",
+			Input:    "exists(x + 1)",
+			Expected: INVALID_PRESENCE_TEST,
+		},
+		ParsingFailureTest{
+			Name:     "Presence test of nothing",
+			Input:    "has()",
+			Expected: INVALID_PRESENCE_TEST,
+		},
+		ParsingFailureTest{
+			Name:     "Unclosed index",
+			Input:    "items[0",
+			Expected: UNBALANCED_BRACKETS,
+		},
+		ParsingFailureTest{
+			Name:     "Unopened index",
+			Input:    "items]",
+			Expected: UNBALANCED_BRACKETS,
+		},
+		ParsingFailureTest{
+			Name:     "Empty index",
+			Input:    "items[]",
 			Expected: INVALID_TOKEN_TRANSITION,
 		},
+		ParsingFailureTest{
+			Name:     "Comment in place of operand",
+			Input:    "1 + // two",
+			Expected: UNEXPECTED_END,
+		},
+		ParsingFailureTest{
+			Name:     "Letters in number",
+			Input:    "12ab",
+			Expected: INVALID_NUMERIC,
+		},
 	}
 
 	runParsingFailureTests(parsingTests, test)
 }
 
+/*
+	Regex patterns written with single backslashes used to parse (with the backslashes dropped), and now must fail where the escape is,
+	while the raw and doubled ways of writing them match as intended.
+*/
+func TestInvalidEscapeSequence(test *testing.T) {
+
+	var parseError *ParseError
+
+	_, err := NewEvaluableExpression("name =~ '\\d+'")
+
+	if !errors.As(err, &parseError) {
+		test.Logf("Expected a *ParseError, got %v", err)
+		test.Fail()
+		return
+	}
+
+	if parseError.Message != "Invalid escape sequence '\\d' in string literal, write '\\\\d' for a backslash, or use a raw string" || parseError.Start != 9 || parseError.End != 11 {
+		test.Logf("Unexpected error %+v", parseError)
+		test.Fail()
+	}
+
+	for _, input := range []string{"name =~ `\\d+`", "name =~ '\\\\d+'"} {
+
+		expression, err := NewEvaluableExpression(input)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %v", input, err)
+			test.Fail()
+			continue
+		}
+
+		result, err := expression.Evaluate(map[string]interface{}{"name": "42"})
+		if result != true || err != nil {
+			test.Logf("Expected '%s' to match, got %v (%v)", input, result, err)
+			test.Fail()
+		}
+	}
+}
+
+/*
+	With `LegacyEscapes`, a backslash keeps whatever follows it as-is, even where that is a Go escape.
+*/
+func TestLegacyEscapes(test *testing.T) {
+
+	expected := map[string]string{
+		"'\\d+'":   "d+",
+		"'\\n'":    "n",
+		"'a\\\\b'": "a\\b",
+		"'it\\'s'": "it's",
+		"`\\d+`":   "\\d+",
+	}
+
+	for input, value := range expected {
+
+		expression, err := NewEvaluableExpressionWithOptions(input, ParseOptions{LegacyEscapes: true})
+		if err != nil {
+			test.Logf("Failed to parse '%s': %v", input, err)
+			test.Fail()
+			continue
+		}
+
+		result, err := expression.Evaluate(nil)
+		if result != value || err != nil {
+			test.Logf("Expected '%s' to be '%s', got '%v' (%v)", input, value, result, err)
+			test.Fail()
+		}
+	}
+}
+
 func runParsingFailureTests(parsingTests []ParsingFailureTest, test *testing.T) {
 
 	var err error
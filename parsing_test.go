@@ -2,8 +2,10 @@ package govaluate
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 	"unicode"
@@ -96,6 +98,86 @@ func TestConstantParsing(test *testing.T) {
 				},
 			},
 		},
+		TokenParsingTest{
+			Name:  "Exponent",
+			Input: "6.02e23",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 6.02e23,
+				},
+			},
+		},
+		TokenParsingTest{
+			Name:  "Negative exponent",
+			Input: "1E-6",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 0.000001,
+				},
+			},
+		},
+		TokenParsingTest{
+			Name:  "Exponent with sign",
+			Input: "2.5e+3",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 2500.0,
+				},
+			},
+		},
+		TokenParsingTest{
+			Name:  "Binary",
+			Input: "0b1010",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 10.0,
+				},
+			},
+		},
+		TokenParsingTest{
+			Name:  "Octal",
+			Input: "0O755",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 493.0,
+				},
+			},
+		},
+		TokenParsingTest{
+			Name:  "Digit separators",
+			Input: "1_000_000",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 1000000.0,
+				},
+			},
+		},
+		TokenParsingTest{
+			Name:  "Digit separators in a fraction",
+			Input: "1_000.000_5",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 1000.0005,
+				},
+			},
+		},
+		TokenParsingTest{
+			Name:  "Hex with digit separators",
+			Input: "0xFF_FF",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 65535.0,
+				},
+			},
+		},
 		TokenParsingTest{
 
 			Name:  "Single string",
@@ -1371,11 +1453,311 @@ func TestEscapedParameters(test *testing.T) {
 				},
 			},
 		},
+		TokenParsingTest{
+
+			Name:  "String literal escapes",
+			Input: "'line\\nnext\\ttab\\\\'",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  STRING,
+					Value: "line\nnext\ttab\\",
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "String literal unicode escapes",
+			Input: "'caf\\u00e9 \\U0001F600 \\x41\\101'",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  STRING,
+					Value: "café 😀 AA",
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "String literal contains other quote",
+			Input: "'say \"hi\"'",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  STRING,
+					Value: "say \"hi\"",
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Raw string literal",
+			Input: "`^\\d+\\.\\d*$`",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  STRING,
+					Value: "^\\d+\\.\\d*$",
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Raw string literal spans lines",
+			Input: "`one\ntwo`",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  STRING,
+					Value: "one\ntwo",
+				},
+			},
+		},
+	}
+
+	runTokenParsingTest(testCases, test)
+}
+
+func TestCommentParsing(test *testing.T) {
+
+	testCases := []TokenParsingTest{
+
+		TokenParsingTest{
+
+			Name:  "Line comment",
+			Input: "1 // one",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 1.0,
+				},
+				ExpressionToken{
+					Kind:  COMMENT,
+					Value: " one",
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Line comment ends at newline",
+			Input: "1 // one\n+ 2",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 1.0,
+				},
+				ExpressionToken{
+					Kind:  COMMENT,
+					Value: " one",
+				},
+				ExpressionToken{
+					Kind:  MODIFIER,
+					Value: "+",
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 2.0,
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Block comment",
+			Input: "/* first */ 1 /*\nsecond\n*/",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  COMMENT,
+					Value: " first ",
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 1.0,
+				},
+				ExpressionToken{
+					Kind:  COMMENT,
+					Value: "\nsecond\n",
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Comment before prefix",
+			Input: "1 - /* negative */ -2",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 1.0,
+				},
+				ExpressionToken{
+					Kind:  MODIFIER,
+					Value: "-",
+				},
+				ExpressionToken{
+					Kind:  COMMENT,
+					Value: " negative ",
+				},
+				ExpressionToken{
+					Kind:  PREFIX,
+					Value: "-",
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 2.0,
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Comment markers in strings",
+			Input: "'http://example.com/*' // url",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  STRING,
+					Value: "http://example.com/*",
+				},
+				ExpressionToken{
+					Kind:  COMMENT,
+					Value: " url",
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Division is not a comment",
+			Input: "4 / 2",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 4.0,
+				},
+				ExpressionToken{
+					Kind:  MODIFIER,
+					Value: "/",
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 2.0,
+				},
+			},
+		},
 	}
 
 	runTokenParsingTest(testCases, test)
 }
 
+/*
+	Comments must keep their positions, and have no effect on what an expression does.
+*/
+func TestCommentEvaluation(test *testing.T) {
+
+	expressionString := "total > 100 // large orders\n" +
+		"&& status =~ /* pattern */ '^ok' ? /* yes */ 'ship' : 'hold'"
+
+	expression, err := NewEvaluableExpression(expressionString)
+	if err != nil {
+		test.Logf("Failed to parse: %v", err)
+		test.Fail()
+		return
+	}
+
+	result, err := expression.Evaluate(map[string]interface{}{"total": 150, "status": "ok"})
+	if err != nil || result != "ship" {
+		test.Logf("Expected 'ship', got %v (%v)", result, err)
+		test.Fail()
+	}
+
+	var comments []ExpressionToken
+	for _, token := range expression.Tokens() {
+		if token.Kind == COMMENT {
+			comments = append(comments, token)
+		}
+	}
+
+	if len(comments) != 3 {
+		test.Logf("Expected 3 comments, got %v", comments)
+		test.Fail()
+		return
+	}
+
+	for _, comment := range comments {
+
+		text := expressionString[comment.Start:comment.End]
+		if !strings.Contains(text, comment.Value.(string)) || !strings.HasPrefix(text, "/") {
+			test.Logf("Comment %q is at the wrong position, found %q", comment.Value, text)
+			test.Fail()
+		}
+	}
+
+	if expression.AST().String() != "((total > 100) && (status =~ '^ok')) ? 'ship' : 'hold'" {
+		test.Logf("Unexpected rendering '%s'", expression.AST().String())
+		test.Fail()
+	}
+
+	filter, _ := NewEvaluableExpression("total > 100 /* large orders */ && status == 'ok'")
+	query, err := filter.ToSQLQuery()
+	if err != nil || query != "[total] > 100 AND [status] = 'ok'" {
+		test.Logf("Expected comments to be left out of SQL, got '%s' (%v)", query, err)
+		test.Fail()
+	}
+
+	rebuilt, err := NewEvaluableExpressionFromTokens(filter.Tokens())
+	if err != nil {
+		test.Logf("Failed to build from tokens: %v", err)
+		test.Fail()
+		return
+	}
+
+	result, err = rebuilt.Evaluate(map[string]interface{}{"total": 150, "status": "ok"})
+	if err != nil || result != true {
+		test.Logf("Expected true, got %v (%v)", result, err)
+		test.Fail()
+	}
+
+	_, err = NewEvaluableExpressionWithOptions("1 /* one */ + /* two */ 2", ParseOptions{MaxTokens: 3})
+	if err != nil {
+		test.Logf("Expected comments not to count as tokens, got %v", err)
+		test.Fail()
+	}
+}
+
+/*
+	Expressions with only comments (or whitespace) in them are empty, and evaluate to nil wherever they're used.
+*/
+func TestEmptyExpression(test *testing.T) {
+
+	for _, input := range []string{"", " \n", "// only", "/* x */", "/* a */ // b\n"} {
+
+		expression, err := NewEvaluableExpression(input)
+		if err != nil {
+			test.Logf("Failed to parse %q: %v", input, err)
+			test.Fail()
+			continue
+		}
+
+		result, err := expression.Evaluate(nil)
+		if result != nil || err != nil {
+			test.Logf("Expected %q to evaluate to nil, got %v (%v)", input, result, err)
+			test.Fail()
+		}
+
+		result, err = expression.Program().Evaluate(nil)
+		if result != nil || err != nil {
+			test.Logf("Expected the program for %q to evaluate to nil, got %v (%v)", input, result, err)
+			test.Fail()
+		}
+
+		query, err := expression.ToSQLQuery()
+		if query != "" || err != nil || expression.AST() != nil {
+			test.Logf("Expected %q to have no SQL or AST, got '%s' (%v)", input, query, err)
+			test.Fail()
+		}
+	}
+
+	// a comment in place of an operand is still missing that operand, and says where.
+	var parseError *ParseError
+
+	_, err := NewEvaluableExpression("1 + // two")
+	if !errors.As(err, &parseError) || parseError.Start != 3 || parseError.Line != 1 {
+		test.Logf("Expected a located *ParseError, got %v", err)
+		test.Fail()
+	}
+}
+
 func TestTernaryParsing(test *testing.T) {
 	tokenParsingTests := []TokenParsingTest{
 
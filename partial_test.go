@@ -0,0 +1,243 @@
+package govaluate
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+/*
+	Represents a test of partially evaluating an expression, and then evaluating what remains.
+*/
+type PartialTest struct {
+	Name      string
+	Input     string
+	Known     map[string]interface{}
+	Unknown   map[string]interface{}
+	Residual  string
+	Vars      []string
+	Expected  interface{}
+	Functions map[string]ExpressionFunction
+}
+
+func TestPartialEvaluation(test *testing.T) {
+
+	partialTests := []PartialTest{
+
+		PartialTest{
+
+			Name:     "Known operands folded",
+			Input:    "amount > limit * 2",
+			Known:    map[string]interface{}{"limit": 50},
+			Unknown:  map[string]interface{}{"amount": 150},
+			Residual: "amount > 100",
+			Vars:     []string{"amount"},
+			Expected: true,
+		},
+		PartialTest{
+
+			Name:     "And short-circuited",
+			Input:    "tier == 'gold' && amount > 100",
+			Known:    map[string]interface{}{"tier": "silver"},
+			Residual: "false",
+			Expected: false,
+		},
+		PartialTest{
+
+			Name:     "And kept when left is true",
+			Input:    "tier == 'gold' && amount > 100",
+			Known:    map[string]interface{}{"tier": "gold"},
+			Unknown:  map[string]interface{}{"amount": 50},
+			Residual: "true && (amount > 100)",
+			Vars:     []string{"amount"},
+			Expected: false,
+		},
+		PartialTest{
+
+			Name:     "Or short-circuited through parenthesis",
+			Input:    "(admin) || owner == user",
+			Known:    map[string]interface{}{"admin": true},
+			Residual: "true",
+			Expected: true,
+		},
+		PartialTest{
+
+			Name:     "Coalesce known",
+			Input:    "override ?? fallback",
+			Known:    map[string]interface{}{"override": "x"},
+			Residual: "'x'",
+			Expected: "x",
+		},
+		PartialTest{
+
+			Name:     "Coalesce of nil",
+			Input:    "override ?? fallback",
+			Known:    map[string]interface{}{"override": nil},
+			Unknown:  map[string]interface{}{"fallback": "y"},
+			Residual: "fallback",
+			Vars:     []string{"fallback"},
+			Expected: "y",
+		},
+		PartialTest{
+
+			Name:     "Ternary false",
+			Input:    "premium ? price * 0.5 : price",
+			Known:    map[string]interface{}{"premium": false},
+			Unknown:  map[string]interface{}{"price": 10},
+			Residual: "price",
+			Vars:     []string{"price"},
+			Expected: 10.0,
+		},
+		PartialTest{
+
+			Name:     "Ternary true",
+			Input:    "premium ? 'half' : price",
+			Known:    map[string]interface{}{"premium": true},
+			Residual: "'half'",
+			Expected: "half",
+		},
+		PartialTest{
+
+			Name:     "Membership in known array",
+			Input:    "country in ('CN', region) && age > 18",
+			Known:    map[string]interface{}{"country": "US", "region": "US"},
+			Unknown:  map[string]interface{}{"age": 20},
+			Residual: "true && (age > 18)",
+			Vars:     []string{"age"},
+			Expected: true,
+		},
+		PartialTest{
+
+			Name:     "Membership in partially known array",
+			Input:    "country in ('CN', region)",
+			Known:    map[string]interface{}{"country": "US"},
+			Unknown:  map[string]interface{}{"region": "US"},
+			Residual: "'US' in ('CN', region)",
+			Vars:     []string{"region"},
+			Expected: true,
+		},
+		PartialTest{
+
+			Name:     "Accessor fields folded",
+			Input:    "foo.Int + bar",
+			Known:    map[string]interface{}{"foo": dummyParameterInstance},
+			Unknown:  map[string]interface{}{"bar": 1},
+			Residual: "101 + bar",
+			Vars:     []string{"bar"},
+			Expected: 102.0,
+		},
+		PartialTest{
+
+			Name:  "Functions not called",
+			Input: "count(a + 1) > 1",
+			Functions: map[string]ExpressionFunction{
+				"count": func(arguments ...interface{}) (interface{}, error) {
+					return arguments[0], nil
+				},
+			},
+			Known:    map[string]interface{}{"a": 1},
+			Residual: "count(2) > 1",
+			Expected: true,
+		},
+		PartialTest{
+
+			Name:     "Failing operators kept",
+			Input:    "ok || name > 1",
+			Known:    map[string]interface{}{"name": "x"},
+			Unknown:  map[string]interface{}{"ok": true},
+			Residual: "ok || ('x' > 1)",
+			Vars:     []string{"ok"},
+			Expected: true,
+		},
+		PartialTest{
+
+			Name:     "Method receiver needed again",
+			Input:    "foo.Func() + bar",
+			Known:    map[string]interface{}{"foo": dummyParameterInstance},
+			Unknown:  map[string]interface{}{"foo": dummyParameterInstance, "bar": "!"},
+			Residual: "foo.Func() + bar",
+			Vars:     []string{"foo", "bar"},
+			Expected: "funk!",
+		},
+		PartialTest{
+
+			Name:     "Non-finite results kept",
+			Input:    "1 / x + y",
+			Known:    map[string]interface{}{"x": 0},
+			Unknown:  map[string]interface{}{"y": 1},
+			Residual: "(1 / 0) + y",
+			Vars:     []string{"y"},
+			Expected: math.Inf(1),
+		},
+	}
+
+	fmt.Printf("Running %d partial evaluation test cases...\n", len(partialTests))
+
+	for _, partialTest := range partialTests {
+
+		expression, err := NewEvaluableExpressionWithFunctions(partialTest.Input, partialTest.Functions)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %s", partialTest.Name, err)
+			test.Fail()
+			continue
+		}
+
+		residual := expression.PartialEval(MapParameters(partialTest.Known))
+
+		if residual.String() != partialTest.Residual {
+			test.Logf("Test '%s' failed", partialTest.Name)
+			test.Logf("Expected residual '%s', got '%s'", partialTest.Residual, residual.String())
+			test.Fail()
+		}
+
+		if !reflect.DeepEqual(residual.Vars(), partialTest.Vars) {
+			test.Logf("Test '%s' failed", partialTest.Name)
+			test.Logf("Expected residual variables %v, got %v", partialTest.Vars, residual.Vars())
+			test.Fail()
+		}
+
+		result, err := residual.Evaluate(partialTest.Unknown)
+		if err != nil || result != partialTest.Expected {
+			test.Logf("Test '%s' failed", partialTest.Name)
+			test.Logf("Expected residual to evaluate to '%v', got '%v' (%v)", partialTest.Expected, result, err)
+			test.Fail()
+		}
+
+		// the residual's text must parse back into an expression which gives the same result.
+		reparsed, err := NewEvaluableExpressionWithFunctions(residual.String(), partialTest.Functions)
+		if err != nil {
+			test.Logf("Test '%s' failed", partialTest.Name)
+			test.Logf("Failed to parse residual '%s': %v", residual.String(), err)
+			test.Fail()
+			continue
+		}
+
+		result, err = reparsed.Evaluate(partialTest.Unknown)
+		if err != nil || result != partialTest.Expected {
+			test.Logf("Test '%s' failed", partialTest.Name)
+			test.Logf("Expected reparsed residual to evaluate to '%v', got '%v' (%v)", partialTest.Expected, result, err)
+			test.Fail()
+		}
+	}
+}
+
+func TestPartialEvaluationUnchanged(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("a + b > 2")
+
+	residual := expression.PartialEval(nil)
+	if residual.String() != "(a + b) > 2" {
+		test.Logf("Expected partial evaluation with no parameters to keep the expression, got '%s'", residual.String())
+		test.Fail()
+	}
+
+	// the original must be untouched.
+	expression.PartialEval(MapParameters(map[string]interface{}{"a": 1, "b": 2}))
+
+	result, err := expression.Evaluate(map[string]interface{}{"a": 0, "b": 0})
+	if err != nil || result != false {
+		test.Logf("Expected original expression to be unaffected by partial evaluation, got '%v' (%v)", result, err)
+		test.Fail()
+	}
+}
@@ -0,0 +1,149 @@
+package govaluate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+/*
+	Programs, and programs which were saved and loaded again, must behave exactly like the planned stages they were compiled from.
+*/
+func TestProgram(test *testing.T) {
+
+	for _, input := range backendInputs {
+
+		expression, err := NewEvaluableExpressionWithFunctions(input, backendFunctions)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %s", input, err)
+			test.Fail()
+			continue
+		}
+
+		planned := *expression
+		planned.compiledStages = nil
+		plannedResult, plannedErr := planned.Evaluate(backendParameters)
+
+		program := expression.Program()
+
+		encoded, err := json.Marshal(program)
+		if err != nil {
+			test.Logf("Failed to encode program of '%s': %s", input, err)
+			test.Fail()
+			continue
+		}
+
+		loaded, err := LoadProgram(encoded, ParseOptions{Functions: backendFunctions})
+		if err != nil {
+			test.Logf("Failed to load program of '%s': %s", input, err)
+			test.Fail()
+			continue
+		}
+
+		for _, candidate := range []*Program{program, loaded} {
+
+			result, err := candidate.Evaluate(backendParameters)
+
+			if !reflect.DeepEqual(result, plannedResult) || !reflect.DeepEqual(err, plannedErr) {
+				test.Logf("Program evaluation of '%s' differs", input)
+				test.Logf("Expected '%v' (%v), got '%v' (%v)", plannedResult, plannedErr, result, err)
+				test.Fail()
+			}
+		}
+	}
+}
+
+func TestProgramLimits(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("text + text + text")
+
+	program := expression.Program()
+	program.Limits = EvaluationLimits{MaxStringLength: 8}
+
+	_, err := program.Evaluate(map[string]interface{}{"text": "abc"})
+
+	var budgetError *BudgetExceededError
+	if !errors.As(err, &budgetError) || budgetError.Budget != BUDGET_STRING_LENGTH {
+		test.Logf("Expected program to exceed its string length budget, got '%v'", err)
+		test.Fail()
+	}
+
+	program.Limits = EvaluationLimits{MaxStages: 4}
+
+	_, err = program.Evaluate(map[string]interface{}{"text": "abc"})
+	if !errors.As(err, &budgetError) || budgetError.Budget != BUDGET_STAGES {
+		test.Logf("Expected program to exceed its stage budget, got '%v'", err)
+		test.Fail()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = expression.Program().EvalContext(ctx, MapParameters(map[string]interface{}{"text": "abc"}))
+	if !errors.Is(err, context.Canceled) {
+		test.Logf("Expected cancelled program to return context.Canceled, got '%v'", err)
+		test.Fail()
+	}
+}
+
+func TestProgramConcurrency(test *testing.T) {
+
+	var group sync.WaitGroup
+
+	expression, _ := NewEvaluableExpression("(a * 2 > 10 && b in ('x', 'y')) ?? false")
+	program := expression.Program()
+
+	for i := 0; i < 8; i++ {
+
+		group.Add(1)
+		go func(a int) {
+
+			defer group.Done()
+
+			for j := 0; j < 100; j++ {
+
+				result, err := program.Evaluate(map[string]interface{}{"a": a, "b": "x"})
+				if err != nil || result != (a*2 > 10) {
+					test.Logf("Expected concurrent evaluation to return %v, got '%v' (%v)", a*2 > 10, result, err)
+					test.Fail()
+					return
+				}
+			}
+		}(i)
+	}
+	group.Wait()
+}
+
+func TestLoadProgramFailure(test *testing.T) {
+
+	expression, _ := NewEvaluableExpressionWithFunctions("args(1) + 1", backendFunctions)
+	encoded, _ := json.Marshal(expression.Program())
+
+	_, err := LoadProgram(encoded, ParseOptions{})
+
+	var undefined *UndefinedFunctionError
+	if !errors.As(err, &undefined) || undefined.Name != "args" {
+		test.Logf("Expected loading without functions to fail with an *UndefinedFunctionError, got '%v'", err)
+		test.Fail()
+	}
+
+	malformed := []string{
+		`{"version":2,"instructions":[],"constants":[]}`,
+		`{"version":1,"instructions":[{"op":5,"symbol":14}],"constants":[]}`,
+		`{"version":1,"instructions":[{"op":0,"arg":3}],"constants":[{}]}`,
+		`{"version":1,"instructions":[{"op":0},{"op":7,"symbol":12}],"constants":[{"bool":true}]}`,
+		`{"version":1,"instructions":[{"op":2,"arg":1,"path":["x","Y"]},{"op":0,"arg":0}],"constants":[{"number":"1"}]}`,
+	}
+
+	for _, data := range malformed {
+
+		_, err = LoadProgram([]byte(data), ParseOptions{})
+		if err == nil {
+			test.Logf("Expected malformed program '%s' to fail to load", data)
+			test.Fail()
+		}
+	}
+}
@@ -3,7 +3,8 @@ package govaluate
 // sanitizedParameters is a wrapper for Parameters that does sanitization as
 // parameters are accessed.
 type sanitizedParameters struct {
-	orig Parameters
+	orig    Parameters
+	numbers NumericMode
 }
 
 func (p sanitizedParameters) Get(key string) (interface{}, error) {
@@ -12,7 +13,19 @@ func (p sanitizedParameters) Get(key string) (interface{}, error) {
 		return nil, err
 	}
 
-	return castToFloat64(value), nil
+	return p.numbers.sanitize(value), nil
+}
+
+/*
+	Wraps [parameters] so that the numbers they hold are converted to the types [numbers] uses.
+	Nil parameters are replaced with an empty set.
+*/
+func sanitizeParameters(parameters Parameters, numbers NumericMode) Parameters {
+
+	if parameters == nil {
+		return DUMMY_PARAMETERS
+	}
+	return &sanitizedParameters{parameters, numbers}
 }
 
 func castToFloat64(value interface{}) interface{} {
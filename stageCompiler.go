@@ -0,0 +1,403 @@
+package govaluate
+
+/*
+	A planned stage (and everything beneath it), compiled into a single function which evaluates it.
+	Compiled stages do the same work as `evaluateStage`, but decide at compile time what each stage needs,
+	rather than re-discovering it every evaluation - which operands are constant, which type checks can never fail,
+	and whether an operator has a faster path for the types it's usually given.
+*/
+type compiledStage func(state *evaluationState) (interface{}, error)
+
+/*
+	Compiles the given planned stage tree. Returns nil if there is nothing to compile.
+*/
+func compileStages(stage *evaluationStage) compiledStage {
+
+	if stage == nil {
+		return nil
+	}
+	return compileStage(stage)
+}
+
+func compileStage(stage *evaluationStage) compiledStage {
+
+	switch stage.symbol {
+
+	case LITERAL:
+		value, _, _, _ := stage.operator(nil, nil, nil, nil, nil)
+		return func(state *evaluationState) (interface{}, error) {
+			return value, nil
+		}
+
+	case VALUE:
+		return compileParameter(stage)
+
+	case NOOP:
+		if stage.rightStage == nil {
+			return func(state *evaluationState) (interface{}, error) {
+				return nil, nil
+			}
+		}
+		return compileStage(stage.rightStage)
+
+	case SEPARATE:
+		return compileSeparator(stage)
+
+	case AND, OR:
+		return compileLogical(stage)
+
+	case TERNARY_TRUE:
+		return compileTernaryTrue(stage)
+
+	case TERNARY_FALSE, COALESCE:
+		return compileTernaryFalse(stage)
+
+	case IN:
+		return compileMembership(stage)
+
+	case MINUS, MULTIPLY, DIVIDE, MODULUS, EXPONENT, PLUS, GT, LT, GTE, LTE, EQ, NEQ:
+		return compileArithmetic(stage)
+	}
+
+	return compileOperator(stage)
+}
+
+func compileParameter(stage *evaluationStage) compiledStage {
+
+	name := stage.name
+	return func(state *evaluationState) (interface{}, error) {
+
+		value, err := state.Get(name)
+		if err != nil {
+			return nil, locateEvaluationError(err, stage)
+		}
+		return value, nil
+	}
+}
+
+/*
+	Separators are planned as a chain of stages, each appending to the array of the one before it.
+	Compiled, the whole chain builds its array at once.
+*/
+func compileSeparator(stage *evaluationStage) compiledStage {
+
+	var elements []compiledStage
+
+	for ; stage != nil && stage.symbol == SEPARATE; stage = stage.leftStage {
+		elements = append(elements, compileOperand(stage.rightStage))
+	}
+	elements = append(elements, compileOperand(stage))
+
+	// gathered from the end of the chain, backwards.
+	for i, j := 0, len(elements)-1; i < j; i, j = i+1, j-1 {
+		elements[i], elements[j] = elements[j], elements[i]
+	}
+
+	return func(state *evaluationState) (interface{}, error) {
+
+		ret := make([]interface{}, len(elements))
+		for i, element := range elements {
+
+			value, err := element(state)
+			if err != nil {
+				return nil, err
+			}
+			ret[i] = value
+		}
+		return ret, nil
+	}
+}
+
+func compileLogical(stage *evaluationStage) compiledStage {
+
+	var shortCircuit interface{}
+
+	left := compileOperand(stage.leftStage)
+	right := compileOperand(stage.rightStage)
+	check := compileTypeChecks(stage)
+
+	// "false && x" is always false, and "true || x" always true, without evaluating x.
+	shortCircuit = stage.symbol == OR
+
+	return func(state *evaluationState) (interface{}, error) {
+
+		leftValue, err := left(state)
+		if err != nil {
+			return nil, err
+		}
+
+		if leftValue == shortCircuit {
+			return shortCircuit, nil
+		}
+
+		rightValue, err := right(state)
+		if err != nil {
+			return nil, err
+		}
+
+		return runOperator(stage, check, leftValue, rightValue, state)
+	}
+}
+
+func compileTernaryTrue(stage *evaluationStage) compiledStage {
+
+	left := compileOperand(stage.leftStage)
+	right := compileOperand(stage.rightStage)
+	check := compileTypeChecks(stage)
+
+	return func(state *evaluationState) (interface{}, error) {
+
+		leftValue, err := left(state)
+		if err != nil {
+			return nil, err
+		}
+
+		if leftValue == false {
+			return nil, nil
+		}
+
+		rightValue, err := right(state)
+		if err != nil {
+			return nil, err
+		}
+
+		return runOperator(stage, check, leftValue, rightValue, state)
+	}
+}
+
+/*
+	Both ":" and "??" return their left side if it is not nil, and otherwise their right side.
+*/
+func compileTernaryFalse(stage *evaluationStage) compiledStage {
+
+	left := compileOperand(stage.leftStage)
+	right := compileOperand(stage.rightStage)
+
+	return func(state *evaluationState) (interface{}, error) {
+
+		leftValue, err := left(state)
+		if err != nil {
+			return nil, err
+		}
+
+		if leftValue != nil {
+			return leftValue, nil
+		}
+		return right(state)
+	}
+}
+
+/*
+	Arithmetic and comparison operators are nearly always given two float64s, for which they can skip their type checks and conversions.
+	Anything else is given to the stage's operator, same as `evaluateStage` would.
+*/
+func compileArithmetic(stage *evaluationStage) compiledStage {
+
+	var constant float64
+	var hasConstant bool
+
+	left := compileOperand(stage.leftStage)
+	right := compileOperand(stage.rightStage)
+	check := compileTypeChecks(stage)
+	calculate := findFloatOperator(stage.symbol)
+
+	// a constant right side (such as "x > 90") doesn't need to be evaluated or unboxed.
+	if stage.rightStage != nil && stage.rightStage.symbol == LITERAL {
+		constantValue, _, _, _ := stage.rightStage.operator(nil, nil, nil, nil, nil)
+		constant, hasConstant = constantValue.(float64)
+	}
+
+	if hasConstant {
+
+		rightValue := interface{}(constant)
+
+		return func(state *evaluationState) (interface{}, error) {
+
+			leftValue, err := left(state)
+			if err != nil {
+				return nil, err
+			}
+
+			leftFloat, ok := leftValue.(float64)
+			if ok {
+				return calculate(leftFloat, constant), nil
+			}
+			return runOperator(stage, check, leftValue, rightValue, state)
+		}
+	}
+
+	return func(state *evaluationState) (interface{}, error) {
+
+		leftValue, err := left(state)
+		if err != nil {
+			return nil, err
+		}
+
+		rightValue, err := right(state)
+		if err != nil {
+			return nil, err
+		}
+
+		leftFloat, ok := leftValue.(float64)
+		if ok {
+			rightFloat, ok := rightValue.(float64)
+			if ok {
+				return calculate(leftFloat, rightFloat), nil
+			}
+		}
+		return runOperator(stage, check, leftValue, rightValue, state)
+	}
+}
+
+/*
+	Arrays of constants (such as "x in ('a', 'b')") are built once, rather than on every evaluation.
+	It's safe to share them, since membership only reads the array.
+*/
+func compileMembership(stage *evaluationStage) compiledStage {
+
+	if !isConstant(stage.rightStage) {
+		return compileOperator(stage)
+	}
+
+	left := compileOperand(stage.leftStage)
+	check := compileTypeChecks(stage)
+
+	// constants never read the evaluation's state.
+	right, err := compileOperand(stage.rightStage)(nil)
+	if err != nil {
+		return compileOperator(stage)
+	}
+
+	return func(state *evaluationState) (interface{}, error) {
+
+		leftValue, err := left(state)
+		if err != nil {
+			return nil, err
+		}
+		return runOperator(stage, check, leftValue, right, state)
+	}
+}
+
+/*
+	Any other operator evaluates both of its sides, checks their types, then runs the stage's operator.
+*/
+func compileOperator(stage *evaluationStage) compiledStage {
+
+	left := compileOperand(stage.leftStage)
+	right := compileOperand(stage.rightStage)
+	check := compileTypeChecks(stage)
+
+	return func(state *evaluationState) (interface{}, error) {
+
+		leftValue, err := left(state)
+		if err != nil {
+			return nil, err
+		}
+
+		rightValue, err := right(state)
+		if err != nil {
+			return nil, err
+		}
+
+		return runOperator(stage, check, leftValue, rightValue, state)
+	}
+}
+
+/*
+	Compiles one side of an operator. Missing sides evaluate to nil.
+*/
+func compileOperand(stage *evaluationStage) compiledStage {
+
+	if stage == nil {
+		return func(state *evaluationState) (interface{}, error) {
+			return nil, nil
+		}
+	}
+	return compileStage(stage)
+}
+
+/*
+	Returns a function which checks the types of both sides of [stage], as `evaluateStage` does.
+	Checks of constant sides are run once, now, and left out if they pass. Returns nil if nothing needs to be checked.
+*/
+func compileTypeChecks(stage *evaluationStage) func(left, right interface{}) error {
+
+	if stage.typeCheck != nil {
+
+		combined := stage.typeCheck
+		return func(left, right interface{}) error {
+
+			if !combined(left, right) {
+				return newTypeMismatchError(left, stage)
+			}
+			return nil
+		}
+	}
+
+	leftCheck := stage.leftTypeCheck
+	rightCheck := stage.rightTypeCheck
+
+	if isPassingConstant(stage.leftStage, leftCheck) {
+		leftCheck = nil
+	}
+	if isPassingConstant(stage.rightStage, rightCheck) {
+		rightCheck = nil
+	}
+
+	if leftCheck == nil && rightCheck == nil {
+		return nil
+	}
+
+	return func(left, right interface{}) error {
+
+		err := typeCheck(leftCheck, left, stage)
+		if err != nil {
+			return err
+		}
+		return typeCheck(rightCheck, right, stage)
+	}
+}
+
+func isPassingConstant(stage *evaluationStage, check stageTypeCheck) bool {
+
+	if stage == nil || stage.symbol != LITERAL || check == nil {
+		return false
+	}
+
+	value, _, _, _ := stage.operator(nil, nil, nil, nil, nil)
+	return check(value)
+}
+
+func runOperator(stage *evaluationStage, check func(left, right interface{}) error, left, right interface{}, state *evaluationState) (interface{}, error) {
+
+	if check != nil && state.checksTypes {
+
+		err := check(left, right)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, _, _, err := stage.operator(left, right, nil, nil, state)
+	if err != nil {
+		return result, locateEvaluationError(err, stage)
+	}
+	return result, nil
+}
+
+/*
+	Returns the float64-only equivalent of the given arithmetic or comparison operator.
+*/
+func findFloatOperator(symbol OperatorSymbol) func(left, right float64) interface{} {
+
+	calculate := findArithmeticOperator(symbol)
+	if calculate != nil {
+		return func(left, right float64) interface{} { return calculate(left, right) }
+	}
+
+	compare := findComparator(symbol)
+	if compare != nil {
+		return func(left, right float64) interface{} { return boolIface(compare(left, right)) }
+	}
+	return nil
+}
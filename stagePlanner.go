@@ -1,8 +1,6 @@
 package govaluate
 
 import (
-	"errors"
-	"fmt"
 	"time"
 )
 
@@ -36,6 +34,7 @@ var stageSymbolMap = map[OperatorSymbol]evaluationOperator{
 	TERNARY_FALSE:  ternaryElseStage,
 	COALESCE:       ternaryElseStage,
 	SEPARATE:       separatorStage,
+	INDEX:          makeIndexStage(NUMBERS_FLOAT),
 }
 
 /*
@@ -81,13 +80,13 @@ func init() {
 		validSymbols:    prefixSymbols,
 		validKinds:      []TokenKind{PREFIX},
 		typeErrorFormat: prefixErrorFormat,
-		nextRight:       planFunction,
+		nextRight:       planIndex,
 	})
 	planExponential = makePrecedentFromPlanner(&precedencePlanner{
 		validSymbols:    exponentialSymbolsS,
 		validKinds:      []TokenKind{MODIFIER},
 		typeErrorFormat: modifierErrorFormat,
-		next:            planFunction,
+		next:            planIndex,
 	})
 	planMultiplicative = makePrecedentFromPlanner(&precedencePlanner{
 		validSymbols:    multiplicativeSymbols,
@@ -178,12 +177,12 @@ func makePrecedentFromPlanner(planner *precedencePlanner) precedent {
 	which is used to completely evaluate a set of tokens at evaluation-time.
 	The three stages of evaluation can be thought of as parsing strings to tokens, then tokens to a stage list, then evaluation with parameters.
 */
-func planStages(tokens []ExpressionToken) (*evaluationStage, error) {
+func planStages(tokens []ExpressionToken, numbers NumericMode, decimals decimalContext) (*evaluationStage, error) {
 
 	stream := newTokenStream(tokens)
 
 	stage, err := planTokens(stream)
-	if err != nil {
+	if err != nil || stage == nil {
 		return nil, err
 	}
 
@@ -191,6 +190,10 @@ func planStages(tokens []ExpressionToken) (*evaluationStage, error) {
 	// this could probably be avoided with a different planning method
 	reorderStages(stage)
 
+	if numbers != NUMBERS_FLOAT {
+		useNumericMode(stage, numbers, numbers.operators(decimals))
+	}
+
 	stage = elideLiterals(stage)
 	return stage, nil
 }
@@ -282,6 +285,8 @@ func planPrecedenceLevel(
 			rightTypeCheck:  checks.right,
 			typeCheck:       checks.combined,
 			typeErrorFormat: typeErrorFormat,
+			start:           token.Start,
+			end:             token.End,
 		}, nil
 	}
 
@@ -289,6 +294,71 @@ func planPrecedenceLevel(
 	return leftStage, nil
 }
 
+/*
+	Plans any number of indexes (such as "items[0]['name']") of the value before them, which bind more tightly than anything else.
+	Each index is a stage whose left is the value indexed, and whose right is the key it's indexed with.
+*/
+func planIndex(stream *tokenStream) (*evaluationStage, error) {
+
+	var token, closing ExpressionToken
+	var stage, key *evaluationStage
+	var err error
+
+	stage, err = planFunction(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	for stream.hasNext() {
+
+		token = stream.next()
+		if token.Kind != BRACKET {
+			stream.rewind()
+			break
+		}
+
+		key, err = planTokens(stream)
+		if err != nil {
+			return nil, err
+		}
+
+		if !stream.hasNext() {
+			return nil, newTokenError(token, "Unbalanced brackets")
+		}
+
+		closing = stream.next()
+		if closing.Kind != BRACKET_CLOSE {
+			return nil, newTokenError(closing, "Expected ']' to close the index before this")
+		}
+
+		// keys are kept in parenthesis, so that they aren't reordered with the index around them.
+		key = &evaluationStage{
+			rightStage: key,
+			operator:   noopStageRight,
+			symbol:     NOOP,
+			start:      token.Start,
+			end:        closing.End,
+		}
+
+		checks := findTypeChecks(INDEX)
+		stage = &evaluationStage{
+
+			symbol:     INDEX,
+			leftStage:  stage,
+			rightStage: key,
+			operator:   stageSymbolMap[INDEX],
+
+			leftTypeCheck:   checks.left,
+			rightTypeCheck:  checks.right,
+			typeErrorFormat: indexErrorFormat,
+			start:           token.Start,
+			end:             closing.End,
+		}
+	}
+
+	return stage, nil
+}
+
 /*
 	A special case where functions need to be of higher precedence than values, and need a special wrapped execution stage operator.
 */
@@ -296,6 +366,7 @@ func planFunction(stream *tokenStream) (*evaluationStage, error) {
 
 	var token ExpressionToken
 	var rightStage *evaluationStage
+	var operator evaluationOperator
 	var err error
 
 	token = stream.next()
@@ -305,17 +376,70 @@ func planFunction(stream *tokenStream) (*evaluationStage, error) {
 		return planAccessor(stream)
 	}
 
+	_, isPresenceTest := token.Value.(presenceTest)
+	if isPresenceTest {
+		return planPresenceTest(stream, token)
+	}
+
 	rightStage, err = planAccessor(stream)
 	if err != nil {
 		return nil, err
 	}
+	spreads := isArgumentList(rightStage)
+
+	switch function := token.Value.(type) {
+	case ExpressionFunction:
+		operator = makeFunctionStage(token.name, function, spreads)
+	case ContextExpressionFunction:
+		operator = makeContextFunctionStage(token.name, function, spreads)
+	default:
+		return nil, newTokenError(token, "Unable to plan function '%s' of type %T", token.name, token.Value)
+	}
 
 	return &evaluationStage{
 
 		symbol:          FUNCTIONAL,
 		rightStage:      rightStage,
-		operator:        makeFunctionStage(token.Value.(ExpressionFunction)),
+		operator:        operator,
 		typeErrorFormat: "Unable to run function '%v': %v",
+		name:            token.name,
+		spreads:         spreads,
+		start:           token.Start,
+		end:             token.End,
+	}, nil
+}
+
+/*
+	Plans "exists(foo)" or "has(foo.Bar)", the [function] token of which was just read.
+	The argument must be a single variable or accessor, since anything else would have to be evaluated (and could fail) to be tested.
+*/
+func planPresenceTest(stream *tokenStream, function ExpressionToken) (*evaluationStage, error) {
+
+	var path []string
+
+	// the syntax check makes sure the clause is there, and balanced.
+	stream.next()
+	argument := stream.next()
+
+	switch argument.Kind {
+	case VARIABLE:
+		path = []string{argument.Value.(string)}
+	case ACCESSOR:
+		path = argument.Value.([]string)
+	}
+
+	if path == nil || stream.next().Kind != CLAUSE_CLOSE {
+		return nil, newTokenError(function, "'%s' can only test a single parameter or accessor, such as '%s(foo.Bar)'", function.name, function.name)
+	}
+
+	return &evaluationStage{
+
+		symbol:   EXISTS,
+		operator: makePresenceStage(path),
+		name:     function.name,
+		path:     path,
+		start:    function.Start,
+		end:      function.End,
 	}, nil
 }
 
@@ -360,8 +484,11 @@ func planAccessor(stream *tokenStream) (*evaluationStage, error) {
 
 		symbol:          ACCESS,
 		rightStage:      rightStage,
-		operator:        makeAccessorStage(token.Value.([]string), isFunction),
+		operator:        makeAccessorStage(token.Value.([]string), isFunction, NUMBERS_FLOAT),
 		typeErrorFormat: "Unable to access parameter field or method '%v': %v",
+		path:            token.Value.([]string),
+		start:           token.Start,
+		end:             token.End,
 	}, nil
 }
 
@@ -375,6 +502,7 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 	var symbol OperatorSymbol
 	var ret *evaluationStage
 	var operator evaluationOperator
+	var name string
 	var err error
 
 	if !stream.hasNext() {
@@ -402,6 +530,8 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 			rightStage: ret,
 			operator:   noopStageRight,
 			symbol:     NOOP,
+			start:      token.Start,
+			end:        token.End,
 		}
 
 		return ret, nil
@@ -414,7 +544,8 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 		return nil, nil
 
 	case VARIABLE:
-		operator = makeParameterStage(token.Value.(string))
+		name = token.Value.(string)
+		operator = makeParameterStage(name)
 
 	case NUMERIC:
 		fallthrough
@@ -428,6 +559,9 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 	case TIME:
 		symbol = LITERAL
 		operator = makeLiteralStage(float64(token.Value.(time.Time).Unix()))
+	case NULL:
+		symbol = LITERAL
+		operator = makeLiteralStage(nil)
 
 	case PREFIX:
 		stream.rewind()
@@ -435,13 +569,15 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 	}
 
 	if operator == nil {
-		errorMsg := fmt.Sprintf("Unable to plan token kind: '%s', value: '%v'", token.Kind.String(), token.Value)
-		return nil, errors.New(errorMsg)
+		return nil, newTokenError(token, "Unable to plan token kind: '%s', value: '%v'", token.Kind.String(), token.Value)
 	}
 
 	return &evaluationStage{
 		symbol:   symbol,
 		operator: operator,
+		name:     name,
+		start:    token.Start,
+		end:      token.End,
 	}, nil
 }
 
@@ -475,7 +611,7 @@ func findTypeChecks(symbol OperatorSymbol) typeChecks {
 		fallthrough
 	case NREQ:
 		return typeChecks{
-			left:  isString,
+			left:  isStringOrNil,
 			right: isRegexOrString,
 		}
 	case AND:
@@ -487,7 +623,12 @@ func findTypeChecks(symbol OperatorSymbol) typeChecks {
 		}
 	case IN:
 		return typeChecks{
-			right: isArray,
+			right: isArrayOrNil,
+		}
+	case INDEX:
+		return typeChecks{
+			left:  isIndexable,
+			right: isIndexKey,
 		}
 	case BITWISE_LSHIFT:
 		fallthrough
@@ -643,6 +784,30 @@ func mirrorStageSubtree(stages []*evaluationStage) {
 	}
 }
 
+/*
+	Replaces the operators of every stage in the tree with those [numbers] uses for the same symbol, given as [operators].
+	Stages are planned with the default (float64) operators, this must be done before literals are elided.
+*/
+func useNumericMode(stage *evaluationStage, numbers NumericMode, operators map[OperatorSymbol]evaluationOperator) {
+
+	if stage == nil {
+		return
+	}
+
+	switch stage.symbol {
+	case ACCESS:
+		stage.operator = makeAccessorStage(stage.path, stage.rightStage != nil, numbers)
+	default:
+		operator, found := operators[stage.symbol]
+		if found {
+			stage.operator = operator
+		}
+	}
+
+	useNumericMode(stage.leftStage, numbers, operators)
+	useNumericMode(stage.rightStage, numbers, operators)
+}
+
 /*
 	Recurses through all operators in the entire tree, eliding operators where both sides are literals.
 */
@@ -698,12 +863,12 @@ func elideStage(root *evaluationStage) *evaluationStage {
 	}
 
 	// typcheck, since the grammar checker is a bit loose with which operator symbols go together.
-	err = typeCheck(root.leftTypeCheck, leftValue, root.symbol, root.typeErrorFormat)
+	err = typeCheck(root.leftTypeCheck, leftValue, root)
 	if err != nil {
 		return root
 	}
 
-	err = typeCheck(root.rightTypeCheck, rightValue, root.symbol, root.typeErrorFormat)
+	err = typeCheck(root.rightTypeCheck, rightValue, root)
 	if err != nil {
 		return root
 	}
@@ -718,6 +883,11 @@ func elideStage(root *evaluationStage) *evaluationStage {
 		return root
 	}
 
+	// non-finite results (such as "1 / 0") are left to be calculated when evaluating, which may have a policy for them.
+	if isNonFinite(result) {
+		return root
+	}
+
 	return &evaluationStage{
 		symbol:   LITERAL,
 		operator: makeLiteralStage(result),
@@ -28,6 +28,10 @@ func TestTokenKindStrings(test *testing.T) {
 		CLAUSE,
 		CLAUSE_CLOSE,
 		TERNARY,
+		COMMENT,
+		NULL,
+		BRACKET,
+		BRACKET_CLOSE,
 	}
 
 	for _, kind := range kinds {
@@ -0,0 +1,96 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestEvalWithTrace(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("age > 18 && country in ('CN', 'US') && score >= 700")
+
+	parameters := MapParameters(map[string]interface{}{
+		"age":     21,
+		"country": "FR",
+		"score":   800,
+	})
+
+	result, trace, err := expression.EvalWithTrace(parameters)
+	if err != nil || result != false {
+		test.Logf("Expected traced evaluation to return false, got '%v' (%v)", result, err)
+		test.FailNow()
+	}
+
+	expected := "((age > 18) && (country in ('CN', 'US'))) && (score >= 700) -> false (short-circuited)\n" +
+		"  (age > 18) && (country in ('CN', 'US')) -> false\n" +
+		"    age > 18 -> true\n" +
+		"      age -> 21\n" +
+		"    country in ('CN', 'US') -> false\n" +
+		"      country -> 'FR'\n" +
+		"      ('CN', 'US') -> ('CN', 'US')"
+
+	if trace.String() != expected {
+		test.Logf("Expected trace:\n%s\ngot:\n%s", expected, trace.String())
+		test.Fail()
+	}
+
+	// the left and right sides hold the values each operator was given.
+	membership := trace.Left.Right
+	if membership.Operator != IN || membership.Left.Result != "FR" || membership.Right.Operator != SEPARATE {
+		test.Logf("Expected membership trace to record its operands, got %+v", membership)
+		test.Fail()
+	}
+
+	if trace.Right != nil || !trace.ShortCircuited {
+		test.Logf("Expected the last comparison to be short-circuited")
+		test.Fail()
+	}
+}
+
+func TestEvalWithTraceFailure(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("(a + 1) * missing")
+
+	_, trace, err := expression.EvalWithTrace(MapParameters(map[string]interface{}{"a": 1}))
+
+	var missing *MissingParameterError
+	if !errors.As(err, &missing) {
+		test.Logf("Expected traced evaluation to fail with a *MissingParameterError, got '%v'", err)
+		test.FailNow()
+	}
+
+	// parenthesis are not traced themselves.
+	if trace.Left.Operator != PLUS || trace.Left.Result != 2.0 {
+		test.Logf("Expected the left side to be traced through its parenthesis, got %+v", trace.Left)
+		test.Fail()
+	}
+
+	if trace.Err == nil || trace.Right.Err == nil || trace.ShortCircuited {
+		test.Logf("Expected the failure to be recorded in the trace")
+		test.Fail()
+	}
+}
+
+func TestEvalWithTraceJSON(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("a == 1 || b")
+
+	_, trace, _ := expression.EvalWithTrace(MapParameters(map[string]interface{}{"a": 1}))
+
+	encoded, err := json.Marshal(trace)
+	if err != nil {
+		test.Logf("Failed to encode trace: %v", err)
+		test.FailNow()
+	}
+
+	expected := `{"operator":"||","expression":"(a == 1) || b","result":true,"shortCircuited":true,` +
+		`"left":{"operator":"==","expression":"a == 1","result":true,` +
+		`"left":{"operator":"VALUE","expression":"a","result":1},` +
+		`"right":{"operator":"LITERAL","expression":"1","result":1}}}`
+
+	if string(encoded) != expected {
+		test.Logf("Expected trace JSON:\n%s\ngot:\n%s", expected, encoded)
+		test.Fail()
+	}
+}
@@ -0,0 +1,234 @@
+package govaluate
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type schemaParameter struct {
+	dummyParameter
+
+	Created  time.Time
+	Tags     []string
+	Labels   map[string]string
+	Child    *schemaParameter
+	Any      interface{}
+	Nothing  *int
+	internal int
+}
+
+func (this schemaParameter) Describe(ctx context.Context, prefix string, count int) (string, error) {
+	return prefix, nil
+}
+
+func (this schemaParameter) Parent() schemaParameter {
+	return this
+}
+
+func (this schemaParameter) Pair() (int, int) {
+	return 1, 2
+}
+
+func (this schemaParameter) Nothing2() {
+}
+
+type schemaCounter struct {
+	N int
+}
+
+func (this schemaCounter) Twice() int {
+	return this.N * 2
+}
+
+type schemaOwner struct {
+	N    schemaCounter
+	Ptr  *schemaCounter
+	Name string
+}
+
+func (this schemaOwner) Inner() schemaCounter {
+	return this.N
+}
+
+func (this *schemaOwner) Greet(name string) string {
+	return this.Name + name
+}
+
+func TestNewTypeSchema(test *testing.T) {
+
+	types, methods := NewTypeSchema(map[string]reflect.Type{
+		"foo":    reflect.TypeOf(dummyParameterInstance),
+		"fooptr": reflect.TypeOf(&dummyParameterInstance),
+		"number": reflect.TypeOf(1),
+		"schema": reflect.TypeOf(schemaParameter{}),
+	})
+
+	expectedTypes := map[string]ValueType{
+		"foo":                   TYPE_STRUCT,
+		"foo.String":            TYPE_STRING,
+		"foo.Int":               TYPE_NUMBER,
+		"foo.BoolFalse":         TYPE_BOOL,
+		"foo.Nil":               TYPE_ANY,
+		"foo.Nested":            TYPE_STRUCT,
+		"foo.Nested.Funk":       TYPE_STRING,
+		"foo.Nested.Dunk":       TYPE_STRING,
+		"foo.Func":              TYPE_STRING,
+		"foo.Func2":             TYPE_STRING,
+		"foo.FuncArgStr":        TYPE_STRING,
+		"foo.AlwaysFail":        TYPE_ANY,
+		"fooptr":                TYPE_STRUCT | TYPE_NIL,
+		"fooptr.Func3":          TYPE_STRING,
+		"fooptr.Nested.Funk":    TYPE_STRING,
+		"number":                TYPE_NUMBER,
+		"schema.Int":            TYPE_NUMBER,
+		"schema.Created":        TYPE_TIME,
+		"schema.Tags":           TYPE_ARRAY,
+		"schema.Labels":         TYPE_MAP,
+		"schema.Child":          TYPE_STRUCT | TYPE_NIL,
+		"schema.Child.Int":      TYPE_NUMBER,
+		"schema.Child.Describe": TYPE_STRING,
+		"schema.Any":            TYPE_ANY,
+		"schema.Nothing":        TYPE_NUMBER | TYPE_NIL,
+		"schema.Describe":       TYPE_STRING,
+		"schema.Parent":         TYPE_STRUCT,
+		"schema.Pair":           TYPE_NUMBER,
+	}
+
+	for path, expected := range expectedTypes {
+
+		actual, found := types[path]
+		if !found || actual != expected {
+			test.Logf("Expected '%s' to be %v, got %v (found: %v)", path, expected, actual, found)
+			test.Fail()
+		}
+	}
+
+	// unexported fields, methods of pointers given as values, anything on the result of a method, and methods without results can't be accessed.
+	for _, path := range []string{"foo.Func3", "schema.internal", "schema.Parent.Func", "schema.Parent.Parent", "schema.Parent.Int", "schema.Nothing2", "schema.Labels.x", "schema.Created.Unix", "schema.dummyParameter", "schema.Parent.Parent.Parent"} {
+
+		_, found := types[path]
+		if found {
+			test.Logf("Expected '%s' not to be in the schema", path)
+			test.Fail()
+		}
+	}
+
+	expectedMethods := map[string]MethodSignature{
+		"foo.FuncArgStr":  MethodSignature{Arguments: []ValueType{TYPE_STRING}, Result: TYPE_STRING},
+		"foo.Func2":       MethodSignature{Result: TYPE_STRING, ReturnsError: true},
+		"foo.Nested.Dunk": MethodSignature{Arguments: []ValueType{TYPE_STRING}, Result: TYPE_STRING},
+		"schema.Describe": MethodSignature{Arguments: []ValueType{TYPE_STRING, TYPE_NUMBER}, Result: TYPE_STRING, ReturnsError: true},
+		"schema.Pair":     MethodSignature{Result: TYPE_NUMBER},
+	}
+
+	for path, expected := range expectedMethods {
+
+		if !reflect.DeepEqual(methods[path], expected) {
+			test.Logf("Expected method '%s' to be %v, got %v", path, expected, methods[path])
+			test.Fail()
+		}
+	}
+
+	if _, found := methods["foo.String"]; found {
+		test.Logf("Expected fields not to be methods")
+		test.Fail()
+	}
+}
+
+/*
+	A derived schema must pass expressions that evaluate, and fail those with the wrong types.
+*/
+func TestNewTypeSchemaCheck(test *testing.T) {
+
+	schema, _ := NewTypeSchema(map[string]reflect.Type{
+		"foo": reflect.TypeOf(dummyParameterInstance),
+	})
+
+	for _, input := range []string{"foo.Int + foo.FuncArgStr('x')", "foo.Nested.Funk + foo.String", "foo.Nested.Dunk('x') + foo.Nested.Funk", "foo.Int > 5 && !foo.BoolFalse"} {
+
+		expression, _ := NewEvaluableExpression(input)
+		err := expression.Check(schema)
+		if err != nil {
+			test.Logf("Expected '%s' to pass, got %v", input, err)
+			test.Fail()
+		}
+	}
+
+	for _, input := range []string{"foo.String > 5", "foo.BoolFalse + 1", "-foo.Func()"} {
+
+		expression, _ := NewEvaluableExpression(input)
+		err := expression.Check(schema)
+		if err == nil {
+			test.Logf("Expected '%s' to fail", input)
+			test.Fail()
+		}
+	}
+}
+
+/*
+	Every path in a derived schema must evaluate to a value of the type it's listed with, and nothing that fails to evaluate can be listed.
+*/
+func TestNewTypeSchemaEvaluation(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"s": &schemaOwner{N: schemaCounter{N: 3}, Ptr: &schemaCounter{N: 4}, Name: "x"},
+	}
+
+	types, methods := NewTypeSchema(map[string]reflect.Type{
+		"s": reflect.TypeOf(parameters["s"]),
+	})
+
+	expectedPaths := []string{"s", "s.N", "s.N.N", "s.N.Twice", "s.Ptr", "s.Ptr.N", "s.Ptr.Twice", "s.Name", "s.Inner", "s.Greet"}
+	if len(types) != len(expectedPaths) {
+		test.Logf("Expected the paths %v, got %v", expectedPaths, types)
+		test.Fail()
+	}
+
+	for _, path := range expectedPaths {
+
+		expected, found := types[path]
+		if !found {
+			test.Logf("Expected '%s' to be in the schema", path)
+			test.Fail()
+			continue
+		}
+
+		input := path
+		if signature, isMethod := methods[path]; isMethod {
+
+			var arguments []string
+			for _, argument := range signature.Arguments {
+				if argument == TYPE_STRING {
+					arguments = append(arguments, "'y'")
+				} else {
+					arguments = append(arguments, "1")
+				}
+			}
+			input += "(" + strings.Join(arguments, ", ") + ")"
+		}
+
+		expression, _ := NewEvaluableExpression(input)
+
+		inferred, err := expression.InferType(types)
+		if err != nil || inferred != expected {
+			test.Logf("Expected '%s' to infer as %v, got %v (%v)", input, expected, inferred, err)
+			test.Fail()
+		}
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil || valueTypeOf(reflect.TypeOf(result))&expected == 0 {
+			test.Logf("Expected '%s' to evaluate to %v, got %v (%v)", input, expected, result, err)
+			test.Fail()
+		}
+	}
+
+	expression, _ := NewEvaluableExpression("s.N.Twice()")
+	result, err := expression.Evaluate(parameters)
+	if result != 6.0 || err != nil {
+		test.Logf("Expected 6, got %v (%v)", result, err)
+		test.Fail()
+	}
+}
@@ -0,0 +1,585 @@
+package govaluate
+
+import (
+	"math"
+)
+
+/*
+	A planned stage (and everything beneath it) which always evaluates to a float64, compiled into a function which never boxes it.
+	Returns false if it couldn't be evaluated that way - such as when a parameter is missing, or isn't a number -
+	in which case the expression should be evaluated normally, to get its actual result or error.
+*/
+type floatStage func(parameters Parameters) (float64, bool)
+
+/*
+	Same as `floatStage`, but for stages which always evaluate to a bool.
+*/
+type boolStage func(parameters Parameters) (bool, bool)
+
+/*
+	Compiles the given planned stage tree into a `floatStage`.
+	Returns nil if the stage can't always evaluate to a float64, such as if it uses strings, arrays, functions or accessors.
+*/
+func compileFloatStage(stage *evaluationStage) floatStage {
+
+	if stage == nil {
+		return nil
+	}
+
+	switch stage.symbol {
+
+	case LITERAL:
+		value, _, _, _ := stage.operator(nil, nil, nil, nil, nil)
+		number, ok := value.(float64)
+		if !ok {
+			return nil
+		}
+		return func(parameters Parameters) (float64, bool) {
+			return number, true
+		}
+
+	case VALUE:
+		return compileFloatParameter(stage.name)
+
+	case NOOP:
+		return compileFloatStage(stage.rightStage)
+
+	case NEGATE, BITWISE_NOT:
+		return compileFloatUnary(stage)
+
+	case TERNARY_FALSE:
+		return compileFloatTernary(stage)
+
+	case BITWISE_AND, BITWISE_OR, BITWISE_XOR, BITWISE_LSHIFT, BITWISE_RSHIFT:
+		return compileFloatBitwise(stage)
+
+	case COALESCE:
+		return compileFloatCoalesce(stage)
+	}
+
+	calculate := findArithmeticOperator(stage.symbol)
+	if calculate == nil {
+		return nil
+	}
+
+	left := compileFloatStage(stage.leftStage)
+	right := compileFloatStage(stage.rightStage)
+	if left == nil || right == nil {
+		return nil
+	}
+
+	return func(parameters Parameters) (float64, bool) {
+
+		leftValue, ok := left(parameters)
+		if !ok {
+			return 0, false
+		}
+
+		rightValue, ok := right(parameters)
+		if !ok {
+			return 0, false
+		}
+		return calculate(leftValue, rightValue), true
+	}
+}
+
+/*
+	Compiles the given planned stage tree into a `boolStage`.
+	Returns nil if the stage can't always evaluate to a bool.
+*/
+func compileBoolStage(stage *evaluationStage) boolStage {
+
+	if stage == nil {
+		return nil
+	}
+
+	switch stage.symbol {
+
+	case LITERAL:
+		value, _, _, _ := stage.operator(nil, nil, nil, nil, nil)
+		boolean, ok := value.(bool)
+		if !ok {
+			return nil
+		}
+		return func(parameters Parameters) (bool, bool) {
+			return boolean, true
+		}
+
+	case VALUE:
+		return compileBoolParameter(stage.name)
+
+	case NOOP:
+		return compileBoolStage(stage.rightStage)
+
+	case INVERT:
+		right := compileBoolStage(stage.rightStage)
+		if right == nil {
+			return nil
+		}
+		return func(parameters Parameters) (bool, bool) {
+
+			value, ok := right(parameters)
+			return !value, ok
+		}
+
+	case AND, OR:
+		return compileBoolLogical(stage)
+
+	case TERNARY_FALSE:
+		return compileBoolTernary(stage)
+
+	case COALESCE:
+		return compileBoolCoalesce(stage)
+	}
+
+	compare := findComparator(stage.symbol)
+	if compare == nil {
+		return nil
+	}
+
+	left := compileFloatStage(stage.leftStage)
+	right := compileFloatStage(stage.rightStage)
+	if left == nil || right == nil {
+		return compileBoolEquality(stage)
+	}
+
+	return func(parameters Parameters) (bool, bool) {
+
+		leftValue, ok := left(parameters)
+		if !ok {
+			return false, false
+		}
+
+		rightValue, ok := right(parameters)
+		if !ok {
+			return false, false
+		}
+		return compare(leftValue, rightValue), true
+	}
+}
+
+/*
+	Parameters are read the same way `sanitizedParameters` would read them, without boxing the converted number.
+	`TypedParameters` are read without unboxing anything at all.
+*/
+func compileFloatParameter(name string) floatStage {
+
+	return func(parameters Parameters) (float64, bool) {
+
+		typed, ok := parameters.(*TypedParameters)
+		if ok {
+			return typed.getFloat64(name)
+		}
+
+		value, err := parameters.Get(name)
+		if err != nil {
+			return 0, false
+		}
+		return typedFloat64(value)
+	}
+}
+
+func compileBoolParameter(name string) boolStage {
+
+	return func(parameters Parameters) (bool, bool) {
+
+		typed, ok := parameters.(*TypedParameters)
+		if ok {
+			return typed.getBool(name)
+		}
+
+		value, err := parameters.Get(name)
+		if err != nil {
+			return false, false
+		}
+
+		boolean, ok := value.(bool)
+		return boolean, ok
+	}
+}
+
+func compileFloatUnary(stage *evaluationStage) floatStage {
+
+	right := compileFloatStage(stage.rightStage)
+	if right == nil {
+		return nil
+	}
+
+	if stage.symbol == BITWISE_NOT {
+		return func(parameters Parameters) (float64, bool) {
+
+			value, ok := right(parameters)
+			if !ok {
+				return 0, false
+			}
+
+			integer, ok := float64ToInt64(value)
+			return float64(^integer), ok
+		}
+	}
+
+	return func(parameters Parameters) (float64, bool) {
+
+		value, ok := right(parameters)
+		return -value, ok
+	}
+}
+
+/*
+	Operands which `bitwiseOperand` would fail on aren't calculated, so that `Eval` can return its error.
+*/
+func compileFloatBitwise(stage *evaluationStage) floatStage {
+
+	calculate := findBitwiseOperator(stage.symbol)
+
+	left := compileFloatStage(stage.leftStage)
+	right := compileFloatStage(stage.rightStage)
+	if left == nil || right == nil {
+		return nil
+	}
+
+	return func(parameters Parameters) (float64, bool) {
+
+		leftValue, ok := left(parameters)
+		if !ok {
+			return 0, false
+		}
+
+		rightValue, ok := right(parameters)
+		if !ok {
+			return 0, false
+		}
+
+		leftInteger, leftOk := float64ToInt64(leftValue)
+		rightInteger, rightOk := float64ToInt64(rightValue)
+		if !leftOk || !rightOk {
+			return 0, false
+		}
+		return calculate(leftInteger, rightInteger)
+	}
+}
+
+/*
+	"a ? b : c" is planned as ":" with "a ? b" on its left. Either side of ":" can only be a number if both are.
+*/
+func compileFloatTernary(stage *evaluationStage) floatStage {
+
+	if stage.leftStage == nil || stage.leftStage.symbol != TERNARY_TRUE {
+		return nil
+	}
+
+	condition := compileBoolStage(stage.leftStage.leftStage)
+	then := compileFloatStage(stage.leftStage.rightStage)
+	otherwise := compileFloatStage(stage.rightStage)
+	if condition == nil || then == nil || otherwise == nil {
+		return nil
+	}
+
+	return func(parameters Parameters) (float64, bool) {
+
+		value, ok := condition(parameters)
+		if !ok {
+			return 0, false
+		}
+
+		if value {
+			return then(parameters)
+		}
+		return otherwise(parameters)
+	}
+}
+
+func compileBoolTernary(stage *evaluationStage) boolStage {
+
+	if stage.leftStage == nil || stage.leftStage.symbol != TERNARY_TRUE {
+		return nil
+	}
+
+	condition := compileBoolStage(stage.leftStage.leftStage)
+	then := compileBoolStage(stage.leftStage.rightStage)
+	otherwise := compileBoolStage(stage.rightStage)
+	if condition == nil || then == nil || otherwise == nil {
+		return nil
+	}
+
+	return func(parameters Parameters) (bool, bool) {
+
+		value, ok := condition(parameters)
+		if !ok {
+			return false, false
+		}
+
+		if value {
+			return then(parameters)
+		}
+		return otherwise(parameters)
+	}
+}
+
+func compileBoolLogical(stage *evaluationStage) boolStage {
+
+	left := compileBoolStage(stage.leftStage)
+	right := compileBoolStage(stage.rightStage)
+	if left == nil || right == nil {
+		return nil
+	}
+
+	// "false && x" is always false, and "true || x" always true, without evaluating x.
+	shortCircuit := stage.symbol == OR
+
+	return func(parameters Parameters) (bool, bool) {
+
+		value, ok := left(parameters)
+		if !ok {
+			return false, false
+		}
+
+		if value == shortCircuit {
+			return value, true
+		}
+		return right(parameters)
+	}
+}
+
+/*
+	"a ?? b" is only b if a is nil, which only parameters (and "??" of them) can be, so anything else on the left is used as it is.
+	Parameters which are neither nil nor numbers can't be given as a float64, so `Eval` is left to find what they are.
+*/
+func compileFloatCoalesce(stage *evaluationStage) floatStage {
+
+	left := compileFloatStage(stage.leftStage)
+	right := compileFloatStage(stage.rightStage)
+	if left == nil || right == nil {
+		return nil
+	}
+
+	isNil := compileNilTest(stage.leftStage)
+	if isNil == nil {
+		return left
+	}
+
+	return func(parameters Parameters) (float64, bool) {
+
+		value, ok := left(parameters)
+		if ok {
+			return value, true
+		}
+
+		if isNil(parameters) {
+			return right(parameters)
+		}
+		return 0, false
+	}
+}
+
+func compileBoolCoalesce(stage *evaluationStage) boolStage {
+
+	left := compileBoolStage(stage.leftStage)
+	right := compileBoolStage(stage.rightStage)
+	if left == nil || right == nil {
+		return nil
+	}
+
+	isNil := compileNilTest(stage.leftStage)
+	if isNil == nil {
+		return left
+	}
+
+	return func(parameters Parameters) (bool, bool) {
+
+		value, ok := left(parameters)
+		if ok {
+			return value, true
+		}
+
+		if isNil(parameters) {
+			return right(parameters)
+		}
+		return false, false
+	}
+}
+
+/*
+	Compiles a function which returns true if the given stage evaluates to nil,
+	or returns nil if it never can - that is, if it isn't a parameter, or "??" of parameters.
+*/
+func compileNilTest(stage *evaluationStage) func(parameters Parameters) bool {
+
+	if stage == nil {
+		return nil
+	}
+
+	switch stage.symbol {
+
+	case NOOP:
+		return compileNilTest(stage.rightStage)
+
+	case VALUE:
+		name := stage.name
+		return func(parameters Parameters) bool {
+			return isNilParameter(parameters, name)
+		}
+
+	case COALESCE:
+		left := compileNilTest(stage.leftStage)
+		right := compileNilTest(stage.rightStage)
+		if left == nil || right == nil {
+			return nil
+		}
+		return func(parameters Parameters) bool {
+			return left(parameters) && right(parameters)
+		}
+	}
+	return nil
+}
+
+/*
+	Returns true if the parameter of the given [name] is there, and nil.
+*/
+func isNilParameter(parameters Parameters, name string) bool {
+
+	typed, ok := parameters.(*TypedParameters)
+	if ok {
+		return typed.isNil(name)
+	}
+
+	value, err := parameters.Get(name)
+	return err == nil && value == nil
+}
+
+/*
+	"==" and "!=" between two sides which can't be numbers compare them as bools, as in "(a > b) == c".
+*/
+func compileBoolEquality(stage *evaluationStage) boolStage {
+
+	if stage.symbol != EQ && stage.symbol != NEQ {
+		return nil
+	}
+
+	left := compileBoolStage(stage.leftStage)
+	right := compileBoolStage(stage.rightStage)
+	if left == nil || right == nil {
+		return nil
+	}
+
+	equal := stage.symbol == EQ
+
+	return func(parameters Parameters) (bool, bool) {
+
+		leftValue, ok := left(parameters)
+		if !ok {
+			return false, false
+		}
+
+		rightValue, ok := right(parameters)
+		if !ok {
+			return false, false
+		}
+		return (leftValue == rightValue) == equal, true
+	}
+}
+
+/*
+	Returns the float64 held by [value], if it is one of the types that `sanitizedParameters` converts to a float64.
+*/
+func typedFloat64(value interface{}) (float64, bool) {
+
+	switch typed := value.(type) {
+	case float64:
+		return typed, true
+	case float32:
+		return float64(typed), true
+	case int:
+		return float64(typed), true
+	case int8:
+		return float64(typed), true
+	case int16:
+		return float64(typed), true
+	case int32:
+		return float64(typed), true
+	case int64:
+		return float64(typed), true
+	case uint8:
+		return float64(typed), true
+	case uint16:
+		return float64(typed), true
+	case uint32:
+		return float64(typed), true
+	case uint64:
+		return float64(typed), true
+	}
+	return 0, false
+}
+
+/*
+	Returns the float64-only equivalent of the given arithmetic operator, or nil if it isn't one.
+*/
+func findArithmeticOperator(symbol OperatorSymbol) func(left, right float64) float64 {
+
+	switch symbol {
+	case PLUS:
+		return func(left, right float64) float64 { return left + right }
+	case MINUS:
+		return func(left, right float64) float64 { return left - right }
+	case MULTIPLY:
+		return func(left, right float64) float64 { return left * right }
+	case DIVIDE:
+		return func(left, right float64) float64 { return left / right }
+	case MODULUS:
+		return func(left, right float64) float64 { return math.Mod(left, right) }
+	case EXPONENT:
+		return func(left, right float64) float64 { return math.Pow(left, right) }
+	}
+	return nil
+}
+
+/*
+	Returns the int64-only equivalent of the given bitwise operator, which returns false for shifts by negative amounts, by 64 or more,
+	or which give more than an int64 can hold.
+*/
+func findBitwiseOperator(symbol OperatorSymbol) func(left, right int64) (float64, bool) {
+
+	switch symbol {
+	case BITWISE_AND:
+		return func(left, right int64) (float64, bool) { return float64(left & right), true }
+	case BITWISE_OR:
+		return func(left, right int64) (float64, bool) { return float64(left | right), true }
+	case BITWISE_XOR:
+		return func(left, right int64) (float64, bool) { return float64(left ^ right), true }
+	case BITWISE_LSHIFT:
+		return func(left, right int64) (float64, bool) {
+
+			if right < 0 || right >= 64 {
+				return 0, false
+			}
+
+			ret := left << uint64(right)
+			return float64(ret), ret>>uint64(right) == left
+		}
+	case BITWISE_RSHIFT:
+		return func(left, right int64) (float64, bool) { return float64(left >> uint64(right)), right >= 0 && right < 64 }
+	}
+	return nil
+}
+
+/*
+	Returns the float64-only equivalent of the given comparator, or nil if it isn't one.
+*/
+func findComparator(symbol OperatorSymbol) func(left, right float64) bool {
+
+	switch symbol {
+	case GT:
+		return func(left, right float64) bool { return left > right }
+	case LT:
+		return func(left, right float64) bool { return left < right }
+	case GTE:
+		return func(left, right float64) bool { return left >= right }
+	case LTE:
+		return func(left, right float64) bool { return left <= right }
+	case EQ:
+		return func(left, right float64) bool { return left == right }
+	case NEQ:
+		return func(left, right float64) bool { return left != right }
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package govaluate
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+/*
+	Expressions which `EvalFloat64` and `EvalBool` must evaluate exactly like `Eval`, given `typedParameters`.
+	Those which can be evaluated without boxing anything are marked as typed.
+*/
+var typedInputs = []struct {
+	input string
+	typed bool
+}{
+	{"price * quantity * (1 - discount) + 2.5", true},
+	{"price / 0", true},
+	{"-price % 3 ** 2", true},
+	{"quantity & 6 | 1 ^ 8 << 2 >> 1 + ~quantity", true},
+	{"vip ? price * 0.9 : price", true},
+	{"price > 10 && quantity <= 3 || !vip", true},
+	{"false && missing", true},
+	{"price == 12.5 && quantity != 2", true},
+	{"vip ? quantity > 2 : false", true},
+	{"small + 1", true},
+	{"price", true},
+	{"vip", true},
+	{"missing + 1", true},
+	{"name + price", true},
+	{"name == 'abc'", false},
+	{"vip ? price", false},
+	{"price > 10 ? 'high' : 'low'", false},
+	{"price ?? 1", true},
+	{"none ?? price", true},
+	{"none ?? vip", true},
+	{"(price > 10) == vip", true},
+	{"price ?? 'none'", false},
+	{"price in (1, 12.5)", false},
+}
+
+var typedParameters = map[string]interface{}{
+	"price":    12.5,
+	"quantity": 3,
+	"discount": 0.2,
+	"vip":      true,
+	"small":    int8(4),
+	"name":     "abc",
+	"none":     nil,
+}
+
+/*
+	EvalFloat64 and EvalBool must return the same result (or error) as Eval would, however the parameters are given.
+*/
+func TestTypedEvaluation(test *testing.T) {
+
+	var typed TypedParameters
+
+	for name, value := range typedParameters {
+		typed.Set(name, value)
+	}
+
+	for _, parameters := range []Parameters{MapParameters(typedParameters), &typed} {
+		for _, typedInput := range typedInputs {
+
+			expression, err := NewEvaluableExpression(typedInput.input)
+			if err != nil {
+				test.Logf("Failed to parse '%s': %s", typedInput.input, err)
+				test.Fail()
+				continue
+			}
+
+			if typedInput.typed != (expression.floatStages != nil || expression.boolStages != nil) {
+				test.Logf("Expected '%s' to be typed: %v", typedInput.input, typedInput.typed)
+				test.Fail()
+			}
+
+			expected, expectedErr := expression.Eval(parameters)
+
+			floatResult, floatErr := expression.EvalFloat64(parameters)
+			boolResult, boolErr := expression.EvalBool(parameters)
+
+			switch expected.(type) {
+			case float64:
+				if !sameFloat64(floatResult, expected.(float64)) || floatErr != nil || boolErr == nil {
+					test.Logf("Typed evaluation of '%s' differs", typedInput.input)
+					test.Logf("Expected '%v', got '%v' (%v) and '%v' (%v)", expected, floatResult, floatErr, boolResult, boolErr)
+					test.Fail()
+				}
+			case bool:
+				if boolResult != expected || boolErr != nil || floatErr == nil {
+					test.Logf("Typed evaluation of '%s' differs", typedInput.input)
+					test.Logf("Expected '%v', got '%v' (%v) and '%v' (%v)", expected, boolResult, boolErr, floatResult, floatErr)
+					test.Fail()
+				}
+			default:
+				if floatErr == nil || boolErr == nil {
+					test.Logf("Expected typed evaluation of '%s' to fail", typedInput.input)
+					test.Fail()
+				}
+				if expectedErr != nil && (!reflect.DeepEqual(floatErr, expectedErr) || !reflect.DeepEqual(boolErr, expectedErr)) {
+					test.Logf("Typed evaluation of '%s' failed differently", typedInput.input)
+					test.Logf("Expected '%v', got '%v' and '%v'", expectedErr, floatErr, boolErr)
+					test.Fail()
+				}
+			}
+		}
+	}
+}
+
+/*
+	Numeric and boolean expressions must evaluate without allocating, whether given TypedParameters or MapParameters,
+	and give the same results as `Eval`.
+*/
+func TestTypedEvaluationAllocations(test *testing.T) {
+
+	var typed TypedParameters
+
+	typed.SetFloat64("price", 12.5)
+	typed.SetFloat64("quantity", 3)
+	typed.SetFloat64("discount", 0.2)
+	typed.SetBool("vip", true)
+	typed.Set("coupon", nil)
+
+	mapped := MapParameters{
+		"price":    12.5,
+		"quantity": 3,
+		"discount": 0.2,
+		"vip":      true,
+		"coupon":   nil,
+	}
+
+	numericInputs := []string{
+		"vip ? price * quantity * (1 - discount) : price * quantity",
+		"-price % 5 + quantity ** 2 / 2",
+		"((quantity | 4) << 2 >> 1) ^ ~quantity & 7",
+		"coupon ?? discount * 100",
+		"discount ?? 5",
+		"(coupon ?? coupon) ?? 5",
+		"price > 10 && !vip ? 1 : 2",
+	}
+
+	booleanInputs := []string{
+		"price * quantity > 30 && !(discount >= 0.5) || vip",
+		"(price > 10) == vip",
+		"(price > 10) != !vip",
+		"price != quantity",
+		"coupon ?? vip",
+		"vip ? price > 1 : false",
+	}
+
+	for _, parameters := range []Parameters{&typed, mapped} {
+
+		for _, input := range numericInputs {
+
+			expression, _ := NewEvaluableExpression(input)
+			expected, _ := expression.Evaluate(mapped)
+
+			result, err := expression.EvalFloat64(parameters)
+			if err != nil || result != expected {
+				test.Logf("Expected '%s' to be %v, got %v (%v)", input, expected, result, err)
+				test.Fail()
+			}
+
+			allocations := testing.AllocsPerRun(100, func() {
+				expression.EvalFloat64(parameters)
+			})
+			if allocations != 0 {
+				test.Logf("EvalFloat64 of '%s' allocated %v times per run with %T", input, allocations, parameters)
+				test.Fail()
+			}
+		}
+
+		for _, input := range booleanInputs {
+
+			expression, _ := NewEvaluableExpression(input)
+			expected, _ := expression.Evaluate(mapped)
+
+			result, err := expression.EvalBool(parameters)
+			if err != nil || result != expected {
+				test.Logf("Expected '%s' to be %v, got %v (%v)", input, expected, result, err)
+				test.Fail()
+			}
+
+			allocations := testing.AllocsPerRun(100, func() {
+				expression.EvalBool(parameters)
+			})
+			if allocations != 0 {
+				test.Logf("EvalBool of '%s' allocated %v times per run with %T", input, allocations, parameters)
+				test.Fail()
+			}
+		}
+	}
+
+	numeric, _ := NewEvaluableExpression(numericInputs[0])
+
+	// setting a parameter again must reuse it.
+	allocations := testing.AllocsPerRun(100, func() {
+		typed.SetFloat64("price", 13)
+		numeric.EvalFloat64(&typed)
+	})
+	if allocations != 0 {
+		test.Logf("Setting TypedParameters allocated %v times per run", allocations)
+		test.Fail()
+	}
+}
+
+func sameFloat64(left, right float64) bool {
+	return left == right || (math.IsNaN(left) && math.IsNaN(right))
+}